@@ -0,0 +1,118 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// appendMu serializes the read and rewrite of the -append file within this
+// process. The inputs of a -concurrency batch run in processes of their own,
+// which it cannot coordinate, so checkAppend rejects that combination.
+var appendMu sync.Mutex
+
+func checkAppend(config Config) error {
+	if config.Append == "" {
+		return nil
+	}
+	switch {
+	case !hasPostStep(config, "create_emacs_org_notes"):
+		return errors.New("-append requires -post create_emacs_org_notes")
+	case config.SummaryLanguages != "":
+		return errors.New("-append cannot be combined with -summary-languages, which writes one file per language")
+	case config.OrgPathTemplate != "":
+		return errors.New("-append and -org-path-template both name the notes file; use one")
+	case config.OutputURI != "":
+		return errors.New("-append adds to a local file and cannot be combined with -output-uri")
+	case config.Stdout || config.NoOutput:
+		return errors.New("-append cannot be combined with -stdout or -no-output")
+	case config.Concurrency > 1:
+		return errors.New("-append cannot be combined with -concurrency above 1, since the inputs would rewrite the file over each other's notes")
+	}
+	if info, err := os.Stat(config.Append); err == nil && info.IsDir() {
+		return fmt.Errorf("-append %q is a directory; name the org file to add to", config.Append)
+	}
+	return nil
+}
+
+// orgEntry turns a complete notes file into a section of a larger one: the
+// #+title: becomes a heading level+1 stars deep, tagged with the
+// #+filetags:, the #+date: and #+subtitle: values become its first lines,
+// the other keywords before the first heading are dropped, and every
+// heading is demoted one level.
+func orgEntry(orgContent string, level int) string {
+	title, tags := "Notes", ""
+	var meta, head []string
+	lines := strings.Split(strings.TrimSpace(orgContent), "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "*") && strings.HasPrefix(strings.TrimLeft(line, "*"), " ") {
+			break
+		}
+		keyword, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.HasPrefix(keyword, "#+") || strings.Contains(keyword, " ") {
+			head = append(head, line)
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(keyword) {
+		case "#+title":
+			if value != "" {
+				title = value
+			}
+		case "#+date", "#+subtitle":
+			if value != "" {
+				meta = append(meta, value)
+			}
+		case "#+filetags":
+			tags = value
+		}
+	}
+
+	heading := strings.Repeat("*", level+1) + " " + title
+	if tags != "" {
+		heading += " " + tags
+	}
+	entry := append([]string{heading}, meta...)
+	if text := strings.TrimSpace(strings.Join(head, "\n")); text != "" {
+		entry = append(entry, text)
+	}
+	if i < len(lines) {
+		entry = append(entry, "", shiftOrgHeadings(strings.Join(lines[i:], "\n"), 1))
+	}
+	return strings.Join(entry, "\n") + "\n"
+}
+
+// appendOrgNotes adds the notes as a new section at the end of the -append
+// file, creating it with a #+title: of its file name when it does not
+// exist.
+func appendOrgNotes(config Config, orgContent string) error {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	existing, err := os.ReadFile(config.Append)
+	if errors.Is(err, os.ErrNotExist) {
+		title := strings.TrimSuffix(filepath.Base(config.Append), filepath.Ext(config.Append))
+		existing = []byte("#+title: " + title + "\n")
+		if err := os.MkdirAll(filepath.Dir(config.Append), 0o755); err != nil {
+			return fmt.Errorf("creating the -append directory: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("reading -append file: %w", err)
+	}
+
+	content := orgEntry(orgContent, config.HeadingOffset)
+	if previous := strings.TrimRight(string(existing), "\n"); previous != "" {
+		content = previous + "\n\n" + content
+	}
+	if err := writeFileAtomic(config.Append, []byte(content)); err != nil {
+		return fmt.Errorf("appending to %s: %w", config.Append, err)
+	}
+	log.Printf("Notes appended to %s\n", config.Append)
+	return nil
+}
@@ -0,0 +1,118 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOrgEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes string
+		level int
+		want  string
+	}{
+		{
+			"title, date, and headings",
+			"#+title: Weekly sync\n#+date: <2024-03-05 Tue>\n#+startup: overview\n\n* Decisions\nShip it.\n** Follow-up\n",
+			0,
+			"* Weekly sync\n<2024-03-05 Tue>\n\n** Decisions\nShip it.\n*** Follow-up\n",
+		},
+		{
+			"abstract and text before the first heading",
+			"#+title: Talk\n#+date: [2024-03-05 Tue]\n#+subtitle: A short talk.\nIntro line.\n\n* Notes\n",
+			0,
+			"* Talk\n[2024-03-05 Tue]\nA short talk.\nIntro line.\n\n** Notes\n",
+		},
+		{
+			"file tags",
+			"#+title: Sync\n#+filetags: :meeting:apollo:\n\n* Decisions\n",
+			0,
+			"* Sync :meeting:apollo:\n\n** Decisions\n",
+		},
+		{
+			"heading offset",
+			"#+title: Talk\n\n** Notes\n",
+			1,
+			"** Talk\n\n*** Notes\n",
+		},
+		{
+			"no title",
+			"* Notes\n#+begin_src\n* not a heading\n#+end_src\n",
+			0,
+			"* Notes\n\n** Notes\n#+begin_src\n* not a heading\n#+end_src\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orgEntry(tt.notes, tt.level); got != tt.want {
+				t.Errorf("orgEntry() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendOrgNotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes", "meeting-notes.org")
+	config := Config{Append: path}
+
+	if err := appendOrgNotes(config, "#+title: First\n\n* Agenda\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendOrgNotes(config, "#+title: Second\n\n* Agenda\n"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#+title: meeting-notes\n\n* First\n\n** Agenda\n\n* Second\n\n** Agenda\n"
+	if string(got) != want {
+		t.Errorf("appended file =\n%q\nwant\n%q", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("#+title: Log\n* Old entry"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendOrgNotes(config, "#+title: Third\n"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = os.ReadFile(path)
+	if want := "#+title: Log\n* Old entry\n\n* Third\n"; string(got) != want {
+		t.Errorf("appended file =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestCheckAppend(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"not set", Config{}, ""},
+		{"ok", Config{Append: filepath.Join(dir, "notes.org"), PostProcessCmd: "create_emacs_org_notes"}, ""},
+		{"no org notes", Config{Append: "notes.org", PostProcessCmd: "create_glossary"}, "requires -post create_emacs_org_notes"},
+		{"languages", Config{Append: "notes.org", PostProcessCmd: "create_emacs_org_notes", SummaryLanguages: "en,de"}, "-summary-languages"},
+		{"path template", Config{Append: "notes.org", PostProcessCmd: "create_emacs_org_notes", OrgPathTemplate: "{{.BaseName}}.org"}, "-org-path-template"},
+		{"stdout", Config{Append: "notes.org", PostProcessCmd: "create_emacs_org_notes", Stdout: true}, "-stdout"},
+		{"concurrency", Config{Append: "notes.org", PostProcessCmd: "create_emacs_org_notes", Concurrency: 4}, "-concurrency above 1"},
+		{"directory", Config{Append: dir, PostProcessCmd: "create_emacs_org_notes"}, "is a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAppend(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkAppend() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkAppend() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
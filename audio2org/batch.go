@@ -0,0 +1,278 @@
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// fileFlags collects repeated -file flags.
+type fileFlags []string
+
+func (f *fileFlags) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *fileFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// expandFileArgs expands directories and glob patterns among the -file
+// arguments, keeping the order they were given in and dropping repeats. A
+// directory stands for the files directly in it with one of exts, or with
+// an extension Whisper accepts when exts is empty; glob matches are only
+// filtered by exts when it is given. A directory or pattern that matches
+// nothing is an error so a typo is not mistaken for an empty batch.
+func expandFileArgs(args, exts []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	for _, arg := range args {
+		matches := []string{arg}
+		info, err := os.Stat(arg)
+		switch {
+		case isAudioURL(arg):
+			// Downloaded by runInput.
+		case err == nil && info.IsDir():
+			dirExts := exts
+			if len(dirExts) == 0 {
+				dirExts = supportedExtensions
+			}
+			if matches, err = scanAudioDir(arg, dirExts); err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("-file directory %q has no %s files", arg, strings.Join(dirExts, " "))
+			}
+		case err != nil && strings.ContainsAny(arg, "*?["):
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("invalid -file pattern %q: %w", arg, err)
+			}
+			if len(exts) > 0 {
+				matches = slices.DeleteFunc(matches, func(match string) bool { return !hasExtension(match, exts) })
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("-file pattern %q matches no files", arg)
+			}
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// scanAudioDir lists the files directly in dir with one of exts, in name
+// order.
+func scanAudioDir(dir string, exts []string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading -file directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && hasExtension(entry.Name(), exts) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+func hasExtension(path string, exts []string) bool {
+	return slices.Contains(exts, strings.ToLower(filepath.Ext(path)))
+}
+
+// parseExtensions parses -ext into lowercase extensions with a leading dot,
+// so "m4a, .MP3" gives .m4a and .mp3.
+func parseExtensions(value string) []string {
+	var exts []string
+	for _, ext := range strings.Split(value, ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if !slices.Contains(exts, ext) {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// runBatch processes each input in turn, naming its outputs after the
+// input, and keeps going when one fails. Once ctx is done no further input
+// is started. It returns an error if any input failed or was not started.
+func runBatch(ctx context.Context, config Config, files []string) error {
+	if config.DryRun {
+		configs := make([]Config, len(files))
+		for i, file := range files {
+			configs[i] = batchFileConfig(config, file)
+		}
+		return printCostEstimates(configs)
+	}
+
+	if err := checkBatchFlags(config, files); err != nil {
+		return configErrors{err}
+	}
+	if !config.TitleFromContent {
+		if err := checkBatchNames(config, files); err != nil {
+			return err
+		}
+	}
+	if config.Open {
+		log.Println("Skipping -open: it opens a single output and is ignored for batches")
+	}
+
+	var manifest *batchManifest
+	if config.Manifest != "" {
+		var err error
+		if manifest, err = loadManifest(config.Manifest, files); err != nil {
+			return err
+		}
+	}
+	var duplicates map[string]string
+	if !config.KeepDuplicates {
+		duplicates = findDuplicates(files, manifest)
+	}
+	// process runs one input, unless the manifest lists it as done or it
+	// duplicates another, and records the outcome in the manifest.
+	process := func(i int, run func() error) error {
+		if ctx.Err() != nil {
+			return errNotStarted
+		}
+		if manifest.done(files[i]) {
+			log.Printf("[%d/%d] Skipping %s: %v\n", i+1, len(files), files[i], errAlreadyDone)
+			return errAlreadyDone
+		}
+		if original, ok := duplicates[files[i]]; ok {
+			log.Printf("[%d/%d] Skipping %s: same audio as %s\n", i+1, len(files), files[i], original)
+			return errDuplicate
+		}
+		err := run()
+		if manifestErr := manifest.record(files[i], err); manifestErr != nil {
+			log.Printf("Warning: %v\n", manifestErr)
+		}
+		return err
+	}
+
+	if config.Concurrency > 1 {
+		log.Printf("Processing %d files, %d at a time\n", len(files), config.Concurrency)
+	}
+	// The inputs share the run's client, rate limiter, and shutdown context;
+	// the rest of their state lives in the config each one gets.
+	errs := runPool(max(config.Concurrency, 1), len(files), func(i int) error {
+		return process(i, func() error {
+			log.Printf("[%d/%d] %s\n", i+1, len(files), files[i])
+			return runInput(batchFileConfig(config, files[i]))
+		})
+	})
+
+	var failed, notStarted, skipped, duplicated []string
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, errNotStarted):
+			notStarted = append(notStarted, files[i])
+		case errors.Is(err, errAlreadyDone):
+			skipped = append(skipped, files[i])
+		case errors.Is(err, errDuplicate):
+			duplicated = append(duplicated, files[i])
+		case err != nil:
+			log.Printf("[%d/%d] %s failed: %v\n", i+1, len(files), files[i], err)
+			failed = append(failed, files[i])
+		}
+	}
+
+	succeeded := len(files) - len(failed) - len(notStarted) - len(skipped) - len(duplicated)
+	if len(skipped) > 0 {
+		log.Printf("Skipped %d of %d files already done according to %s\n", len(skipped), len(files), config.Manifest)
+	}
+	if len(duplicated) > 0 {
+		log.Printf("Skipped %d of %d files with the same audio as another input (-keep-duplicates to process them):\n", len(duplicated), len(files))
+		for _, file := range duplicated {
+			log.Printf("  duplicate: %s of %s\n", file, duplicates[file])
+		}
+	}
+	if len(notStarted) > 0 {
+		log.Printf("Batch stopped by a shutdown signal: %d succeeded, %d failed, %d not started\n", succeeded, len(failed), len(notStarted))
+	} else {
+		log.Printf("Batch finished: %d succeeded, %d failed\n", succeeded, len(failed))
+	}
+	for _, file := range failed {
+		log.Printf("  failed: %s\n", file)
+	}
+	for _, file := range notStarted {
+		log.Printf("  not started: %s\n", file)
+	}
+	switch {
+	case len(failed) > 0:
+		return fmt.Errorf("%d of %d files failed", len(failed), len(files))
+	case len(notStarted) > 0:
+		return fmt.Errorf("%d of %d files were not started because of a shutdown signal", len(notStarted), len(files))
+	}
+	return nil
+}
+
+// checkBatchFlags rejects the inputs and flags that cannot be part of a
+// batch of files.
+func checkBatchFlags(config Config, files []string) error {
+	switch {
+	case config.TranscriptionFilePath != "":
+		return errors.New("specify only one of -file or -transcription")
+	case slices.Contains(files, stdinPath):
+		return errors.New("-file - reads a single input from stdin and cannot be part of a batch")
+	case slices.ContainsFunc(files, isAudioURL):
+		return errors.New("a -file URL is downloaded as a single input and cannot be part of a batch")
+	case config.Info || config.FormatCheck || config.Sample > 0 || config.Compare != "":
+		return errors.New("-info, -format-check, -sample, and -compare take a single -file")
+	case config.OutputFileName != "":
+		return errors.New("-output names a single transcript; leave it out to name each batch output after its input")
+	case config.Manifest != "" && config.Resume != "":
+		return errors.New("-manifest and -resume cannot be combined: -manifest tracks whole inputs, -resume the chunks of a single one")
+	case config.Resume != "":
+		return errors.New("-resume records the progress of a single input and cannot be used with several -file inputs")
+	case config.Concurrency > 1 && config.DebugBundleDir != "":
+		return errors.New("-debug-bundle cannot be combined with -concurrency above 1, since the files would write over each other's requests")
+	}
+	return nil
+}
+
+// limitFiles keeps the first limit files, in the order expandFileArgs
+// returned them.
+func limitFiles(files []string, limit int) []string {
+	if len(files) <= limit {
+		return files
+	}
+	log.Printf("Processing the first %d of %d -file inputs (-limit)\n", limit, len(files))
+	return files[:limit]
+}
+
+// batchFileConfig is the configuration for one input of a batch.
+func batchFileConfig(config Config, file string) Config {
+	config.AudioFilePath = file
+	config.Open = false
+	return config
+}
+
+// checkBatchNames rejects batches where two inputs in different directories
+// share a name, since their outputs would be written to the same path.
+func checkBatchNames(config Config, files []string) error {
+	seen := map[string]string{}
+	for _, file := range files {
+		name := audioOutputName(config, file)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("%s and %s would both write %s; rename one or run them separately", other, file, name)
+		}
+		seen[name] = file
+	}
+	return nil
+}
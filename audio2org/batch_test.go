@@ -0,0 +1,232 @@
+package audio2org
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpandFileArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.mp3", "a.mp3", "notes.txt", "odd[1].mp3", "rec/one.m4a", "rec/two.WAV", "rec/cover.jpg", "rec/old/three.m4a", "empty/notes.txt"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		exts    []string
+		want    []string
+		wantErr bool
+	}{
+		{"single path", join("b.mp3"), nil, join("b.mp3"), false},
+		{"glob is sorted", join("*.mp3"), nil, join("a.mp3", "b.mp3", "odd[1].mp3"), false},
+		{"repeats dropped", append(join("b.mp3"), join("*.mp3")...), nil, join("b.mp3", "a.mp3", "odd[1].mp3"), false},
+		{"existing path with glob characters", join("odd[1].mp3"), nil, join("odd[1].mp3"), false},
+		{"missing path is kept for the run to report", join("missing.mp3"), nil, join("missing.mp3"), false},
+		{"glob without matches", join("*.wav"), nil, nil, true},
+		{"directory picks supported formats", join("rec"), nil, join("rec/one.m4a", "rec/two.WAV"), false},
+		{"directory limited by -ext", join("rec"), []string{".m4a"}, join("rec/one.m4a"), false},
+		{"glob limited by -ext", join("*"), []string{".mp3"}, join("a.mp3", "b.mp3", "odd[1].mp3"), false},
+		{"literal path ignores -ext", join("notes.txt"), []string{".m4a"}, join("notes.txt"), false},
+		{"directory without audio", join("empty"), nil, nil, true},
+		{"glob filtered to nothing", join("*.mp3"), []string{".m4a"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandFileArgs(tt.args, tt.exts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandFileArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("expandFileArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	got := parseExtensions(" m4a, .MP3,,m4a ")
+	if strings.Join(got, " ") != ".m4a .mp3" {
+		t.Errorf("parseExtensions() = %q, want [.m4a .mp3]", got)
+	}
+}
+
+func TestLimitFiles(t *testing.T) {
+	files := []string{"rec/a.mp3", "rec/b.mp3", "rec/c.mp3"}
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{1, "rec/a.mp3"},
+		{2, "rec/a.mp3 rec/b.mp3"},
+		{3, "rec/a.mp3 rec/b.mp3 rec/c.mp3"},
+		{10, "rec/a.mp3 rec/b.mp3 rec/c.mp3"},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(limitFiles(files, tt.limit), " "); got != tt.want {
+			t.Errorf("limitFiles(%d) = %q, want %q", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestCheckBatchNames(t *testing.T) {
+	config := Config{Format: "text"}
+	if err := checkBatchNames(config, []string{"a/one.mp3", "a/two.m4a"}); err != nil {
+		t.Errorf("checkBatchNames() = %v, want nil", err)
+	}
+	if err := checkBatchNames(config, []string{"a/one.mp3", "b/one.m4a"}); err == nil {
+		t.Error("checkBatchNames() accepted two inputs that write one.txt")
+	}
+}
+
+func TestRunBatchContinuesAfterFailures(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	dir := t.TempDir()
+	config := Config{
+		MaxChunkMB:      24,
+		MaxRetries:      0,
+		RetryBaseDelay:  time.Second,
+		RetryLog:        "normal",
+		TranscribeModel: "whisper-1",
+		SummaryModel:    "gpt-4o",
+		MaxTokens:       3000,
+		Temperature:     0.7,
+		Format:          "text",
+		Backend:         "openai",
+		AuthHeader:      "bearer",
+		BaseURL:         defaultBaseURL,
+	}
+
+	err := runBatch(context.Background(), config, []string{filepath.Join(dir, "one.mp3"), filepath.Join(dir, "two.mp3")})
+	if err == nil || err.Error() != "2 of 2 files failed" {
+		t.Errorf("runBatch() = %v, want both files to fail", err)
+	}
+}
+
+func TestRunBatchConcurrentInputs(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	bothStarted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if peak = max(peak, inFlight); peak == 2 && inFlight == 2 {
+			close(bothStarted)
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		// Hold each upload until the other input has sent its own.
+		select {
+		case <-bothStarted:
+		case <-time.After(5 * time.Second):
+		}
+		_, header, err := r.FormFile("file")
+		if err != nil || header.Filename == "bad.mp3" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "bad audio"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"text": "Transcript of %s."}`, header.Filename)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := validConfig()
+	config.TranscriptionFilePath = ""
+	config.OutputDir = filepath.Join(dir, "output")
+	config.BaseURL = server.URL
+	config.NoCache = true
+	config.RetryLog = "quiet"
+	config.Concurrency = 2
+	client, err := newHTTPClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.client = client
+
+	var files []string
+	for _, name := range []string{"one.mp3", "two.mp3", "bad.mp3"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("ID3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	err = runBatch(context.Background(), config, files)
+	if err == nil || err.Error() != "1 of 3 files failed" {
+		t.Errorf("runBatch() = %v, want only bad.mp3 to fail", err)
+	}
+	mu.Lock()
+	if peak != 2 {
+		t.Errorf("%d uploads were in flight at once, want 2", peak)
+	}
+	mu.Unlock()
+	for _, name := range []string{"one", "two"} {
+		paths, err := filepath.Glob(filepath.Join(config.OutputDir, name+"_*.txt"))
+		if err != nil || len(paths) != 1 {
+			t.Fatalf("%s outputs = %v (%v), want one transcript", name, paths, err)
+		}
+		data, err := os.ReadFile(paths[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "Transcript of " + name + ".mp3."; !strings.Contains(string(data), want) {
+			t.Errorf("%s = %q, want %q", paths[0], data, want)
+		}
+	}
+}
+
+func TestRunBatchRejectsSingleInputFlags(t *testing.T) {
+	files := []string{"one.mp3", "two.mp3"}
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"output", Config{OutputFileName: "notes.txt"}, "-output names a single transcript"},
+		{"resume", Config{Resume: "state.json"}, "-resume records the progress of a single input"},
+		{"debug bundle", Config{Concurrency: 2, DebugBundleDir: "bundle"}, "-debug-bundle cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runBatch(context.Background(), tt.config, files)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("runBatch() = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if code := exitCode(err); code != exitUsage {
+				t.Errorf("exitCode() = %d, want %d", code, exitUsage)
+			}
+		})
+	}
+}
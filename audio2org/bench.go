@@ -0,0 +1,181 @@
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type benchStats struct {
+	Files        int                   `json:"files"`
+	Audio        time.Duration         `json:"audio"`
+	AudioGuessed bool                  `json:"audio_guessed"`
+	Stages       map[string]stageStats `json:"stages"`
+}
+
+type stageStats struct {
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+}
+
+// benchEnabled is set once before any work starts; bench and the stages
+// of each input are guarded by benchMu since chunks and the inputs of a
+// -concurrency batch report from several goroutines.
+var (
+	benchEnabled bool
+	benchMu      sync.Mutex
+	bench        = benchStats{Stages: map[string]stageStats{}}
+)
+
+// timeStage starts timing one run of a stage and returns the function that
+// stops it. The time is added to the stages of config's input, and to the
+// -bench report when -bench is set.
+func timeStage(config Config, name string) func() {
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		benchMu.Lock()
+		defer benchMu.Unlock()
+		if config.stages != nil {
+			addStage(config.stages, name, elapsed)
+		}
+		if benchEnabled {
+			addStage(bench.Stages, name, elapsed)
+		}
+	}
+}
+
+func addStage(stages map[string]stageStats, name string, elapsed time.Duration) {
+	stage := stages[name]
+	stage.Count++
+	stage.Total += elapsed
+	stages[name] = stage
+}
+
+// inputStageSeconds returns the total time of each stage of config's
+// input in seconds, rounded to the millisecond.
+func inputStageSeconds(config Config) map[string]float64 {
+	benchMu.Lock()
+	defer benchMu.Unlock()
+	seconds := make(map[string]float64, len(config.stages))
+	for name, stage := range config.stages {
+		seconds[name] = stage.Total.Round(time.Millisecond).Seconds()
+	}
+	return seconds
+}
+
+// formatStageTimings is the -timings report: each stage of the input with
+// its total time, longest first, and how many runs it took when more than
+// one. Nested stages, such as the API requests within transcribe, overlap.
+func formatStageTimings(config Config, total time.Duration) string {
+	benchMu.Lock()
+	stages := make(map[string]stageStats, len(config.stages))
+	for name, stage := range config.stages {
+		stages[name] = stage
+	}
+	benchMu.Unlock()
+
+	names := make([]string, 0, len(stages))
+	width := len("total")
+	for name := range stages {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if stages[names[i]].Total != stages[names[j]].Total {
+			return stages[names[i]].Total > stages[names[j]].Total
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("Stage timings:\n")
+	for _, name := range names {
+		stage := stages[name]
+		fmt.Fprintf(&b, "  %-*s  %s", width, name, stage.Total.Round(time.Millisecond))
+		if stage.Count > 1 {
+			fmt.Fprintf(&b, " (%d runs)", stage.Count)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "  %-*s  %s", width, "total", total.Round(time.Millisecond))
+	return b.String()
+}
+
+// recordBenchFile counts a successfully transcribed audio file and its
+// duration for -bench. The duration comes from the transcription when
+// Whisper reported it, and from ffprobe or the file size otherwise.
+func recordBenchFile(config Config, transcription TranscriptionResponse) {
+	if !benchEnabled || config.AudioFilePath == "" {
+		return
+	}
+
+	duration := time.Duration(transcription.Duration * float64(time.Second))
+	guessed := false
+	if duration == 0 {
+		duration, guessed, _ = estimateDuration(config.AudioFilePath)
+	}
+
+	benchMu.Lock()
+	defer benchMu.Unlock()
+	bench.Files++
+	bench.Audio += duration
+	bench.AudioGuessed = bench.AudioGuessed || guessed
+}
+
+// runBench processes the -file inputs as a batch and prints throughput and
+// the average time spent in each stage.
+func runBench(ctx context.Context, config Config, files []string) error {
+	switch {
+	case len(files) == 0:
+		return errors.New("-bench requires -file")
+	case config.DryRun:
+		return errors.New("-bench measures real requests and cannot be combined with -dry-run; point -base-url at a mock server to benchmark without API cost")
+	}
+
+	benchEnabled = true
+	start := time.Now()
+	err := runBatch(ctx, config, files)
+	elapsed := time.Since(start)
+
+	benchMu.Lock()
+	stats := bench
+	benchMu.Unlock()
+	fmt.Print(formatBenchReport(stats, len(files), config.Concurrency, elapsed))
+	return err
+}
+
+func formatBenchReport(stats benchStats, inputs, concurrency int, elapsed time.Duration) string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format, args...)
+	}
+
+	line("Files:           %d of %d succeeded, %d at a time\n", stats.Files, inputs, concurrency)
+	line("Wall time:       %s\n", elapsed.Round(time.Millisecond))
+	audio := formatTimestamp(stats.Audio.Seconds())
+	if stats.AudioGuessed {
+		audio += " (partly guessed from file sizes; install ffprobe for real durations)"
+	}
+	line("Audio processed: %s\n", audio)
+	if elapsed > 0 {
+		line("Throughput:      %.2f files/min, %.1f audio minutes per minute\n",
+			float64(stats.Files)/elapsed.Minutes(), stats.Audio.Minutes()/elapsed.Minutes())
+	}
+
+	names := make([]string, 0, len(stats.Stages))
+	for name := range stats.Stages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stage := stats.Stages[name]
+		line("Stage:           %s: %d %s, %s average, %s total\n", name, stage.Count, plural(stage.Count, "run", "runs"),
+			(stage.Total / time.Duration(stage.Count)).Round(time.Millisecond), stage.Total.Round(time.Millisecond))
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetBench(t *testing.T) {
+	t.Helper()
+	benchEnabled = true
+	bench = benchStats{Stages: map[string]stageStats{}}
+	t.Cleanup(func() {
+		benchEnabled = false
+		bench = benchStats{Stages: map[string]stageStats{}}
+	})
+}
+
+func TestTimeStageDisabled(t *testing.T) {
+	config := Config{stages: map[string]stageStats{}}
+	timeStage(config, "transcribe")()
+	if len(bench.Stages) != 0 {
+		t.Errorf("timeStage() recorded %v without -bench", bench.Stages)
+	}
+	if got := config.stages["transcribe"].Count; got != 1 {
+		t.Errorf("input transcribe count = %d, want 1", got)
+	}
+}
+
+func TestTimeStageKeepsInputsApart(t *testing.T) {
+	resetBench(t)
+	first := Config{stages: map[string]stageStats{}}
+	second := Config{stages: map[string]stageStats{}}
+	timeStage(first, "transcribe")()
+	timeStage(first, "transcribe")()
+	timeStage(second, "transcribe")()
+
+	if got := first.stages["transcribe"].Count; got != 2 {
+		t.Errorf("first input transcribe count = %d, want 2", got)
+	}
+	if got := second.stages["transcribe"].Count; got != 1 {
+		t.Errorf("second input transcribe count = %d, want 1", got)
+	}
+	if got := bench.Stages["transcribe"].Count; got != 3 {
+		t.Errorf("-bench transcribe count = %d, want 3", got)
+	}
+}
+
+func TestFormatBenchReport(t *testing.T) {
+	stats := benchStats{
+		Files: 3,
+		Audio: 30 * time.Minute,
+		Stages: map[string]stageStats{
+			"Whisper API request": {Count: 4, Total: 8 * time.Second},
+			"transcribe":          {Count: 3, Total: 9 * time.Second},
+		},
+	}
+	report := formatBenchReport(stats, 4, 2, 2*time.Minute)
+
+	for _, want := range []string{
+		"Files:           3 of 4 succeeded, 2 at a time\n",
+		"Audio processed: 00:30:00\n",
+		"Throughput:      1.50 files/min, 15.0 audio minutes per minute\n",
+		"Stage:           Whisper API request: 4 runs, 2s average, 8s total\n",
+		"Stage:           transcribe: 3 runs, 3s average, 9s total\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestFormatStageTimings(t *testing.T) {
+	config := Config{stages: map[string]stageStats{
+		"transcribe":          {Count: 1, Total: 12345 * time.Millisecond},
+		"Whisper API request": {Count: 3, Total: 12 * time.Second},
+		"write outputs":       {Count: 2, Total: 4 * time.Millisecond},
+	}}
+
+	want := "Stage timings:\n" +
+		"  transcribe           12.345s\n" +
+		"  Whisper API request  12s (3 runs)\n" +
+		"  write outputs        4ms (2 runs)\n" +
+		"  total                15s"
+	if got := formatStageTimings(config, 15*time.Second); got != want {
+		t.Errorf("formatStageTimings() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// bundleMetadata is the metadata.json of a -bundle directory.
+type bundleMetadata struct {
+	Source           string             `json:"source"`
+	TranscribeModel  string             `json:"transcribe_model"`
+	SummaryModel     string             `json:"summary_model,omitempty"`
+	PostProcessCmd   string             `json:"post_process_cmd,omitempty"`
+	DurationSeconds  float64            `json:"duration_seconds,omitempty"`
+	ChatRequests     int                `json:"chat_requests"`
+	ChatTokens       Usage              `json:"chat_tokens"`
+	EstimatedCostUSD float64            `json:"estimated_cost_usd"`
+	TimingsSeconds   map[string]float64 `json:"timings_seconds"`
+	StageSeconds     map[string]float64 `json:"stage_seconds"`
+	CreatedAt        time.Time          `json:"created_at"`
+	Files            []string           `json:"files"`
+}
+
+func checkBundle(config Config) error {
+	if !config.Bundle {
+		return nil
+	}
+	switch {
+	case len(audioInputs(config)) == 0:
+		return errors.New("-bundle requires -file")
+	case config.OutputURI != "" || config.NoOutput || config.Stdout:
+		return errors.New("-bundle writes a local directory and cannot be combined with -output-uri, -no-output, or -stdout")
+	case config.Append != "" || config.OrgPathTemplate != "":
+		return errors.New("-bundle keeps the notes in the run directory and cannot be combined with -append or -org-path-template")
+	case config.Resume != "":
+		return errors.New("-bundle starts a new directory every run and cannot be combined with -resume")
+	}
+	return nil
+}
+
+// createBundleDir creates run_<timestamp> in -output-dir, with _2, _3, ...
+// added when inputs of a batch start in the same second.
+func createBundleDir(config Config) (string, error) {
+	if err := os.MkdirAll(config.OutputDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating output directory: %w", err)
+	}
+	base := filepath.Join(config.OutputDir, "run_"+time.Now().Format("20060102_150405"))
+	for n := 1; ; n++ {
+		dir := base
+		if n > 1 {
+			dir = fmt.Sprintf("%s_%d", base, n)
+		}
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			log.Printf("Writing this run's outputs to %s (-bundle)\n", dir)
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("creating the -bundle directory: %w", err)
+		}
+	}
+}
+
+// finishBundle copies the audio into the -bundle directory as
+// audio<extension> and writes metadata.json listing everything in it.
+func finishBundle(config Config, duration time.Duration, timings map[string]time.Duration) error {
+	dir := config.OutputDir
+	audioPath := filepath.Join(dir, "audio"+filepath.Ext(config.AudioFilePath))
+	if err := copyFile(config.AudioFilePath, audioPath); err != nil {
+		return fmt.Errorf("copying the audio into the -bundle directory: %w", err)
+	}
+
+	metadata := bundleMetadata{
+		Source:          inputSource(config),
+		TranscribeModel: transcriptionModel(config),
+		PostProcessCmd:  config.PostProcessCmd,
+		DurationSeconds: duration.Seconds(),
+		TimingsSeconds:  map[string]float64{},
+		StageSeconds:    inputStageSeconds(config),
+		CreatedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+	if hasPostStep(config, "create_emacs_org_notes") || hasPostStep(config, "create_markdown_notes") {
+		metadata.SummaryModel = config.SummaryModel
+	}
+	for stage, elapsed := range timings {
+		metadata.TimingsSeconds[stage] = elapsed.Round(time.Millisecond).Seconds()
+	}
+	if u := config.usage; u != nil {
+		u.mu.Lock()
+		metadata.ChatRequests, metadata.ChatTokens, metadata.EstimatedCostUSD = u.requests, u.tokens, u.cost
+		u.mu.Unlock()
+	}
+	metadata.EstimatedCostUSD += transcriptionCost(config, duration)
+	metadata.EstimatedCostUSD = math.Round(metadata.EstimatedCostUSD*1e6) / 1e6
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("listing the -bundle directory: %w", err)
+	}
+	for _, entry := range entries {
+		metadata.Files = append(metadata.Files, entry.Name())
+	}
+	metadata.Files = append(metadata.Files, "metadata.json")
+	sort.Strings(metadata.Files)
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding -bundle metadata: %w", err)
+	}
+	return writeToFile(config, filepath.Join(dir, "metadata.json"), string(data)+"\n")
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,99 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateBundleDir(t *testing.T) {
+	config := Config{OutputDir: filepath.Join(t.TempDir(), "output")}
+	first, err := createBundleDir(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := createBundleDir(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(filepath.Base(first), "run_") {
+		t.Errorf("bundle directory = %s, want run_<timestamp>", first)
+	}
+	// The second run may start in the next second, else it gets a suffix.
+	if second == first {
+		t.Errorf("both runs got %s", first)
+	}
+}
+
+func TestFinishBundle(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(t.TempDir(), "talk.mp3")
+	if err := os.WriteFile(audioPath, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "transcript.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	usage := &runUsage{}
+	usage.addChat("gpt-4o", &Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100})
+	config := Config{
+		AudioFilePath:   audioPath,
+		OutputDir:       dir,
+		TranscribeModel: "whisper-1",
+		SummaryModel:    "gpt-4o",
+		PostProcessCmd:  "create_emacs_org_notes",
+		usage:           usage,
+	}
+
+	err := finishBundle(config, 90*time.Second, map[string]time.Duration{"transcribe": 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audio, err := os.ReadFile(filepath.Join(dir, "audio.mp3")); err != nil || string(audio) != "audio" {
+		t.Errorf("audio copy = %q, %v", audio, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata bundleMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	// 1.5 minutes of whisper-1 plus 1000 input and 100 output gpt-4o tokens.
+	if metadata.EstimatedCostUSD != 0.0125 {
+		t.Errorf("estimated cost = %v, want 0.0125", metadata.EstimatedCostUSD)
+	}
+	if metadata.SummaryModel != "gpt-4o" || metadata.DurationSeconds != 90 || metadata.TimingsSeconds["transcribe"] != 1.5 {
+		t.Errorf("metadata = %+v", metadata)
+	}
+	if want := []string{"audio.mp3", "metadata.json", "transcript.txt"}; !reflect.DeepEqual(metadata.Files, want) {
+		t.Errorf("files = %v, want %v", metadata.Files, want)
+	}
+}
+
+func TestCheckBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"off", Config{}, false},
+		{"file", Config{Bundle: true, AudioFilePath: "talk.mp3"}, false},
+		{"transcription", Config{Bundle: true, TranscriptionFilePath: "talk.txt"}, true},
+		{"stdout", Config{Bundle: true, AudioFilePath: "talk.mp3", Stdout: true}, true},
+		{"append", Config{Bundle: true, AudioFilePath: "talk.mp3", Append: "notes.org"}, true},
+		{"resume", Config{Bundle: true, AudioFilePath: "talk.mp3", Resume: "state.json"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkBundle(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkBundle() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package audio2org
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func cacheDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "go-audio2org")
+}
+
+func chunkCacheKey(audio io.ReadSeeker, model string, extraForm map[string]string) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+
+	keys := make([]string, 0, len(extraForm))
+	for key := range extraForm {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h.Write([]byte(key + "=" + extraForm[key]))
+		h.Write([]byte{0})
+	}
+
+	if _, err := audio.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("hashing audio for the cache: %w", err)
+	}
+	if _, err := io.Copy(h, audio); err != nil {
+		return "", fmt.Errorf("hashing audio for the cache: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readCachedChunk(key string) (TranscriptionResponse, bool) {
+	var cached TranscriptionResponse
+	data, err := os.ReadFile(filepath.Join(cacheDir(), "chunks", key+".json"))
+	if err != nil {
+		return cached, false
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return cached, false
+	}
+	return cached, true
+}
+
+func writeCachedChunk(key string, transcription TranscriptionResponse) {
+	dir := filepath.Join(cacheDir(), "chunks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("Error creating cache directory: %v\n", err)
+		return
+	}
+
+	data, err := json.Marshal(transcription)
+	if err != nil {
+		log.Printf("Error encoding chunk cache: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, key+".json"), data, 0644); err != nil {
+		log.Printf("Error writing chunk cache: %v\n", err)
+	}
+}
+
+// transcribeCached transcribes a whole file or one piece of a larger
+// recording, reusing a previous result for identical audio and options, so
+// re-running on the same recording, for example to try another notes
+// prompt, or retrying an interrupted run does not pay for it again.
+func transcribeCached(config Config, filePath string, audio io.ReadSeeker, extraForm map[string]string) (TranscriptionResponse, error) {
+	if config.NoCache {
+		return transcribeAudio(config, filePath, audio, extraForm)
+	}
+
+	key, err := chunkCacheKey(audio, transcriptionModel(config), cacheKeyForm(config, extraForm))
+	if err != nil {
+		return TranscriptionResponse{}, err
+	}
+	if cached, ok := readCachedChunk(key); ok {
+		log.Println("Using cached transcription (-no-cache to transcribe again)")
+		return cached, nil
+	}
+
+	transcription, err := transcribeAudio(config, filePath, audio, extraForm)
+	if err != nil {
+		return transcription, err
+	}
+	writeCachedChunk(key, transcription)
+	return transcription, nil
+}
+
+// cacheKeyForm adds the options that change the transcription but are not
+// in extraForm to the fields the cache key is made from.
+func cacheKeyForm(config Config, extraForm map[string]string) map[string]string {
+	form := config.WhisperParams.form()
+	if config.Language != "" {
+		form["language"] = config.Language
+	}
+	if prompt := whisperPrompt(config); prompt != "" {
+		form["base_prompt"] = prompt
+	}
+	if config.Diarize {
+		form["diarize"] = "true"
+	}
+	if config.Translate {
+		form["translate"] = "true"
+	}
+	if config.WhisperResponseFormat != "" {
+		form["response_format"] = config.WhisperResponseFormat
+	}
+	for field, value := range extraForm {
+		form[field] = value
+	}
+	return form
+}
+
+// clearCache removes every cached transcription, for -clear-cache.
+func clearCache() error {
+	dir := filepath.Join(cacheDir(), "chunks")
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clearing the cache: %w", err)
+	}
+	log.Printf("Cleared the transcription cache in %s\n", dir)
+	return nil
+}
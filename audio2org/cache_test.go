@@ -0,0 +1,56 @@
+package audio2org
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranscribeCached(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Hello there."}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL, TranscribeModel: "whisper-1", RetryLog: "quiet"}
+	transcribe := func(config Config) TranscriptionResponse {
+		t.Helper()
+		transcription, err := transcribeCached(config, "talk.mp3", strings.NewReader("audio"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return transcription
+	}
+
+	transcribe(config)
+	if got := transcribe(config); got.Text != "Hello there." || requests != 1 {
+		t.Errorf("second run = %q after %d requests, want the cached text after 1", got.Text, requests)
+	}
+
+	vocab := config
+	vocab.VocabPrompt = "Okonkwo"
+	transcribe(vocab)
+	if requests != 2 {
+		t.Errorf("requests = %d, want a new request for a different prompt", requests)
+	}
+
+	noCache := config
+	noCache.NoCache = true
+	transcribe(noCache)
+	if requests != 3 {
+		t.Errorf("requests = %d, want -no-cache to bypass the cache", requests)
+	}
+
+	if err := clearCache(); err != nil {
+		t.Fatal(err)
+	}
+	transcribe(config)
+	if requests != 4 {
+		t.Errorf("requests = %d, want a new request after clearCache()", requests)
+	}
+}
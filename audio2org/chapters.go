@@ -0,0 +1,153 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const chapterSectionSeconds = 30.0
+
+type chapter struct {
+	Start float64
+	End   float64
+	Title string
+}
+
+type chapterPick struct {
+	Section int    `json:"section"`
+	Title   string `json:"title"`
+}
+
+type chaptersResponse struct {
+	Chapters []chapterPick `json:"chapters"`
+}
+
+func createChapters(config Config, transcription TranscriptionResponse, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_chapters command...")
+
+	sections := groupSegments(transcription.Segments, chapterSectionSeconds)
+	if len(sections) == 0 {
+		return "", errors.New("create_chapters: the transcription has no segments")
+	}
+
+	duration := transcription.Duration
+	if last := sections[len(sections)-1].End; duration < last {
+		duration = last
+	}
+
+	message := map[string]string{
+		"role":    "user",
+		"content": createChaptersPrompt(sections),
+	}
+
+	reqBody := map[string]interface{}{
+		"model":           config.SummaryModel,
+		"messages":        []map[string]string{message},
+		"max_tokens":      2000,
+		"temperature":     0.3,
+		"response_format": map[string]string{"type": "json_object"},
+	}
+
+	content, err := sendChatRequest(config, reqBody, chatResponsePath(config, baseFilePath, ""))
+	if err != nil {
+		return "", err
+	}
+	var response chaptersResponse
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		return "", fmt.Errorf("unmarshalling chapters: %w", err)
+	}
+
+	chapters := buildChapters(response, sections, duration)
+	if len(chapters) == 0 {
+		return "", errors.New("create_chapters: the model returned no usable chapters")
+	}
+
+	chaptersText := formatChaptersText(chapters)
+	if err := writeToFile(config, generateDerivedFilePath(baseFilePath, "_chapters.vtt"), formatChaptersVTT(chapters)); err != nil {
+		return "", err
+	}
+	if err := writeToFile(config, generateDerivedFilePath(baseFilePath, "_chapters.txt"), chaptersText); err != nil {
+		return "", err
+	}
+	return chaptersText, nil
+}
+
+// buildChapters turns the model's section picks into contiguous chapters
+// that start at 0 and end at the source duration.
+func buildChapters(response chaptersResponse, sections []transcriptSection, duration float64) []chapter {
+	starts := map[int]string{}
+	for _, c := range response.Chapters {
+		if c.Section >= 1 && c.Section <= len(sections) && strings.TrimSpace(c.Title) != "" {
+			if _, ok := starts[c.Section]; !ok {
+				starts[c.Section] = strings.TrimSpace(c.Title)
+			}
+		}
+	}
+
+	indexes := make([]int, 0, len(starts))
+	for index := range starts {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	var chapters []chapter
+	for i, index := range indexes {
+		start := sections[index-1].Start
+		if i == 0 {
+			start = 0
+		}
+		chapters = append(chapters, chapter{Start: start, Title: starts[index]})
+	}
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = duration
+		}
+	}
+	return chapters
+}
+
+func formatChaptersVTT(chapters []chapter) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, c := range chapters {
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, formatVTTTimestamp(c.Start), formatVTTTimestamp(c.End), c.Title)
+	}
+	return b.String()
+}
+
+func formatChaptersText(chapters []chapter) string {
+	var b strings.Builder
+	for _, c := range chapters {
+		fmt.Fprintf(&b, "%s %s\n", formatTimestamp(c.Start), c.Title)
+	}
+	return b.String()
+}
+
+func formatVTTTimestamp(seconds float64) string {
+	millis := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", millis/3600000, millis/60000%60, millis/1000%60, millis%1000)
+}
+
+func createChaptersPrompt(sections []transcriptSection) string {
+	var b strings.Builder
+	for i, section := range sections {
+		fmt.Fprintf(&b, "Section %d [%s]:", i+1, formatTimestamp(section.Start))
+		for _, segment := range section.Segments {
+			b.WriteString(" ")
+			b.WriteString(strings.TrimSpace(segment.Text))
+		}
+		b.WriteString("\n")
+	}
+
+	return fmt.Sprintf(`The following transcript of a recording is split into numbered sections of about thirty seconds each. Divide the recording into chapters at the points where the topic shifts, the way a podcast player would show them. Aim for a chapter every few minutes; the first chapter must start at section 1.
+
+Respond with a JSON object of the form {"chapters": [{"section": 1, "title": "..."}]}, where "section" is the section number the chapter starts at and "title" is a short chapter title of at most six words.
+
+%s`, b.String())
+}
@@ -0,0 +1,37 @@
+package audio2org
+
+import "testing"
+
+func TestBuildChapters(t *testing.T) {
+	sections := groupSegments([]TranscriptionSegment{
+		{Start: 0, End: 20, Text: "hello"},
+		{Start: 31, End: 55, Text: "first topic"},
+		{Start: 62, End: 88, Text: "second topic"},
+	}, 30)
+
+	response := chaptersResponse{Chapters: []chapterPick{
+		{Section: 3, Title: "Second"},
+		{Section: 2, Title: "Intro"},
+		{Section: 9, Title: "Out of range"},
+	}}
+
+	chapters := buildChapters(response, sections, 90)
+	want := []chapter{
+		{Start: 0, End: 62, Title: "Intro"},
+		{Start: 62, End: 90, Title: "Second"},
+	}
+	if len(chapters) != len(want) {
+		t.Fatalf("got %d chapters, want %d: %v", len(chapters), len(want), chapters)
+	}
+	for i := range want {
+		if chapters[i] != want[i] {
+			t.Errorf("chapter %d = %+v, want %+v", i, chapters[i], want[i])
+		}
+	}
+
+	vtt := formatChaptersVTT(chapters)
+	wantVTT := "WEBVTT\n\n1\n00:00:00.000 --> 00:01:02.000\nIntro\n\n2\n00:01:02.000 --> 00:01:30.000\nSecond\n"
+	if vtt != wantVTT {
+		t.Errorf("VTT =\n%s\nwant\n%s", vtt, wantVTT)
+	}
+}
@@ -0,0 +1,111 @@
+package audio2org
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// requestProxy returns the proxy HTTPS_PROXY, HTTP_PROXY, and NO_PROXY
+// choose for requests to -base-url, which is what newTransport uses, or nil
+// for a direct connection.
+func requestProxy(config Config) (*url.URL, error) {
+	req, err := http.NewRequest(http.MethodGet, config.BaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+func logProxy(config Config) {
+	proxy, err := requestProxy(config)
+	switch {
+	case err != nil:
+		slog.Debug("Invalid proxy setting", "error", err)
+	case proxy == nil:
+		slog.Debug("Connecting to -base-url directly, without a proxy")
+	default:
+		slog.Debug("Connecting to -base-url through a proxy", "proxy", proxy.Redacted())
+	}
+}
+
+// runCheck is -check: it lists the models at -base-url with the API key,
+// through the same client, proxy, and TLS settings as a real run, and
+// reports what failed.
+func runCheck(config Config) error {
+	if err := checkBaseURL(config.BaseURL); err != nil {
+		return configErrors{err}
+	}
+	key, err := apiKey(config)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return errNoAPIKey
+	}
+	warnAPIKeyFormat(config, key)
+	log.Printf("Using the API key from %s\n", apiKeySource(config))
+
+	proxy, err := requestProxy(config)
+	if err != nil {
+		return configErrors{fmt.Errorf("invalid proxy setting: %w", err)}
+	}
+	if proxy != nil {
+		log.Printf("Proxy: %s\n", proxy.Redacted())
+	} else {
+		log.Println("Proxy: none, connecting directly")
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	endpoint := apiURL(config, "/models", config.SummaryModel)
+	log.Printf("Checking GET %s...\n", endpoint)
+	var models struct {
+		Data []struct{} `json:"data"`
+	}
+	started := time.Now()
+	resp, err := client.R().
+		SetContext(runContext(config)).
+		SetHeaders(apiHeaders(config, key)).
+		SetResult(&models).
+		SetError(&OpenAIErrorResponse{}).
+		Get(endpoint)
+	if err != nil {
+		return fmt.Errorf("check failed: %w%s", err, connectionHint(err, proxy))
+	}
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		// Some gateways and Azure deployments do not serve /models, but
+		// answering at all shows the connection works.
+		log.Printf("Warning: %s has no models endpoint (404); the connection works, but the API key could not be checked\n", endpoint)
+		return nil
+	case resp.IsError():
+		return fmt.Errorf("check failed: %w", apiError("OpenAI API", resp))
+	}
+	log.Printf("Check passed: the API accepted the key and listed %d models in %s\n", len(models.Data), time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// connectionHint suggests a fix for the errors a proxy tends to cause.
+func connectionHint(err error, proxy *url.URL) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var verification *tls.CertificateVerificationError
+	if errors.As(err, &unknownAuthority) || errors.As(err, &verification) {
+		if proxy != nil {
+			return " (the proxy may be intercepting TLS; pass its CA certificate with -ca-file)"
+		}
+		return " (pass the CA certificate of the server with -ca-file)"
+	}
+	if proxy != nil {
+		return fmt.Sprintf(" (through the proxy %s; check HTTPS_PROXY and NO_PROXY)", proxy.Redacted())
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package audio2org
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRunCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode int
+	}{
+		{"models listed", http.StatusOK, `{"data": [{"id": "whisper-1"}, {"id": "gpt-4o"}]}`, 0},
+		{"no models endpoint", http.StatusNotFound, `{}`, 0},
+		{"key rejected", http.StatusUnauthorized, `{"error": {"message": "Incorrect API key provided", "type": "invalid_request_error", "code": "invalid_api_key"}}`, exitAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, authorization, project string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, authorization, project = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("OpenAI-Project")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			t.Setenv("OPENAI_API_KEY", "sk-test")
+
+			err := runCheck(Config{BaseURL: server.URL + "/v1", AuthHeader: "bearer", ProjectID: "proj_456", RetryLog: "quiet"})
+			if path != "/v1/models" || authorization != "Bearer sk-test" || project != "proj_456" {
+				t.Errorf("request to %s with Authorization %q and OpenAI-Project %q", path, authorization, project)
+			}
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Errorf("runCheck() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || exitCode(err) != tt.wantCode {
+				t.Errorf("runCheck() = %v, want an error with exit code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRunCheckNoKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY_FILE", "")
+	if err := runCheck(Config{BaseURL: defaultBaseURL}); !errors.Is(err, errNoAPIKey) {
+		t.Errorf("runCheck() without a key = %v, want errNoAPIKey", err)
+	}
+}
@@ -0,0 +1,299 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunks are planned at this fraction of -max-chunk-mb, since re-encoding a
+// time range does not give exactly proportional sizes.
+const chunkSizeSafety = 0.9
+
+// Chunk boundaries move to the middle of a pause if there is one within
+// this fraction of the chunk length.
+const chunkBoundaryWindow = 0.1
+
+// Whisper only looks at the last 224 tokens of the prompt, so there is no
+// point in sending more of the previous chunk than this.
+const maxPromptTailChars = 800
+
+// incompleteMarker heads a transcript that stops before the end of the
+// recording, so it is not mistaken for the whole thing.
+const incompleteMarker = "[TRANSCRIPTION INCOMPLETE]"
+
+// partialTranscriptionError is returned when a chunk fails or the run is
+// interrupted after earlier chunks were transcribed. It keeps their joined
+// text so it can be written out before the run fails. units names the
+// pieces, such as "chunks" or "regions".
+type partialTranscriptionError struct {
+	transcription TranscriptionResponse
+	done, total   int
+	units         string
+	err           error
+}
+
+func (e *partialTranscriptionError) Error() string { return e.err.Error() }
+
+func (e *partialTranscriptionError) Unwrap() error { return e.err }
+
+// chunkForm returns the form fields for transcribing one chunk of a longer
+// recording. The tail of the previous chunk's text is passed as the prompt
+// so names and spelling stay consistent across chunk boundaries.
+func chunkForm(config Config, previousText string, extraForm map[string]string) map[string]string {
+	form := map[string]string{}
+	for key, value := range extraForm {
+		form[key] = value
+	}
+
+	// A vocabulary prompt that fills the budget leaves no room for a tail.
+	tail := promptTail(previousText, max(maxPromptTailChars-len(config.VocabPrompt), 0))
+	if tail == "" {
+		return form
+	}
+	if prompt := whisperPrompt(config); prompt != "" {
+		tail = prompt + " " + tail
+	}
+	form["prompt"] = tail
+	return form
+}
+
+// promptTail returns at most maxChars bytes from the end of text, starting
+// at a word boundary.
+func promptTail(text string, maxChars int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= maxChars {
+		return text
+	}
+
+	start := len(text) - maxChars
+	for start < len(text) && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	tail := text[start:]
+	if i := strings.IndexAny(tail, " \n\t"); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return strings.TrimSpace(tail)
+}
+
+func maxChunkBytes(config Config) int64 {
+	return int64(config.MaxChunkMB) * 1024 * 1024
+}
+
+// transcribeLargeAudio splits audio that is over -max-chunk-mb into time
+// ranges cut at pauses where possible, transcribes them in order, and joins
+// the text. Segment and word times are shifted to be relative to the whole file.
+// If a chunk fails after others finished, the error is a
+// *partialTranscriptionError with the text so far.
+func transcribeLargeAudio(config Config, audioFilePath string, size int64, extraForm map[string]string) (TranscriptionResponse, error) {
+	if isSubtitleFormat(config.WhisperResponseFormat) {
+		return TranscriptionResponse{}, fmt.Errorf("-whisper-response-format %s cannot join the subtitles of a file split into chunks; use -format %s without it, which numbers the cues across chunks", config.WhisperResponseFormat, config.WhisperResponseFormat)
+	}
+	if err := requireFFmpeg("Transcribing files over -max-chunk-mb"); err != nil {
+		return TranscriptionResponse{}, err
+	}
+	if err := requireFFprobe("Transcribing files over -max-chunk-mb"); err != nil {
+		return TranscriptionResponse{}, err
+	}
+
+	duration, err := probeDuration(audioFilePath)
+	if err != nil {
+		return TranscriptionResponse{}, err
+	}
+	total := duration.Seconds()
+	silences, err := runSilenceDetect(audioFilePath)
+	if err != nil {
+		return TranscriptionResponse{}, err
+	}
+	regions := sizeChunkRegions(total, size, maxChunkBytes(config), silenceMidpoints(silences))
+	log.Printf("Audio is %.1f MB, over the %d MB limit; splitting into %d chunks\n", float64(size)/(1024*1024), config.MaxChunkMB, len(regions))
+
+	workDir, err := chunkWorkDir(config, audioFilePath, extraForm)
+	if err != nil {
+		return TranscriptionResponse{}, err
+	}
+
+	var result TranscriptionResponse
+	var parts []string
+	previousText := ""
+	for i, region := range regions {
+		transcription, ok := readChunkPiece(workDir, i)
+		if ok {
+			log.Printf("Chunk %d/%d was transcribed by an earlier run, skipping\n", i+1, len(regions))
+		} else {
+			log.Printf("Transcribing chunk %d/%d (%s-%s)...\n", i+1, len(regions), formatTimestamp(region.Start), formatTimestamp(region.End))
+			transcription, err = transcribeRegion(config, audioFilePath, "chunk", region, chunkForm(config, previousText, extraForm))
+			if err != nil {
+				err = fmt.Errorf("transcribing chunk %d: %w", i+1, err)
+				if len(parts) == 0 {
+					return TranscriptionResponse{}, err
+				}
+				result.Text = strings.Join(parts, " ")
+				result.Duration = region.Start
+				return TranscriptionResponse{}, &partialTranscriptionError{transcription: result, done: i, total: len(regions), units: "chunks", err: err}
+			}
+			writeChunkPiece(workDir, i, transcription)
+		}
+		if result.Language == "" {
+			result.Language = transcription.Language
+		}
+		for _, segment := range transcription.Segments {
+			segment.ID = len(result.Segments)
+			segment.Start += region.Start
+			segment.End += region.Start
+			result.Segments = append(result.Segments, segment)
+		}
+		for _, word := range transcription.Words {
+			word.Start += region.Start
+			word.End += region.Start
+			result.Words = append(result.Words, word)
+		}
+
+		if text := strings.TrimSpace(transcription.Text); text != "" {
+			parts = append(parts, text)
+			previousText = text
+		}
+	}
+
+	result.Text = strings.Join(parts, " ")
+	result.Duration = total
+	if config.KeepChunks {
+		log.Printf("Kept the chunk transcriptions in %s (-keep-chunks)\n", workDir)
+	} else if err := os.RemoveAll(workDir); err != nil {
+		log.Printf("Error removing the chunk directory %s: %v\n", workDir, err)
+	}
+	return result, nil
+}
+
+// chunkWorkDir returns the directory transcribeLargeAudio keeps each
+// finished chunk of audioFilePath in until the whole file is done, so a
+// rerun after a failure skips them even with -no-cache. It is keyed by the
+// audio and the options that change how it is split or transcribed.
+func chunkWorkDir(config Config, audioFilePath string, extraForm map[string]string) (string, error) {
+	file, err := os.Open(audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	form := cacheKeyForm(config, extraForm)
+	form["max_chunk_mb"] = strconv.Itoa(config.MaxChunkMB)
+	key, err := chunkCacheKey(file, transcriptionModel(config), form)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(os.TempDir(), "go-audio2org-chunks", key), nil
+}
+
+func chunkPiecePath(workDir string, index int) string {
+	return filepath.Join(workDir, fmt.Sprintf("chunk_%03d.json", index))
+}
+
+func readChunkPiece(workDir string, index int) (TranscriptionResponse, bool) {
+	var transcription TranscriptionResponse
+	data, err := os.ReadFile(chunkPiecePath(workDir, index))
+	if err != nil {
+		return transcription, false
+	}
+	if err := json.Unmarshal(data, &transcription); err != nil {
+		return transcription, false
+	}
+	return transcription, true
+}
+
+// writeChunkPiece saves a finished chunk. A chunk that cannot be saved is
+// only transcribed again by a rerun, so failures are logged, not returned.
+func writeChunkPiece(workDir string, index int, transcription TranscriptionResponse) {
+	if err := os.MkdirAll(workDir, 0700); err != nil {
+		log.Printf("Error creating the chunk directory: %v\n", err)
+		return
+	}
+	data, err := json.Marshal(transcription)
+	if err != nil {
+		log.Printf("Error encoding chunk %d: %v\n", index+1, err)
+		return
+	}
+	if err := os.WriteFile(chunkPiecePath(workDir, index), data, 0600); err != nil {
+		log.Printf("Error saving chunk %d: %v\n", index+1, err)
+	}
+}
+
+// sizeChunkRegions divides total seconds of audio into enough equal ranges
+// that each stays under limit bytes, then moves each boundary to the
+// nearest pause within chunkBoundaryWindow so words are not cut in half.
+func sizeChunkRegions(total float64, size, limit int64, pauses []float64) []audioRegion {
+	count := int(math.Ceil(float64(size) / (float64(limit) * chunkSizeSafety)))
+	if count < 1 {
+		count = 1
+	}
+	length := total / float64(count)
+	window := length * chunkBoundaryWindow
+
+	var regions []audioRegion
+	start := 0.0
+	for i := 1; i < count; i++ {
+		boundary := float64(i) * length
+		best, bestDistance := boundary, math.Inf(1)
+		for _, pause := range pauses {
+			distance := math.Abs(pause - boundary)
+			if pause > start && distance <= window && distance < bestDistance {
+				best, bestDistance = pause, distance
+			}
+		}
+		regions = append(regions, audioRegion{Start: start, End: best})
+		start = best
+	}
+	return append(regions, audioRegion{Start: start, End: total})
+}
+
+// silenceMidpoints returns the middle of each complete silence interval in
+// silencedetect output.
+func silenceMidpoints(silencedetectOutput string) []float64 {
+	starts := silenceStartPattern.FindAllStringSubmatch(silencedetectOutput, -1)
+	ends := silenceEndPattern.FindAllStringSubmatch(silencedetectOutput, -1)
+
+	var midpoints []float64
+	for i := 0; i < len(starts) && i < len(ends); i++ {
+		start, _ := strconv.ParseFloat(starts[i][1], 64)
+		end, _ := strconv.ParseFloat(ends[i][1], 64)
+		midpoints = append(midpoints, (start+end)/2)
+	}
+	return midpoints
+}
+
+// writePartialTranscription writes the pieces transcribed before a failure
+// to <name>_incomplete.txt next to where the transcript would have gone,
+// under incompleteMarker.
+func writePartialTranscription(config Config, partial *partialTranscriptionError) error {
+	if config.NoTranscriptFile || config.NoOutput {
+		return nil
+	}
+	outputDir := config.OutputDir
+	if config.OutputURI == "" {
+		var err error
+		if outputDir, err = createOutputDir(config.OutputDir); err != nil {
+			return err
+		}
+	}
+	outputFileName := config.OutputFileName
+	if outputFileName == "" {
+		outputFileName = audioOutputName(config, config.AudioFilePath)
+	}
+	path := generateDerivedFilePath(filepath.Join(outputDir, outputFileName), "_incomplete.txt")
+
+	units := partial.units
+	content := fmt.Sprintf("%s %s 1-%d of %d, up to %s; stopped by: %v\n\n%s\n", incompleteMarker, strings.ToUpper(units[:1])+units[1:],
+		partial.done, partial.total, formatTimestamp(partial.transcription.Duration), partial.err, partial.transcription.Text)
+	if err := writeToFile(config, path, content); err != nil {
+		return err
+	}
+	log.Printf("Wrote the %d of %d %s transcribed before the failure to %s\n", partial.done, partial.total, units, path)
+	return nil
+}
@@ -0,0 +1,269 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptTail(t *testing.T) {
+	tests := []struct {
+		text     string
+		maxChars int
+		want     string
+	}{
+		{"  short text  ", 20, "short text"},
+		{"alpha beta gamma delta", 11, "delta"},
+		{"alpha beta gamma delta", 12, "gamma delta"},
+		{"", 10, ""},
+		{"日本語のテキスト", 7, "スト"},
+	}
+
+	for _, tt := range tests {
+		if got := promptTail(tt.text, tt.maxChars); got != tt.want {
+			t.Errorf("promptTail(%q, %d) = %q, want %q", tt.text, tt.maxChars, got, tt.want)
+		}
+	}
+}
+
+func TestChunkFormKeepsStyleAndExtraFields(t *testing.T) {
+	config := Config{TranscriptStyle: "verbatim"}
+	form := chunkForm(config, "Dr. Okonkwo said hello", map[string]string{"response_format": "verbose_json"})
+
+	if form["response_format"] != "verbose_json" {
+		t.Errorf("response_format = %q, want verbose_json", form["response_format"])
+	}
+	if want := transcriptStylePrompts["verbatim"] + " Dr. Okonkwo said hello"; form["prompt"] != want {
+		t.Errorf("prompt = %q, want %q", form["prompt"], want)
+	}
+
+	if _, ok := chunkForm(config, "", nil)["prompt"]; ok {
+		t.Error("first chunk should not override the style prompt")
+	}
+}
+
+func TestSizeChunkRegions(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  float64
+		size   int64
+		limit  int64
+		pauses []float64
+		want   []audioRegion
+	}{
+		{"under the limit", 600, 10, 24, nil, []audioRegion{{0, 600}}},
+		{"fixed cuts without pauses", 600, 50, 24, nil, []audioRegion{{0, 200}, {200, 400}, {400, 600}}},
+		{
+			name:   "cuts moved to the nearest pause in the window",
+			total:  600,
+			size:   50,
+			limit:  24,
+			pauses: []float64{150, 190, 205, 415, 470},
+			want:   []audioRegion{{0, 205}, {205, 415}, {415, 600}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sizeChunkRegions(tt.total, tt.size, tt.limit, tt.pauses)
+			if len(got) != len(tt.want) {
+				t.Fatalf("sizeChunkRegions() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("region %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSilenceMidpoints(t *testing.T) {
+	output := "[silencedetect @ 0x1] silence_start: 10.5\n[silencedetect @ 0x1] silence_end: 12.5 | silence_duration: 2\n[silencedetect @ 0x1] silence_start: 99\n"
+
+	got := silenceMidpoints(output)
+	if len(got) != 1 || got[0] != 11.5 {
+		t.Errorf("silenceMidpoints() = %v, want [11.5]", got)
+	}
+}
+
+func TestChunkFormLeadsWithVocabPrompt(t *testing.T) {
+	config := Config{VocabPrompt: "Okonkwo, tachycardia, SVT"}
+	form := chunkForm(config, strings.Repeat("word ", 200), nil)
+
+	if !strings.HasPrefix(form["prompt"], "Okonkwo, tachycardia, SVT word") {
+		t.Errorf("prompt = %q, want the vocabulary prompt before the previous chunk", form["prompt"])
+	}
+	if len(form["prompt"]) > maxPromptTailChars+len(" ") {
+		t.Errorf("prompt is %d characters, want the tail shortened to fit %d", len(form["prompt"]), maxPromptTailChars)
+	}
+}
+
+// fakeChunkTools puts a stand-in ffprobe that reports 60 seconds, or
+// $FAKE_DURATION, and a stand-in ffmpeg that finds no silences and writes
+// "chunk audio" for each extracted range on PATH, so files can be split
+// without real audio.
+func fakeChunkTools(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	scripts := map[string]string{
+		"ffprobe": "#!/bin/sh\necho ${FAKE_DURATION:-60}\n",
+		// silencedetect reports the silences in $FAKE_SILENCES, if any.
+		"ffmpeg": "#!/bin/sh\nprintf \"$FAKE_SILENCES\" >&2\nfor last; do :; done\n[ \"$last\" = - ] || printf 'chunk audio' > \"$last\"\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+	// Finished chunks are kept in the temp dir until the whole file is done.
+	t.Setenv("TMPDIR", t.TempDir())
+}
+
+// writeSparseAudio creates an MP3 of size bytes without writing them all.
+func writeSparseAudio(t *testing.T, path string, size int64) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("ID3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, size); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessTranscriptionWritesPartialChunks(t *testing.T) {
+	fakeChunkTools(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "bad chunk"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"text": "Part %d."}`, requests)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := validConfig()
+	config.TranscriptionFilePath = ""
+	config.AudioFilePath = filepath.Join(dir, "talk.mp3")
+	config.OutputDir = filepath.Join(dir, "output")
+	config.OpenAIAPIKey = "key"
+	config.BaseURL = server.URL
+	config.MaxChunkMB = 1
+	config.NoCache = true
+	config.RetryLog = "quiet"
+	writeSparseAudio(t, config.AudioFilePath, 3<<20)
+
+	_, _, err := processTranscription(config)
+	if err == nil || !strings.Contains(err.Error(), "transcribing chunk 3") {
+		t.Fatalf("processTranscription() = %v, want the chunk 3 error", err)
+	}
+	if code := exitCode(err); code == 0 {
+		t.Errorf("exitCode() = 0, want a failure")
+	}
+	data, err := os.ReadFile(filepath.Join(config.OutputDir, "talk_incomplete.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.HasPrefix(content, incompleteMarker+" Chunks 1-2 of 4") || !strings.HasSuffix(content, "\n\nPart 1. Part 2.\n") {
+		t.Errorf("partial transcript =\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(config.OutputDir, "talk.txt")); err == nil {
+		t.Error("the incomplete transcript was also written as the transcript")
+	}
+}
+
+func TestTranscribeLargeAudioResumesFinishedChunks(t *testing.T) {
+	fakeChunkTools(t)
+	var prompts []string
+	failChunk := 3
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prompts = append(prompts, r.FormValue("prompt"))
+		if len(prompts) == failChunk {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "bad chunk"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"text": "Request %d."}`, len(prompts))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "talk.mp3")
+	writeSparseAudio(t, path, 3<<20)
+	config := validConfig()
+	config.OpenAIAPIKey = "key"
+	config.BaseURL = server.URL
+	config.MaxChunkMB = 1
+	config.NoCache = true
+	config.RetryLog = "quiet"
+
+	var partial *partialTranscriptionError
+	if _, err := transcribeLargeAudio(config, path, 3<<20, nil); !errors.As(err, &partial) || partial.done != 2 {
+		t.Fatalf("first run = %v, want it to stop after 2 chunks", err)
+	}
+
+	prompts, failChunk = nil, 0
+	transcription, err := transcribeLargeAudio(config, path, 3<<20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 2 {
+		t.Errorf("rerun made %d requests, want 2 for the chunks that did not finish", len(prompts))
+	}
+	if len(prompts) > 0 && prompts[0] != "Request 2." {
+		t.Errorf("first rerun prompt = %q, want the text of the last saved chunk", prompts[0])
+	}
+	if want := "Request 1. Request 2. Request 1. Request 2."; transcription.Text != want {
+		t.Errorf("Text = %q, want %q", transcription.Text, want)
+	}
+
+	workDir, err := chunkWorkDir(config, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(workDir); !os.IsNotExist(err) {
+		t.Errorf("chunk directory %s is left after a successful run: %v", workDir, err)
+	}
+}
+
+func TestTranscribeLargeAudioKeepChunks(t *testing.T) {
+	fakeChunkTools(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Part."}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "talk.mp3")
+	writeSparseAudio(t, path, 3<<20)
+	config := validConfig()
+	config.OpenAIAPIKey = "key"
+	config.BaseURL = server.URL
+	config.MaxChunkMB = 1
+	config.NoCache = true
+	config.KeepChunks = true
+	if _, err := transcribeLargeAudio(config, path, 3<<20, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir, err := chunkWorkDir(config, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces, err := filepath.Glob(filepath.Join(workDir, "chunk_*.json"))
+	if err != nil || len(pieces) != 4 {
+		t.Errorf("kept chunks = %v (%v), want 4", pieces, err)
+	}
+}
@@ -0,0 +1,242 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const compareContextLines = 3
+
+var sentenceBreakPattern = regexp.MustCompile(`([.!?。！？])\s+`)
+
+// compareModels parses -compare into its two transcription models.
+func compareModels(value string) ([]string, error) {
+	models := strings.Split(value, ",")
+	for i := range models {
+		models[i] = strings.TrimSpace(models[i])
+	}
+	if len(models) != 2 {
+		return nil, fmt.Errorf("-compare expects two models separated by a comma, got %q", value)
+	}
+	if models[0] == models[1] {
+		return nil, fmt.Errorf("-compare needs two different models, got %s twice", models[0])
+	}
+	for _, model := range models {
+		if !slices.Contains(transcribeModels, model) {
+			return nil, fmt.Errorf("unknown -compare model %q: expected one of %s", model, strings.Join(transcribeModels, ", "))
+		}
+	}
+	return models, nil
+}
+
+// compareTranscriptions transcribes the -file input with both -compare
+// models, writes each transcript, and writes a unified diff between them
+// with one sentence per line.
+func compareTranscriptions(config Config, models []string) error {
+	if err := checkAudioFile(config); err != nil {
+		return err
+	}
+
+	outputDir := config.OutputDir
+	if config.OutputURI == "" && !config.NoOutput {
+		var err error
+		if outputDir, err = createOutputDir(config.OutputDir); err != nil {
+			return err
+		}
+	}
+	name := config.OutputFileName
+	if name == "" {
+		name = audioOutputName(config, config.AudioFilePath)
+	}
+	basePath := versionOutputPath(config, filepath.Join(outputDir, name))
+
+	uploadPath, cleanup, err := uploadSource(config)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var paths, texts []string
+	for _, model := range models {
+		log.Printf("Transcribing with %s...\n", model)
+		// Plain text only: the gpt-4o models reject verbose_json, which
+		// segment features would otherwise request.
+		modelConfig := config
+		modelConfig.TranscribeModel = model
+		modelConfig.InlineSummary, modelConfig.PostProcessCmd, modelConfig.Format = false, "", "text"
+		transcription, err := transcribeFile(modelConfig, uploadPath)
+		if err != nil {
+			return fmt.Errorf("transcribing with %s: %w", model, err)
+		}
+
+		path := generateDerivedFilePath(basePath, "_"+model+".txt")
+		if err := writeToFile(config, path, transcription.Text); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		texts = append(texts, transcription.Text)
+	}
+
+	a, b := splitSentences(texts[0]), splitSentences(texts[1])
+	diff, changed := unifiedDiff(paths[0], paths[1], a, b)
+	if changed == 0 {
+		log.Printf("%s and %s produced the same transcript\n", models[0], models[1])
+	} else {
+		log.Printf("%d of %d sentences differ between %s and %s\n", changed, max(len(a), len(b)), models[0], models[1])
+	}
+	return writeToFile(config, generateDerivedFilePath(basePath, "_compare.diff"), diff)
+}
+
+// splitSentences puts each sentence of a transcript on its own line, so a
+// line diff shows which sentences the models disagree on.
+func splitSentences(text string) []string {
+	var sentences []string
+	for _, line := range strings.Split(sentenceBreakPattern.ReplaceAllString(text, "$1\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sentences = append(sentences, line)
+		}
+	}
+	return sentences
+}
+
+// splitBetweenSentences cuts text into pieces of at most about maxChars,
+// between sentences.
+func splitBetweenSentences(text string, maxChars int) []string {
+	var pieces []string
+	var b strings.Builder
+	for _, sentence := range splitSentences(text) {
+		if b.Len() > 0 && b.Len()+len(sentence)+1 > maxChars {
+			pieces = append(pieces, b.String())
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(sentence)
+	}
+	if b.Len() > 0 {
+		pieces = append(pieces, b.String())
+	}
+	return pieces
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff of a and b and the number of lines of
+// the longer side that changed.
+func unifiedDiff(nameA, nameB string, a, b []string) (string, int) {
+	ops := diffLines(a, b)
+
+	removed, added := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case '-':
+			removed++
+		case '+':
+			added++
+		}
+	}
+	if removed == 0 && added == 0 {
+		return "", 0
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	// Line numbers in a and b at the start of each op.
+	lineA, lineB := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		lineA[i+1], lineB[i+1] = lineA[i], lineB[i]
+		if op.kind != '+' {
+			lineA[i+1]++
+		}
+		if op.kind != '-' {
+			lineB[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+
+		// Changes separated by at most twice the context share a hunk.
+		start, last := max(i-compareContextLines, 0), i
+		for j := i + 1; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				if j-last-1 > 2*compareContextLines {
+					break
+				}
+				last = j
+			}
+		}
+		end := min(last+compareContextLines+1, len(ops))
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(lineA[start], lineA[end]), hunkRange(lineB[start], lineB[end]))
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		i = end - 1
+	}
+
+	return out.String(), max(removed, added)
+}
+
+func hunkRange(from, to int) string {
+	if to-from == 1 {
+		return fmt.Sprintf("%d", from+1)
+	}
+	if to == from {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+// diffLines computes a shortest edit script between a and b from their
+// longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
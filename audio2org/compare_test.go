@@ -0,0 +1,68 @@
+package audio2org
+
+import "testing"
+
+func TestCompareModels(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"whisper-1,gpt-4o-transcribe", false},
+		{" whisper-1 , gpt-4o-mini-transcribe ", false},
+		{"whisper-1", true},
+		{"whisper-1,whisper-1", true},
+		{"whisper-1,gpt-5", true},
+		{"whisper-1,gpt-4o-transcribe,gpt-4o-mini-transcribe", true},
+	}
+
+	for _, tt := range tests {
+		if _, err := compareModels(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("compareModels(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("Hello there. How are you?  Fine!\nOK")
+	want := []string{"Hello there.", "How are you?", "Fine!", "OK"}
+	if len(got) != len(want) {
+		t.Fatalf("splitSentences() = %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("sentence %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := []string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve"}
+	b := []string{"one", "too", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve", "thirteen"}
+
+	want := `--- a.txt
++++ b.txt
+@@ -1,5 +1,5 @@
+ one
+-two
++too
+ three
+ four
+ five
+@@ -10,3 +10,4 @@
+ ten
+ eleven
+ twelve
++thirteen
+`
+	got, changed := unifiedDiff("a.txt", "b.txt", a, b)
+	if got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if changed != 2 {
+		t.Errorf("changed = %d, want 2", changed)
+	}
+
+	if got, changed := unifiedDiff("a.txt", "b.txt", a, a); got != "" || changed != 0 {
+		t.Errorf("unifiedDiff() of identical input = %q, %d", got, changed)
+	}
+}
@@ -0,0 +1,77 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+func checkDropLowConfidence(config Config) error {
+	if !config.DropLowConfidence {
+		for _, name := range []string{"no-speech-threshold", "logprob-threshold"} {
+			if isFlagSet(name) {
+				return fmt.Errorf("-%s requires -drop-low-confidence", name)
+			}
+		}
+		return nil
+	}
+	switch {
+	case config.Backend == "local":
+		return errors.New("-drop-low-confidence needs the segment confidence Whisper reports, which -backend local does not parse")
+	case config.NoSpeechThreshold < 0 || config.NoSpeechThreshold > 1:
+		return fmt.Errorf("-no-speech-threshold must be between 0 and 1, got %g", config.NoSpeechThreshold)
+	case config.LogprobThreshold > 0:
+		return fmt.Errorf("-logprob-threshold must not be positive, got %g", config.LogprobThreshold)
+	}
+	return nil
+}
+
+func lowConfidence(config Config, segment TranscriptionSegment) bool {
+	return segment.NoSpeechProb > config.NoSpeechThreshold || segment.AvgLogprob < config.LogprobThreshold
+}
+
+// dropLowConfidence removes the segments Whisper was unsure of, and the
+// words inside them, and rebuilds the text from the segments that are left.
+func dropLowConfidence(config Config, transcription TranscriptionResponse) TranscriptionResponse {
+	var kept, dropped []TranscriptionSegment
+	for _, segment := range transcription.Segments {
+		if lowConfidence(config, segment) {
+			dropped = append(dropped, segment)
+		} else {
+			kept = append(kept, segment)
+		}
+	}
+	if len(dropped) == 0 {
+		return transcription
+	}
+
+	var texts []string
+	for _, segment := range kept {
+		if text := strings.TrimSpace(segment.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	var words []TranscriptionWord
+	for _, word := range transcription.Words {
+		inDropped := false
+		for _, segment := range dropped {
+			if word.Start >= segment.Start && word.Start < segment.End {
+				inDropped = true
+				break
+			}
+		}
+		if !inDropped {
+			words = append(words, word)
+		}
+	}
+
+	for _, segment := range dropped {
+		log.Printf("Dropped low-confidence segment at %s (no_speech_prob %.2f, avg_logprob %.2f): %s\n",
+			formatTimestamp(segment.Start), segment.NoSpeechProb, segment.AvgLogprob, strings.TrimSpace(segment.Text))
+	}
+	transcription.Segments = kept
+	transcription.Words = words
+	transcription.Text = strings.Join(texts, " ")
+	return transcription
+}
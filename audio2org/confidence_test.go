@@ -0,0 +1,78 @@
+package audio2org
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDropLowConfidence(t *testing.T) {
+	config := Config{DropLowConfidence: true, NoSpeechThreshold: 0.6, LogprobThreshold: -1}
+	transcription := TranscriptionResponse{
+		Text: " Hello there. Thanks for watching! Let's begin.",
+		Segments: []TranscriptionSegment{
+			{ID: 0, Start: 0, End: 2, Text: " Hello there.", AvgLogprob: -0.2, NoSpeechProb: 0.01},
+			{ID: 1, Start: 2, End: 5, Text: " Thanks for watching!", AvgLogprob: -0.4, NoSpeechProb: 0.9},
+			{ID: 2, Start: 5, End: 7, Text: " Um", AvgLogprob: -1.6, NoSpeechProb: 0.1},
+			{ID: 3, Start: 7, End: 9, Text: " Let's begin.", AvgLogprob: -0.3, NoSpeechProb: 0.05},
+		},
+		Words: []TranscriptionWord{
+			{Word: "Hello", Start: 0, End: 1},
+			{Word: "Thanks", Start: 2.5, End: 3},
+			{Word: "Um", Start: 5, End: 5.5},
+			{Word: "Let's", Start: 7, End: 7.5},
+		},
+	}
+
+	got := dropLowConfidence(config, transcription)
+	if want := "Hello there. Let's begin."; got.Text != want {
+		t.Errorf("text = %q, want %q", got.Text, want)
+	}
+	var ids []int
+	for _, segment := range got.Segments {
+		ids = append(ids, segment.ID)
+	}
+	if want := []int{0, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("kept segments %v, want %v", ids, want)
+	}
+	var words []string
+	for _, word := range got.Words {
+		words = append(words, word.Word)
+	}
+	if want := []string{"Hello", "Let's"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("kept words %v, want %v", words, want)
+	}
+
+	config.NoSpeechThreshold, config.LogprobThreshold = 1, -2
+	if got := dropLowConfidence(config, transcription); got.Text != transcription.Text {
+		t.Errorf("with nothing to drop, text = %q, want it unchanged", got.Text)
+	}
+}
+
+func TestCheckDropLowConfidence(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"off", Config{}, ""},
+		{"defaults", Config{DropLowConfidence: true, NoSpeechThreshold: 0.6, LogprobThreshold: -1}, ""},
+		{"local backend", Config{DropLowConfidence: true, Backend: "local", NoSpeechThreshold: 0.6, LogprobThreshold: -1}, "-backend local"},
+		{"no-speech above 1", Config{DropLowConfidence: true, NoSpeechThreshold: 1.5, LogprobThreshold: -1}, "-no-speech-threshold"},
+		{"positive logprob", Config{DropLowConfidence: true, NoSpeechThreshold: 0.6, LogprobThreshold: 0.5}, "-logprob-threshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDropLowConfidence(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkDropLowConfidence() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkDropLowConfidence() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package audio2org
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// configFileFlags holds the flags whose values came from the -config file,
+// which environment variables such as AUDIO2ORG_DEFAULT_POST override.
+var configFileFlags = map[string]bool{}
+
+type configSetting struct {
+	line   int
+	name   string
+	values []string
+}
+
+// applyConfigFile sets the flags named in the -config file that were not
+// given on the command line, and returns their names. The file is a flat
+// TOML document of flag names without the dash:
+//
+//	summary-model = "gpt-4o"
+//	temperature = 0.3
+//	file = ["intro.m4a", "outro.m4a"]
+func applyConfigFile(fs *flag.FlagSet, path string) ([]string, error) {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var applied []string
+	seen := map[string]bool{}
+	for _, setting := range settings {
+		switch {
+		case seen[setting.name]:
+			return nil, fmt.Errorf("%s:%d: %s is set twice", path, setting.line, setting.name)
+		case setting.name == "config":
+			return nil, fmt.Errorf("%s:%d: a config file cannot name another config file", path, setting.line)
+		case fs.Lookup(setting.name) == nil:
+			return nil, fmt.Errorf("%s:%d: unknown setting %q: expected a flag name such as summary-model", path, setting.line, setting.name)
+		}
+		seen[setting.name] = true
+		if given[setting.name] {
+			continue
+		}
+		for _, value := range setting.values {
+			if err := fs.Set(setting.name, value); err != nil {
+				return nil, fmt.Errorf("%s:%d: %s: %w", path, setting.line, setting.name, err)
+			}
+		}
+		applied = append(applied, setting.name)
+	}
+	return applied, nil
+}
+
+func readConfigFile(path string) ([]configSetting, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading -config: %w", err)
+	}
+	defer f.Close()
+
+	var settings []configSetting
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			return nil, fmt.Errorf("%s:%d: tables are not supported; list the settings at the top level", path, line)
+		}
+
+		name, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected name = value", path, line)
+		}
+		values, err := parseConfigValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		settings = append(settings, configSetting{line: line, name: strings.TrimSpace(name), values: values})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading -config: %w", err)
+	}
+	return settings, nil
+}
+
+// parseConfigValue reads a TOML string, number, boolean, or one-line array
+// of them, followed by an optional comment.
+func parseConfigValue(text string) ([]string, error) {
+	if !strings.HasPrefix(text, "[") {
+		value, rest, err := parseConfigScalar(text)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkConfigRest(rest); err != nil {
+			return nil, err
+		}
+		return []string{value}, nil
+	}
+
+	var values []string
+	rest := strings.TrimSpace(text[1:])
+	for !strings.HasPrefix(rest, "]") {
+		value, after, err := parseConfigScalar(rest)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		rest = strings.TrimSpace(after)
+		if strings.HasPrefix(rest, ",") {
+			rest = strings.TrimSpace(rest[1:])
+		} else if !strings.HasPrefix(rest, "]") {
+			return nil, errors.New("expected , or ] in array")
+		}
+	}
+	if err := checkConfigRest(rest[1:]); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseConfigScalar reads one value from the start of text and returns it
+// with the text after it.
+func parseConfigScalar(text string) (string, string, error) {
+	switch {
+	case text == "":
+		return "", "", errors.New("missing value")
+	case text[0] == '"':
+		for i := 1; i < len(text); i++ {
+			switch text[i] {
+			case '\\':
+				i++
+			case '"':
+				value, err := strconv.Unquote(text[:i+1])
+				if err != nil {
+					return "", "", fmt.Errorf("invalid string %s", text[:i+1])
+				}
+				return value, text[i+1:], nil
+			}
+		}
+		return "", "", errors.New("unterminated string")
+	case text[0] == '\'':
+		end := strings.IndexByte(text[1:], '\'')
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+		return text[1 : end+1], text[end+2:], nil
+	}
+
+	end := strings.IndexAny(text, " \t,]#")
+	if end < 0 {
+		end = len(text)
+	}
+	value := text[:end]
+	if value != "true" && value != "false" {
+		if _, err := strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64); err != nil {
+			return "", "", fmt.Errorf("invalid value %s: strings must be quoted", value)
+		}
+		value = strings.ReplaceAll(value, "_", "")
+	}
+	return value, text[end:], nil
+}
+
+func checkConfigRest(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after the value", rest)
+	}
+	return nil
+}
+
+// isSetOnCommandLine reports whether the named flag was given on the
+// command line rather than in the -config file.
+func isSetOnCommandLine(name string) bool {
+	return isFlagSet(name) && !configFileFlags[name]
+}
+
+// applyEnvOverrides sets the flags that have an environment variable from
+// it when it is set, over both the -config file and the command line. A
+// variable that replaces a flag given on the command line is logged.
+func applyEnvOverrides(config *Config) {
+	for _, v := range []struct {
+		env, flag string
+		value     *string
+	}{
+		{"OPENAI_BASE_URL", "base-url", &config.BaseURL},
+		{"OPENAI_ORG_ID", "org-id", &config.OrgID},
+		{"OPENAI_PROJECT_ID", "project-id", &config.ProjectID},
+	} {
+		value := os.Getenv(v.env)
+		if value == "" {
+			continue
+		}
+		if isSetOnCommandLine(v.flag) && *v.value != value {
+			log.Printf("%s overrides -%s\n", v.env, v.flag)
+		}
+		*v.value = value
+	}
+}
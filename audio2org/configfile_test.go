@@ -0,0 +1,111 @@
+package audio2org
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio2org.toml")
+	content := `# Defaults for interviews
+summary-model = "gpt-4o"   # the notes model
+temperature = 0.3
+max-tokens = 4_000
+no-cache = true
+shutdown-grace = '10s'
+file = ["intro.m4a", "outro.m4a"]
+output-dir = "notes"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var config Config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&config.SummaryModel, "summary-model", "gpt-4o-mini", "")
+	fs.Float64Var(&config.Temperature, "temperature", 0.7, "")
+	fs.IntVar(&config.MaxTokens, "max-tokens", 1000, "")
+	fs.BoolVar(&config.NoCache, "no-cache", false, "")
+	fs.DurationVar(&config.ShutdownGrace, "shutdown-grace", 25*time.Second, "")
+	fs.Var(&config.AudioFiles, "file", "")
+	fs.StringVar(&config.OutputDir, "output-dir", "output", "")
+	if err := fs.Parse([]string{"-output-dir", "elsewhere"}); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := applyConfigFile(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.SummaryModel != "gpt-4o" || config.Temperature != 0.3 || config.MaxTokens != 4000 || !config.NoCache || config.ShutdownGrace != 10*time.Second {
+		t.Errorf("config = %+v, want the file's values", config)
+	}
+	if !reflect.DeepEqual([]string(config.AudioFiles), []string{"intro.m4a", "outro.m4a"}) {
+		t.Errorf("AudioFiles = %q, want both files from the array", config.AudioFiles)
+	}
+	if config.OutputDir != "elsewhere" {
+		t.Errorf("OutputDir = %q, want the command line to win over the file", config.OutputDir)
+	}
+	want := []string{"summary-model", "temperature", "max-tokens", "no-cache", "shutdown-grace", "file"}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied = %q, want %q", applied, want)
+	}
+}
+
+func TestApplyConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown setting", `summary_model = "gpt-4o"`, `:1: unknown setting "summary_model"`},
+		{"unquoted string", "\nsummary-model = gpt-4o", ":2: invalid value gpt-4o: strings must be quoted"},
+		{"invalid flag value", `max-tokens = "many"`, ":1: max-tokens: "},
+		{"table", "[notes]\nsummary-model = \"gpt-4o\"", ":1: tables are not supported"},
+		{"set twice", "max-tokens = 1\nmax-tokens = 2", ":2: max-tokens is set twice"},
+		{"unterminated string", `summary-model = "gpt-4o`, ":1: unterminated string"},
+		{"trailing text", `max-tokens = 1 2`, `:1: unexpected "2" after the value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audio2org.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("summary-model", "", "")
+			fs.Int("max-tokens", 0, "")
+
+			_, err := applyConfigFile(fs, path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("applyConfigFile() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv("OPENAI_BASE_URL", "https://gateway.internal/openai/v1")
+	t.Setenv("OPENAI_ORG_ID", "")
+	t.Setenv("OPENAI_PROJECT_ID", "proj_env")
+	config := Config{BaseURL: "https://flag.example/v1", OrgID: "org_flag"}
+
+	applyEnvOverrides(&config)
+
+	if config.BaseURL != "https://gateway.internal/openai/v1" {
+		t.Errorf("BaseURL = %q, want OPENAI_BASE_URL to win over the flag", config.BaseURL)
+	}
+	if config.OrgID != "org_flag" {
+		t.Errorf("OrgID = %q, want the flag kept when OPENAI_ORG_ID is empty", config.OrgID)
+	}
+	if config.ProjectID != "proj_env" {
+		t.Errorf("ProjectID = %q, want OPENAI_PROJECT_ID", config.ProjectID)
+	}
+}
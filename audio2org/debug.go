@@ -0,0 +1,122 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var debugBundleSeq int
+
+func writeDebugConfig(config Config) {
+	if config.DebugBundleDir == "" {
+		return
+	}
+
+	if config.OpenAIAPIKey != "" {
+		config.OpenAIAPIKey = redacted
+	}
+	if len(config.WebhookHeaders) > 0 {
+		headers := make(headerFlags, len(config.WebhookHeaders))
+		for i, header := range config.WebhookHeaders {
+			name, _, _ := strings.Cut(header, ":")
+			headers[i] = name + ": " + redacted
+		}
+		config.WebhookHeaders = headers
+	}
+	// Presigned -file URLs keep their signature in the query, and webhook
+	// URLs such as Slack's keep their token in the path.
+	if isAudioURL(config.AudioFilePath) {
+		config.AudioFilePath = redactURL(config.AudioFilePath)
+	}
+	files := make(fileFlags, len(config.AudioFiles))
+	for i, file := range config.AudioFiles {
+		files[i] = file
+		if isAudioURL(file) {
+			files[i] = redactURL(file)
+		}
+	}
+	config.AudioFiles = files
+	config.BaseURL = redactURL(config.BaseURL)
+	if config.S3Endpoint != "" {
+		config.S3Endpoint = redactURL(config.S3Endpoint)
+	}
+	if config.WebhookURL != "" {
+		config.WebhookURL = redactURLPath(config.WebhookURL)
+	}
+	writeDebugFile(config, "config.json", marshalDebugJSON(config))
+}
+
+// redactURLPath keeps only the scheme and host of rawURL.
+func redactURLPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	return u.Scheme + "://" + u.Host + "/" + redacted
+}
+
+func saveDebugExchange(config Config, kind, url string, body interface{}, prompt string, response []byte) {
+	if config.DebugBundleDir == "" {
+		return
+	}
+
+	debugBundleSeq++
+	prefix := fmt.Sprintf("%02d_%s", debugBundleSeq, kind)
+
+	request := map[string]interface{}{
+		"url":     redactURL(url),
+		"headers": apiHeaders(config, redacted),
+		"body":    body,
+	}
+	writeDebugFile(config, prefix+"_request.json", marshalDebugJSON(request))
+
+	if prompt != "" {
+		writeDebugFile(config, prefix+"_prompt.txt", []byte(prompt))
+	}
+	writeDebugFile(config, prefix+"_response.json", response)
+}
+
+func chatPromptText(reqBody map[string]interface{}) string {
+	messages, ok := reqBody["messages"].([]map[string]string)
+	if !ok {
+		return ""
+	}
+
+	if len(messages) == 1 {
+		return messages[0]["content"]
+	}
+
+	var b strings.Builder
+	for _, message := range messages {
+		fmt.Fprintf(&b, "--- %s ---\n%s\n\n", message["role"], message["content"])
+	}
+	return b.String()
+}
+
+func marshalDebugJSON(v interface{}) []byte {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding debug bundle entry: %v\n", err)
+		return nil
+	}
+	return data
+}
+
+func writeDebugFile(config Config, name string, content []byte) {
+	if err := os.MkdirAll(config.DebugBundleDir, 0755); err != nil {
+		log.Printf("Error creating debug bundle directory: %v\n", err)
+		return
+	}
+
+	path := filepath.Join(config.DebugBundleDir, name)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		log.Printf("Error writing debug bundle file: %v\n", err)
+	}
+}
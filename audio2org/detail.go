@@ -0,0 +1,57 @@
+package audio2org
+
+import "fmt"
+
+// detailLevel is the length guidance -detail-level puts in the built-in
+// notes prompts, and the max_tokens that goes with it.
+type detailLevel struct {
+	// guidance replaces the paragraphs on how thoroughly to summarize.
+	guidance string
+	// summary and note finish the structure items for the Summary and
+	// Notes sections.
+	summary   string
+	note      string
+	maxTokens int
+}
+
+var detailLevels = map[string]detailLevel{
+	"brief": {
+		guidance: `Keep the notes brief. Cover only the main points, decisions, and conclusions, leaving out examples, asides, and supporting detail unless the point makes no sense without them. Aim for about 300 words in total.
+
+Prefer short sentences and bullet points over paragraphs, and do not repeat in the notes what the summary already says.`,
+		summary:   "in two or three sentences.",
+		note:      "Keep each note to one or two lines.",
+		maxTokens: 1500,
+	},
+	"normal": {
+		guidance: `Summarize each section thoroughly, ensuring you provide detailed explanations, examples, and sufficient elaboration on each point. The summary should capture the nuances of the content, including specific insights and supporting details that were mentioned in the original material.
+
+Make sure the summary is detailed, capturing key points while providing ample context and depth. Avoid being too brief or overly terse, and ensure that the elaboration provides useful, actionable insights in every section.`,
+		summary:   "with detailed elaboration.",
+		note:      "For each note, please ensure that detailed explanations, examples, and any relevant insights are included.",
+		maxTokens: 3000,
+	},
+	"detailed": {
+		guidance: `Write comprehensive notes that someone who missed the recording could rely on instead of it. Cover every topic that comes up, in the order it comes up, with the reasoning, examples, numbers, names, and open questions that were mentioned, and quote short phrases where the exact wording matters.
+
+Do not merge or skip topics to save space; the notes may be long. Each section should have several paragraphs or a detailed list rather than a single line.`,
+		summary:   "in one or two paragraphs.",
+		note:      "Give each note several sentences with the full explanation, examples, and any figures or names mentioned.",
+		maxTokens: 6000,
+	},
+}
+
+func checkDetailLevel(config Config) error {
+	if _, ok := detailLevels[config.DetailLevel]; !ok {
+		return fmt.Errorf("unknown -detail-level %q: expected brief, normal, or detailed", config.DetailLevel)
+	}
+	return nil
+}
+
+// notesDetail is the -detail-level in use, normal when it is unknown.
+func notesDetail(level string) detailLevel {
+	if detail, ok := detailLevels[level]; ok {
+		return detail
+	}
+	return detailLevels["normal"]
+}
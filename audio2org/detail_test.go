@@ -0,0 +1,41 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetailLevelPrompts(t *testing.T) {
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		level   string
+		want    string
+		notWant string
+	}{
+		{"brief", "Keep the notes brief.", "Avoid being too brief"},
+		{"normal", "Avoid being too brief", "Keep the notes brief."},
+		{"detailed", "Write comprehensive notes", "Avoid being too brief"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			prompts := map[string]string{
+				"org":      defaultOrgSystemPrompt(date, tt.level),
+				"markdown": createMarkdownPrompt("the transcript", date, tt.level),
+			}
+			detail := notesDetail(tt.level)
+			for name, prompt := range prompts {
+				if !strings.Contains(prompt, tt.want) || strings.Contains(prompt, tt.notWant) {
+					t.Errorf("%s prompt for -detail-level %s does not ask for %q only:\n%s", name, tt.level, tt.want, prompt)
+				}
+				if !strings.Contains(prompt, "key points, "+detail.summary) || !strings.Contains(prompt, "logically. "+detail.note) {
+					t.Errorf("%s prompt for -detail-level %s does not describe the sections at that level:\n%s", name, tt.level, prompt)
+				}
+			}
+		})
+	}
+
+	if err := checkDetailLevel(Config{DetailLevel: "short"}); err == nil || !strings.Contains(err.Error(), "brief, normal, or detailed") {
+		t.Errorf("checkDetailLevel(short) = %v, want an error listing the levels", err)
+	}
+}
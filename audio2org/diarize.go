@@ -0,0 +1,56 @@
+package audio2org
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whisperCppSpeakerTurn is the marker whisper.cpp prints at the end of a
+// segment with -tdrz when the next one is spoken by someone else.
+const whisperCppSpeakerTurn = "[SPEAKER_TURN]"
+
+// labelSpeakerTurns gives each segment a speaker label, changing to the
+// other speaker after every turn. tinydiarize finds turns, not voices, so
+// the labels alternate between two speakers.
+func labelSpeakerTurns(segments []TranscriptionSegment, turns []bool) {
+	speaker := 1
+	for i := range segments {
+		segments[i].Speaker = fmt.Sprintf("Speaker %d", speaker)
+		if turns[i] {
+			speaker = 3 - speaker
+		}
+	}
+}
+
+// speakerText is the segment's text after its speaker label, if any.
+func speakerText(segment TranscriptionSegment) string {
+	text := strings.TrimSpace(segment.Text)
+	if segment.Speaker == "" {
+		return text
+	}
+	return segment.Speaker + ": " + text
+}
+
+// formatSpeakerTurns joins the segments into one paragraph per turn, each
+// starting with its speaker label, for the -diarize transcript.
+func formatSpeakerTurns(segments []TranscriptionSegment) string {
+	var turns []string
+	var current []string
+	speaker := ""
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		if segment.Speaker != speaker && len(current) > 0 {
+			turns = append(turns, speaker+": "+strings.Join(current, " "))
+			current = nil
+		}
+		speaker = segment.Speaker
+		current = append(current, text)
+	}
+	if len(current) > 0 {
+		turns = append(turns, speaker+": "+strings.Join(current, " "))
+	}
+	return strings.Join(turns, "\n\n")
+}
@@ -0,0 +1,25 @@
+package audio2org
+
+import "testing"
+
+func TestFormatSpeakerTurns(t *testing.T) {
+	segments := []TranscriptionSegment{
+		{Text: " How did the trial go?", Speaker: "Speaker 1"},
+		{Text: " Better than expected.", Speaker: "Speaker 2"},
+		{Text: " ", Speaker: "Speaker 2"},
+		{Text: " We enrolled forty patients.", Speaker: "Speaker 2"},
+		{Text: " That's great news.", Speaker: "Speaker 1"},
+	}
+
+	want := "Speaker 1: How did the trial go?\n\nSpeaker 2: Better than expected. We enrolled forty patients.\n\nSpeaker 1: That's great news."
+	if got := formatSpeakerTurns(segments); got != want {
+		t.Errorf("formatSpeakerTurns() = %q, want %q", got, want)
+	}
+}
+
+func TestDiarizeNeedsLocalBackend(t *testing.T) {
+	err := checkLocalBackend(Config{Backend: "openai", Diarize: true})
+	if err == nil {
+		t.Fatal("checkLocalBackend() accepted -diarize with the openai backend")
+	}
+}
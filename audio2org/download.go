@@ -0,0 +1,258 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// downloadAttempts is how many times a download that ends early is resumed
+// from where it stopped before giving up.
+const downloadAttempts = 3
+
+var audioContentTypes = map[string]string{
+	"audio/mpeg":   ".mp3",
+	"audio/mp3":    ".mp3",
+	"audio/mp4":    ".m4a",
+	"audio/x-m4a":  ".m4a",
+	"audio/wav":    ".wav",
+	"audio/x-wav":  ".wav",
+	"audio/wave":   ".wav",
+	"audio/webm":   ".webm",
+	"audio/ogg":    ".ogg",
+	"audio/flac":   ".flac",
+	"audio/x-flac": ".flac",
+	"video/mp4":    ".mp4",
+	"video/webm":   ".webm",
+}
+
+// isAudioURL reports whether a -file value is an http(s) URL to download
+// rather than a local path.
+func isAudioURL(value string) bool {
+	lower := strings.ToLower(value)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// checkURLInput rejects -checksum without a -file URL, a malformed
+// checksum, and the features that need the audio to stay on disk after the
+// run.
+func checkURLInput(config Config) error {
+	if !isAudioURL(config.AudioFilePath) {
+		if config.Checksum != "" {
+			return errors.New("-checksum only applies to a -file URL")
+		}
+		return nil
+	}
+
+	if _, err := parseChecksum(config.Checksum); err != nil {
+		return err
+	}
+	switch {
+	case config.MaxDownloadMB <= 0:
+		return fmt.Errorf("-max-download-mb must be positive, got %d", config.MaxDownloadMB)
+	case hasPostStep(config, "create_org_transcript"):
+		return errors.New("create_org_transcript links to the audio file, which a -file URL only downloads to a temp file")
+	case config.Clock:
+		return errors.New("-clock uses the audio file's modification time, which a -file URL does not have")
+	case config.Resume != "":
+		return errors.New("-resume cannot tell whether a URL holds the same audio as before; download it to a file first")
+	}
+	return nil
+}
+
+// parseChecksum accepts a SHA-256 digest as hex, optionally prefixed with
+// "sha256:", and returns it in lowercase. An empty value is no checksum.
+func parseChecksum(value string) (string, error) {
+	digest := strings.ToLower(strings.TrimSpace(value))
+	if algorithm, rest, ok := strings.Cut(digest, ":"); ok {
+		if algorithm != "sha256" {
+			return "", fmt.Errorf("-checksum %q: only sha256 is supported", value)
+		}
+		digest = rest
+	}
+	if digest == "" && value == "" {
+		return "", nil
+	}
+	if len(digest) != 64 || strings.Trim(digest, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("-checksum %q is not a SHA-256 digest of 64 hex digits", value)
+	}
+	return digest, nil
+}
+
+// urlAudioExtension picks the extension for the downloaded file, from the
+// URL's path or else from the response's Content-Type, so the upload has a
+// name Whisper can infer the format from.
+func urlAudioExtension(rawURL, contentType string) (string, error) {
+	if u, err := url.Parse(rawURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); slices.Contains(supportedExtensions, ext) {
+			return ext, nil
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if ext, ok := audioContentTypes[mediaType]; ok {
+		return ext, nil
+	}
+	return "", fmt.Errorf("cannot tell the audio format of %s: the path has no known extension and the Content-Type is %q", redactURL(rawURL), contentType)
+}
+
+// urlBaseName is the file name at the end of the URL's path, which the
+// outputs are named after.
+func urlBaseName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "/" && base != "." {
+			return base
+		}
+	}
+	return "download"
+}
+
+// redactURL drops the query string, where presigned URLs keep their
+// credentials, so the URL can be logged.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "the -file URL"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// downloadAudio streams the -file URL into a temp file of at most
+// -max-download-mb, resuming with a Range request when the connection
+// drops part way, and checks the result against -checksum. A response that
+// is an HTML page, such as a login or error page served with status 200,
+// is rejected rather than uploaded as audio.
+func downloadAudio(config Config) (_ string, err error) {
+	rawURL := config.AudioFilePath
+	limit := int64(config.MaxDownloadMB) * 1024 * 1024
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		filePath string
+		f        *os.File
+		total    int64 = -1
+		written  int64
+	)
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+		if err != nil && filePath != "" {
+			removeTempFile(filePath)
+		}
+	}()
+	log.Printf("Downloading %s...\n", redactURL(rawURL))
+	for attempt := 1; ; attempt++ {
+		request := client.R().SetContext(runContext(config)).SetDoNotParseResponse(true)
+		if written > 0 {
+			request.SetHeader("Range", fmt.Sprintf("bytes=%d-", written))
+		}
+		resp, err := request.Get(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("downloading %s: %w", redactURL(rawURL), err)
+		}
+		body := resp.RawBody()
+		status := resp.StatusCode()
+
+		switch {
+		case written > 0 && (status != http.StatusPartialContent || !strings.HasPrefix(resp.Header().Get("Content-Range"), fmt.Sprintf("bytes %d-", written))):
+			body.Close()
+			return "", fmt.Errorf("downloading %s: the connection dropped after %d bytes and the server does not support resuming (status %s)", redactURL(rawURL), written, resp.Status())
+		case status < 200 || status > 299:
+			snippet, _ := io.ReadAll(io.LimitReader(body, 512))
+			body.Close()
+			return "", fmt.Errorf("downloading %s: %s\n%s", redactURL(rawURL), resp.Status(), strings.TrimSpace(string(snippet)))
+		}
+
+		if f == nil {
+			if total, err = strconv.ParseInt(resp.Header().Get("Content-Length"), 10, 64); err != nil {
+				total = -1
+			}
+			if total > limit {
+				body.Close()
+				return "", fmt.Errorf("downloading %s: the file is %.1f MB, over -max-download-mb %d", redactURL(rawURL), float64(total)/(1024*1024), config.MaxDownloadMB)
+			}
+			head := make([]byte, 512)
+			n, _ := io.ReadFull(body, head)
+			head = head[:n]
+			contentType := resp.Header().Get("Content-Type")
+			if strings.HasPrefix(contentType, "text/html") || strings.HasPrefix(http.DetectContentType(head), "text/html") {
+				body.Close()
+				return "", fmt.Errorf("downloading %s: the server returned an HTML page, not audio; check that the URL is public or still valid", redactURL(rawURL))
+			}
+			ext, err := urlAudioExtension(rawURL, contentType)
+			if err != nil {
+				body.Close()
+				return "", err
+			}
+			if filePath, err = createTempFile("download", ext); err != nil {
+				body.Close()
+				return "", err
+			}
+			if f, err = os.OpenFile(filePath, os.O_WRONLY|os.O_TRUNC, 0600); err != nil {
+				body.Close()
+				return "", fmt.Errorf("opening temp file: %w", err)
+			}
+			if _, err := f.Write(head); err != nil {
+				body.Close()
+				return "", fmt.Errorf("writing the download: %w", err)
+			}
+			written = int64(n)
+		}
+
+		n, copyErr := io.Copy(f, io.LimitReader(body, limit-written+1))
+		body.Close()
+		written += n
+		if written > limit {
+			return "", fmt.Errorf("downloading %s: stopped at -max-download-mb %d", redactURL(rawURL), config.MaxDownloadMB)
+		}
+		if copyErr == nil && (total < 0 || written >= total) {
+			break
+		}
+		if runContext(config).Err() != nil {
+			return "", fmt.Errorf("downloading %s: %w", redactURL(rawURL), runContext(config).Err())
+		}
+		if attempt >= downloadAttempts {
+			if copyErr == nil {
+				copyErr = io.ErrUnexpectedEOF
+			}
+			return "", fmt.Errorf("downloading %s: got %d of %d bytes after %d attempts: %w", redactURL(rawURL), written, total, attempt, copyErr)
+		}
+		log.Printf("Download stopped after %d of %d bytes, resuming...\n", written, total)
+	}
+
+	err = f.Close()
+	f = nil
+	if err != nil {
+		return "", fmt.Errorf("writing the download: %w", err)
+	}
+	if written == 0 {
+		return "", fmt.Errorf("downloading %s: the response was empty", redactURL(rawURL))
+	}
+	if want, _ := parseChecksum(config.Checksum); want != "" {
+		got, err := fileSHA256(filePath)
+		if err != nil {
+			return "", err
+		}
+		if got != want {
+			return "", fmt.Errorf("downloading %s: SHA-256 is %s, but -checksum is %s", redactURL(rawURL), got, want)
+		}
+		log.Println("Download matches -checksum")
+	}
+	log.Printf("Downloaded %.1f MB of audio\n", float64(written)/(1024*1024))
+	return filePath, nil
+}
@@ -0,0 +1,166 @@
+package audio2org
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadAudio(t *testing.T) {
+	audio := bytes.Repeat([]byte("ID3\x04audio-frame-"), 4096)
+	sum := sha256.Sum256(audio)
+	digest := hex.EncodeToString(sum[:])
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/talk.mp3":
+			http.ServeContent(w, r, "talk.mp3", time.Time{}, bytes.NewReader(audio))
+		case "/flaky/talk.mp3":
+			// The first response drops the connection half way.
+			if r.Header.Get("Range") == "" {
+				w.Header().Set("Content-Length", strconv.Itoa(len(audio)))
+				w.Write(audio[:len(audio)/2])
+				return
+			}
+			http.ServeContent(w, r, "talk.mp3", time.Time{}, bytes.NewReader(audio))
+		case "/expired.mp3":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<!DOCTYPE html><html><body>Request has expired</body></html>"))
+		case "/missing.mp3":
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		checksum string
+		maxMB    int
+		requests int
+		wantErr  string
+	}{
+		{"download", "/talk.mp3", "", 2048, 1, ""},
+		{"checksum", "/talk.mp3", "sha256:" + strings.ToUpper(digest), 2048, 1, ""},
+		{"resumed", "/flaky/talk.mp3", digest, 2048, 2, ""},
+		{"checksum mismatch", "/talk.mp3", strings.Repeat("0", 64), 2048, 1, "-checksum"},
+		{"html page", "/expired.mp3", "", 2048, 1, "HTML page"},
+		{"not found", "/missing.mp3", "", 2048, 1, "404"},
+		{"too large", "/talk.mp3", "", 0, 1, "-max-download-mb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			config := validConfig()
+			config.AudioFilePath = server.URL + tt.path + "?X-Amz-Signature=secret"
+			config.Checksum = tt.checksum
+			config.MaxDownloadMB = tt.maxMB
+			path, err := downloadAudio(config)
+			if requests != tt.requests {
+				t.Errorf("made %d requests, want %d", requests, tt.requests)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("downloadAudio() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				if strings.Contains(err.Error(), "secret") {
+					t.Errorf("error %q shows the URL's query string", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer removeTempFile(path)
+			if filepath.Ext(path) != ".mp3" {
+				t.Errorf("downloaded to %s, want an .mp3 file", path)
+			}
+			if got, _ := os.ReadFile(path); !bytes.Equal(got, audio) {
+				t.Errorf("downloaded %d bytes that do not match the %d served", len(got), len(audio))
+			}
+		})
+	}
+}
+
+func TestCheckURLInput(t *testing.T) {
+	url := "https://bucket.s3.amazonaws.com/talk.mp3"
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"local file", Config{AudioFilePath: "talk.mp3"}, ""},
+		{"url", Config{AudioFilePath: url, MaxDownloadMB: 2048}, ""},
+		{"checksum without url", Config{AudioFilePath: "talk.mp3", Checksum: "abc"}, "-file URL"},
+		{"bad checksum", Config{AudioFilePath: url, MaxDownloadMB: 2048, Checksum: "sha256:abc"}, "64 hex digits"},
+		{"md5", Config{AudioFilePath: url, MaxDownloadMB: 2048, Checksum: "md5:abc"}, "only sha256"},
+		{"org transcript", Config{AudioFilePath: url, MaxDownloadMB: 2048, PostProcessCmd: "create_org_transcript"}, "create_org_transcript"},
+		{"resume", Config{AudioFilePath: url, MaxDownloadMB: 2048, Resume: "talk.resume.json"}, "-resume"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkURLInput(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkURLInput() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkURLInput() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestURLAudioExtension(t *testing.T) {
+	tests := []struct {
+		url         string
+		contentType string
+		want        string
+	}{
+		{"https://example.com/a/Talk.M4A?sig=1", "", ".m4a"},
+		{"https://example.com/recordings/42", "audio/mpeg", ".mp3"},
+		{"https://example.com/recordings/42", "audio/x-wav; charset=binary", ".wav"},
+		{"https://example.com/recordings/42", "application/octet-stream", ""},
+	}
+	for _, tt := range tests {
+		got, err := urlAudioExtension(tt.url, tt.contentType)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("urlAudioExtension(%q, %q) = %q, %v, want %q", tt.url, tt.contentType, got, err, tt.want)
+		}
+	}
+}
+
+func TestRunValidatesBeforeDownloading(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("ID3"))
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	config := validConfig()
+	config.TranscriptionFilePath = ""
+	config.AudioFiles = fileFlags{server.URL + "/talk.mp3"}
+	config.MaxDownloadMB = 2048
+	config.NoEnv = true
+	config.MaxTokens = 0
+	if err := run(config); err == nil || !strings.Contains(err.Error(), "-max-tokens must be positive") {
+		t.Errorf("run() = %v, want the -max-tokens problem", err)
+	}
+	if requests != 0 {
+		t.Errorf("the URL was requested %d times before the configuration was checked", requests)
+	}
+}
@@ -0,0 +1,244 @@
+package audio2org
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Transcription prices in US dollars per audio minute and chat prices in US
+// dollars per million tokens, from https://openai.com/api/pricing/. -dry-run
+// is only as accurate as these, so update them when the prices change.
+var transcribePricesPerMinute = map[string]float64{
+	"whisper-1":              0.006,
+	"gpt-4o-transcribe":      0.006,
+	"gpt-4o-mini-transcribe": 0.003,
+}
+
+var chatPrices = map[string]tokenPrice{
+	"gpt-4o":        {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4.1":       {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+	"gpt-4-turbo":   {Input: 10.00, Output: 30.00},
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+}
+
+type tokenPrice struct {
+	Input  float64
+	Output float64
+}
+
+// Without ffprobe the duration is guessed from the file size at this
+// bitrate, typical for spoken-word MP3 and M4A recordings.
+const dryRunBitRate = 128_000
+
+// Speech runs at roughly 150 words a minute, about 200 tokens.
+const speechTokensPerMinute = 200
+
+// Instructions and formatting around the transcript in each chat prompt.
+const promptOverheadTokens = 400
+
+// postOutputTokens is the max_tokens each post-processing command asks
+// for, used as the upper bound on its output. create_emacs_org_notes and
+// create_markdown_notes use -max-tokens instead.
+var postOutputTokens = map[string]int{
+	"create_glossary":     3000,
+	"create_json_summary": 3000,
+	"create_topic_org":    8000,
+	"create_chapters":     2000,
+	"create_flashcards":   4000,
+}
+
+type costEstimate struct {
+	Input          string
+	Duration       time.Duration
+	DurationGuess  bool
+	TranscribeCost float64
+	Calls          []chatCallEstimate
+}
+
+type chatCallEstimate struct {
+	Purpose      string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+	Priced       bool
+}
+
+func (e costEstimate) Total() float64 {
+	total := e.TranscribeCost
+	for _, call := range e.Calls {
+		total += call.Cost
+	}
+	return total
+}
+
+// printCostEstimates prints the projected cost of running each
+// configuration, plus a total for batches, without calling any API.
+func printCostEstimates(configs []Config) error {
+	var total float64
+	for i, config := range configs {
+		estimate, err := estimateCost(config)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		printCostEstimate(estimate, config)
+		total += estimate.Total()
+	}
+	if len(configs) > 1 {
+		fmt.Printf("\nBatch total:     $%.2f for %d files\n", total, len(configs))
+	}
+	fmt.Println("Estimates are approximate: chat output is counted at its token limit, and for audio the transcript length is guessed from the duration.")
+	return nil
+}
+
+func estimateCost(config Config) (costEstimate, error) {
+	var estimate costEstimate
+	var transcriptTokens int
+
+	if config.AudioFilePath != "" {
+		estimate.Input = config.AudioFilePath
+		duration, guessed, err := estimateDuration(config.AudioFilePath)
+		if err != nil {
+			return estimate, err
+		}
+		estimate.Duration, estimate.DurationGuess = duration, guessed
+		if config.Backend != "local" {
+			estimate.TranscribeCost = duration.Minutes() * transcribePricesPerMinute[config.TranscribeModel]
+		}
+		transcriptTokens = int(duration.Minutes() * speechTokensPerMinute)
+	} else {
+		estimate.Input = config.TranscriptionFilePath
+		text, err := readExistingTranscription(config.TranscriptionFilePath)
+		if err != nil {
+			return estimate, err
+		}
+		transcriptTokens = estimateTokens(text)
+	}
+
+	postTokens := transcriptTokens
+	if config.MaxTranscriptChars > 0 {
+		postTokens = min(postTokens, tokensForChars(config.MaxTranscriptChars))
+	}
+	estimate.Calls = estimateChatCalls(config, postTokens)
+	return estimate, nil
+}
+
+// estimateChatCalls lists the chat requests the run would make for a
+// transcript of about this many tokens.
+func estimateChatCalls(config Config, transcriptTokens int) []chatCallEstimate {
+	var calls []chatCallEstimate
+	add := func(purpose, model string, inputTokens, outputTokens int) {
+		call := chatCallEstimate{Purpose: purpose, Model: model, InputTokens: inputTokens + promptOverheadTokens, OutputTokens: outputTokens}
+		if price, ok := chatPrices[model]; ok {
+			call.Priced = true
+			call.Cost = (float64(call.InputTokens)*price.Input + float64(call.OutputTokens)*price.Output) / 1e6
+		}
+		calls = append(calls, call)
+	}
+
+	if config.TitleFromContent && config.OutputFileName == "" {
+		add("title", config.SummaryModel, min(transcriptTokens, tokensForChars(8000)), 30)
+	}
+
+	for _, step := range postSteps(config) {
+		// With -summary-chunk-tokens, the notes are written from the part
+		// summaries, each counted at its token limit.
+		notesTokens := transcriptTokens
+		switch step {
+		case "create_emacs_org_notes", "create_markdown_notes":
+			if config.SummaryChunkTokens > 0 && transcriptTokens > config.SummaryChunkTokens {
+				parts := (transcriptTokens + config.SummaryChunkTokens - 1) / config.SummaryChunkTokens
+				for i := 1; i <= parts; i++ {
+					partTokens := min(config.SummaryChunkTokens, transcriptTokens-(i-1)*config.SummaryChunkTokens)
+					add(fmt.Sprintf("summary part %d of %d", i, parts), config.SummaryModel, partTokens, config.MaxTokens)
+				}
+				notesTokens = parts * config.MaxTokens
+			}
+		}
+
+		switch step {
+		case "create_org_transcript":
+		case "create_emacs_org_notes":
+			languages := summaryLanguages(config)
+			if len(languages) == 0 {
+				languages = []string{""}
+			}
+			for _, language := range languages {
+				purpose := step
+				if language != "" {
+					purpose += " (" + language + ")"
+				}
+				add(purpose, config.SummaryModel, notesTokens, config.MaxTokens)
+			}
+		case "create_markdown_notes":
+			add(step, config.SummaryModel, notesTokens, config.MaxTokens)
+		default:
+			add(step, config.SummaryModel, transcriptTokens, postOutputTokens[step])
+		}
+	}
+
+	if config.InlineSummary {
+		add("inline summary", config.SummaryModel, transcriptTokens, 3000)
+	}
+	return calls
+}
+
+// estimateDuration returns the audio duration from ffprobe, or a guess
+// from the file size when ffprobe is not installed or cannot read it.
+func estimateDuration(audioFilePath string) (time.Duration, bool, error) {
+	info, err := os.Stat(audioFilePath)
+	if err != nil {
+		return 0, false, fmt.Errorf("reading audio file: %w", err)
+	}
+	if requireFFprobe("-dry-run") == nil {
+		if duration, err := probeDuration(audioFilePath); err == nil {
+			return duration, false, nil
+		}
+	}
+	seconds := float64(info.Size()) * 8 / dryRunBitRate
+	return time.Duration(seconds * float64(time.Second)), true, nil
+}
+
+func printCostEstimate(estimate costEstimate, config Config) {
+	fmt.Printf("Input:           %s\n", estimate.Input)
+	if estimate.Duration > 0 || estimate.DurationGuess {
+		source := "ffprobe"
+		if estimate.DurationGuess {
+			source = fmt.Sprintf("guessed from the file size at %d kb/s; install ffprobe for the real duration", dryRunBitRate/1000)
+		}
+		fmt.Printf("Duration:        %s (%s)\n", formatTimestamp(estimate.Duration.Seconds()), source)
+		if config.Backend == "local" {
+			fmt.Printf("Transcription:   $0.00 (%.1f min with whisper.cpp on this machine)\n", estimate.Duration.Minutes())
+		} else {
+			fmt.Printf("Transcription:   $%.2f (%.1f min with %s at $%.4f/min)\n",
+				estimate.TranscribeCost, estimate.Duration.Minutes(), config.TranscribeModel, transcribePricesPerMinute[config.TranscribeModel])
+		}
+	}
+	for _, call := range estimate.Calls {
+		if !call.Priced {
+			fmt.Printf("Chat:            unknown (%s with %s, which is not in the pricing table; known models: %s)\n",
+				call.Purpose, call.Model, strings.Join(pricedChatModels(), ", "))
+			continue
+		}
+		fmt.Printf("Chat:            $%.2f (%s with %s: ~%d input tokens, up to %d output tokens)\n",
+			call.Cost, call.Purpose, call.Model, call.InputTokens, call.OutputTokens)
+	}
+	fmt.Printf("Total:           $%.2f\n", estimate.Total())
+}
+
+func pricedChatModels() []string {
+	models := make([]string, 0, len(chatPrices))
+	for model := range chatPrices {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
@@ -0,0 +1,84 @@
+package audio2org
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEstimateChatCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		wantCost float64
+		purposes []string
+	}{
+		{"no post-processing", Config{}, 0, nil},
+		{"org transcript makes no chat call", Config{PostProcessCmd: "create_org_transcript"}, 0, nil},
+		{
+			name:     "notes in two languages",
+			config:   Config{PostProcessCmd: "create_emacs_org_notes", SummaryModel: "gpt-4o", MaxTokens: 3000, SummaryLanguages: "en,de"},
+			wantCost: 2 * (1400*2.50 + 3000*10.00) / 1e6,
+			purposes: []string{"create_emacs_org_notes (en)", "create_emacs_org_notes (de)"},
+		},
+		{
+			name:     "markdown notes from part summaries",
+			config:   Config{PostProcessCmd: "create_markdown_notes", SummaryModel: "gpt-4o", MaxTokens: 3000, SummaryChunkTokens: 400},
+			wantCost: ((800+800+600)*2.50 + 3*3000*10.00 + (3*3000+400)*2.50 + 3000*10.00) / 1e6,
+			purposes: []string{"summary part 1 of 3", "summary part 2 of 3", "summary part 3 of 3", "create_markdown_notes"},
+		},
+		{
+			name:     "glossary and inline summary",
+			config:   Config{PostProcessCmd: "create_glossary", InlineSummary: true, SummaryModel: "gpt-4o"},
+			wantCost: 2 * (1400*2.50 + 3000*10.00) / 1e6,
+			purposes: []string{"create_glossary", "inline summary"},
+		},
+		{
+			name:     "title and topics follow -summary-model",
+			config:   Config{PostProcessCmd: "create_topic_org", TitleFromContent: true, SummaryModel: "gpt-4o-mini"},
+			wantCost: ((1400*0.15 + 30*0.60) + (1400*0.15 + float64(postOutputTokens["create_topic_org"])*0.60)) / 1e6,
+			purposes: []string{"title", "create_topic_org"},
+		},
+		{
+			name:     "unpriced model",
+			config:   Config{PostProcessCmd: "create_emacs_org_notes", SummaryModel: "llama3", MaxTokens: 3000},
+			purposes: []string{"create_emacs_org_notes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate := costEstimate{Calls: estimateChatCalls(tt.config, 1000)}
+			var purposes []string
+			for _, call := range estimate.Calls {
+				purposes = append(purposes, call.Purpose)
+			}
+			if strings.Join(purposes, "|") != strings.Join(tt.purposes, "|") {
+				t.Errorf("calls = %q, want %q", purposes, tt.purposes)
+			}
+			if got := estimate.Total(); math.Abs(got-tt.wantCost) > 1e-9 {
+				t.Errorf("Total() = %f, want %f", got, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestEstimateCostFromTranscription(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "talk.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("word ", 800)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	estimate, err := estimateCost(Config{TranscriptionFilePath: path, PostProcessCmd: "create_chapters", MaxTranscriptChars: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.TranscribeCost != 0 || len(estimate.Calls) != 1 {
+		t.Fatalf("estimate = %+v, want a single chat call and no transcription", estimate)
+	}
+	if got := estimate.Calls[0].InputTokens; got != 500+promptOverheadTokens {
+		t.Errorf("InputTokens = %d, want the -max-transcript-chars limit plus the prompt", got)
+	}
+}
@@ -0,0 +1,33 @@
+package audio2org
+
+import "errors"
+
+// errDuplicate is recorded for batch inputs with the same audio as another
+// input, which are not transcribed again.
+var errDuplicate = errors.New("same audio as another input")
+
+// findDuplicates maps each of files whose content is the same as an
+// earlier input's, or as an input the -manifest lists as done, to that
+// input. Inputs that cannot be read are left for processing to report.
+func findDuplicates(files []string, manifest *batchManifest) map[string]string {
+	duplicates := map[string]string{}
+	firsts := map[string]string{}
+	for _, file := range files {
+		hash, ok := manifest.hash(file)
+		if !ok {
+			var err error
+			if hash, err = fileSHA256(file); err != nil {
+				continue
+			}
+		}
+		if first, ok := firsts[hash]; ok {
+			duplicates[file] = first
+			continue
+		}
+		firsts[hash] = file
+		if done, ok := manifest.doneWithHash(hash, file); ok {
+			duplicates[file] = done
+		}
+	}
+	return duplicates
+}
@@ -0,0 +1,50 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	monday := write("monday.mp3", "first recording")
+	copied := write("monday-copy.mp3", "first recording")
+	tuesday := write("tuesday.mp3", "second recording")
+	renamed := write("tuesday-renamed.mp3", "second recording")
+	missing := filepath.Join(dir, "missing.mp3")
+
+	files := []string{monday, tuesday, copied, missing, renamed}
+	want := map[string]string{copied: monday, renamed: tuesday}
+	if got := findDuplicates(files, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("findDuplicates() = %v, want %v", got, want)
+	}
+
+	// An earlier run finished tuesday.mp3 under its old name.
+	manifestPath := filepath.Join(dir, "manifest.json")
+	hash, err := fileSHA256(tuesday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal([]manifestEntry{{Path: filepath.Join(dir, "old-name.mp3"), SHA256: hash, Status: manifestDone}})
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := loadManifest(manifestPath, []string{monday, renamed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{renamed: filepath.Join(dir, "old-name.mp3")}
+	if got := findDuplicates([]string{monday, renamed}, manifest); !reflect.DeepEqual(got, want) {
+		t.Errorf("findDuplicates() with -manifest = %v, want %v", got, want)
+	}
+}
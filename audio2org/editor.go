@@ -0,0 +1,103 @@
+package audio2org
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// fallbackEditors are tried in order when $EDITOR is unset.
+var fallbackEditors = []string{"vi", "nano"}
+
+// editorCommand returns $EDITOR split into its arguments, or the first
+// fallback editor found on the PATH, or nil.
+func editorCommand(env string, lookPath func(string) (string, error)) []string {
+	if editor := strings.Fields(env); len(editor) > 0 {
+		return editor
+	}
+	for _, name := range fallbackEditors {
+		if _, err := lookPath(name); err == nil {
+			return []string{name}
+		}
+	}
+	return nil
+}
+
+func editTranscript(transcriptPath, transcriptionText string) (string, error) {
+	editor := editorCommand(os.Getenv("EDITOR"), exec.LookPath)
+	if len(editor) == 0 {
+		log.Printf("Skipping -edit: $EDITOR is not set and neither %s is installed\n", strings.Join(fallbackEditors, " nor "))
+		return transcriptionText, nil
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		log.Println("Skipping -edit: not running in a terminal")
+		return transcriptionText, nil
+	}
+
+	before, err := os.ReadFile(transcriptPath)
+	if err != nil {
+		return "", fmt.Errorf("reading transcription file: %w", err)
+	}
+
+	log.Printf("Opening %s in %s...\n", transcriptPath, editor[0])
+	cmd := exec.Command(editor[0], append(editor[1:], transcriptPath)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running editor: %w", err)
+	}
+
+	after, err := os.ReadFile(transcriptPath)
+	if err != nil {
+		return "", fmt.Errorf("reading transcription file: %w", err)
+	}
+	if bytes.Equal(before, after) {
+		log.Println("Transcript left unchanged in the editor; continuing with it")
+	} else {
+		log.Println("Using the edited transcript")
+	}
+	return stripMetadataHeader(string(after)), nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// openOutput opens path with -open-with, or the platform's default app
+// for the file. Failures are logged and never fail the run.
+func openOutput(config Config, path string) {
+	if config.QuietSuccess || !isTerminal(os.Stdout) {
+		log.Println("Skipping -open: not running in a terminal")
+		return
+	}
+
+	var opener []string
+	switch {
+	case config.OpenWith != "":
+		opener = strings.Fields(config.OpenWith)
+	case runtime.GOOS == "darwin":
+		opener = []string{"open"}
+	case runtime.GOOS == "windows":
+		opener = []string{"cmd", "/c", "start", ""}
+	default:
+		opener = []string{"xdg-open"}
+	}
+
+	log.Printf("Opening %s with %s...\n", path, opener[0])
+	cmd := exec.Command(opener[0], append(opener[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("Error opening %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,36 @@
+package audio2org
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		installed []string
+		want      []string
+	}{
+		{"editor with arguments", "code --wait", nil, []string{"code", "--wait"}},
+		{"falls back to vi", "", []string{"vi", "nano"}, []string{"vi"}},
+		{"falls back to nano", "  ", []string{"nano"}, []string{"nano"}},
+		{"nothing installed", "", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath := func(name string) (string, error) {
+				for _, installed := range tt.installed {
+					if name == installed {
+						return "/usr/bin/" + name, nil
+					}
+				}
+				return "", errors.New("not found")
+			}
+			if got := editorCommand(tt.env, lookPath); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("editorCommand(%q) = %q, want %q", tt.env, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// transcriptTooShort reports whether text, ignoring surrounding
+// whitespace, is shorter than -min-transcript-chars, as Whisper returns for
+// muted or silent recordings.
+func transcriptTooShort(config Config, text string) bool {
+	return utf8.RuneCountInString(strings.TrimSpace(text)) < config.MinTranscriptChars
+}
+
+// checkTranscriptLength reports whether the post-processing should be
+// skipped because the transcript is empty or too short, logging why, or
+// fails the run instead with -fail-on-empty.
+func checkTranscriptLength(config Config, text string) (skip bool, err error) {
+	if !transcriptTooShort(config, text) {
+		return false, nil
+	}
+	problem := "the transcript is empty"
+	if n := utf8.RuneCountInString(strings.TrimSpace(text)); n > 0 {
+		problem = fmt.Sprintf("the transcript is only %d characters, under -min-transcript-chars %d", n, config.MinTranscriptChars)
+	}
+	if config.AudioFilePath != "" {
+		problem += fmt.Sprintf("; check that %s is not muted or silent", inputSource(config))
+	}
+	if config.FailOnEmpty {
+		return true, fmt.Errorf("%s (-fail-on-empty)", problem)
+	}
+	log.Printf("Warning: %s. Skipping post-processing\n", problem)
+	return true, nil
+}
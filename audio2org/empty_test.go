@@ -0,0 +1,41 @@
+package audio2org
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCheckTranscriptLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		text     string
+		wantSkip bool
+		wantErr  string
+		wantLog  string
+	}{
+		{"text", Config{MinTranscriptChars: 1}, "Hello there.", false, "", ""},
+		{"empty", Config{MinTranscriptChars: 1, AudioFilePath: "talk.mp3"}, "", true, "", "the transcript is empty; check that talk.mp3 is not muted or silent. Skipping post-processing"},
+		{"whitespace", Config{MinTranscriptChars: 1}, " \n\t", true, "", "the transcript is empty. Skipping"},
+		{"short", Config{MinTranscriptChars: 10}, " Uh. ", true, "", "only 3 characters, under -min-transcript-chars 10"},
+		{"disabled", Config{MinTranscriptChars: 0}, "", false, "", ""},
+		{"fail", Config{MinTranscriptChars: 1, FailOnEmpty: true, AudioFilePath: "talk.mp3"}, "", true, "the transcript is empty; check that talk.mp3 is not muted or silent (-fail-on-empty)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			captureLog(t, &buf)
+			skip, err := checkTranscriptLength(tt.config, tt.text)
+			if skip != tt.wantSkip {
+				t.Errorf("skip = %v, want %v", skip, tt.wantSkip)
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+			if !strings.Contains(buf.String(), tt.wantLog) || tt.wantLog == "" && buf.Len() > 0 {
+				t.Errorf("log = %q, want %q", buf.String(), tt.wantLog)
+			}
+		})
+	}
+}
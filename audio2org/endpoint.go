@@ -0,0 +1,138 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const defaultBaseURL = "https://api.openai.com/v1"
+
+// openAIKeyPattern is what OpenAI keys have looked like so far. Key formats
+// change, so a key that does not match is only warned about.
+var openAIKeyPattern = regexp.MustCompile(`^sk-[A-Za-z0-9_-]+$`)
+
+// apiURL joins an endpoint path such as /chat/completions onto -base-url.
+// A query string on the base URL, such as Azure's api-version, is kept,
+// and {model} in its path is replaced by the request's model so a single
+// base URL can reach Azure deployments named after the models.
+func apiURL(config Config, path, model string) string {
+	u, err := url.Parse(config.BaseURL)
+	if err != nil {
+		// checkBaseURL has already rejected anything that does not parse.
+		return config.BaseURL + path
+	}
+	u.Path = strings.ReplaceAll(strings.TrimSuffix(u.Path, "/"), "{model}", model) + path
+	u.RawPath = ""
+	return u.String()
+}
+
+func checkBaseURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid -base-url %q: %w", value, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid -base-url %q: expected an http or https URL such as %s", value, defaultBaseURL)
+	}
+	return nil
+}
+
+// authHeader returns the header that carries the API key: a bearer token
+// in Authorization, or Azure's api-key header.
+func authHeader(config Config, key string) (string, string) {
+	if config.AuthHeader == "api-key" {
+		return "api-key", key
+	}
+	return "Authorization", "Bearer " + key
+}
+
+// apiHeaders returns the headers every API request carries: the API key,
+// and the OpenAI-Organization and OpenAI-Project that its usage is billed
+// to when -org-id and -project-id are given.
+func apiHeaders(config Config, key string) map[string]string {
+	name, value := authHeader(config, key)
+	headers := map[string]string{name: value}
+	if config.OrgID != "" {
+		headers["OpenAI-Organization"] = config.OrgID
+	}
+	if config.ProjectID != "" {
+		headers["OpenAI-Project"] = config.ProjectID
+	}
+	return headers
+}
+
+// apiKey reads the key from -api-key-file, the OPENAI_API_KEY_FILE file,
+// or OPENAI_API_KEY, in that order, without the surrounding whitespace or
+// quotes that copying a key out of a secret store tends to add. Reading it
+// from a file keeps it out of the environment, where ps and child
+// processes can see it.
+func apiKey(config Config) (string, error) {
+	path, name := config.APIKeyFile, "-api-key-file"
+	if path == "" {
+		path, name = os.Getenv("OPENAI_API_KEY_FILE"), "OPENAI_API_KEY_FILE"
+	}
+	if path == "" {
+		return trimAPIKey(os.Getenv("OPENAI_API_KEY")), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading the API key from %s: %w", name, err)
+	}
+	key := trimAPIKey(string(data))
+	if key == "" {
+		return "", fmt.Errorf("%s %s is empty", name, path)
+	}
+	return key, nil
+}
+
+// apiKeySource describes where apiKey reads the key from, naming the .env
+// file when that is where the variable came from.
+func apiKeySource(config Config) string {
+	if config.APIKeyFile != "" {
+		return "-api-key-file " + config.APIKeyFile
+	}
+	name, source := "OPENAI_API_KEY", "OPENAI_API_KEY"
+	if path := os.Getenv("OPENAI_API_KEY_FILE"); path != "" {
+		name, source = "OPENAI_API_KEY_FILE", "OPENAI_API_KEY_FILE "+path
+	}
+	if file, ok := dotenvVars[name]; ok {
+		return source + " from " + file
+	}
+	return source + " from the environment"
+}
+
+func trimAPIKey(key string) string {
+	key = strings.TrimSpace(key)
+	for _, quote := range []string{`"`, "'"} {
+		if len(key) >= 2 && strings.HasPrefix(key, quote) && strings.HasSuffix(key, quote) {
+			key = strings.TrimSpace(key[1 : len(key)-1])
+		}
+	}
+	return key
+}
+
+// warnAPIKeyFormat logs a warning when a key sent to OpenAI itself does not
+// look like an OpenAI key, since the API only answers with a bare 401.
+// Gateways and Azure use keys of their own, so they are not checked.
+func warnAPIKeyFormat(config Config, key string) {
+	if config.AuthHeader != "bearer" || config.BaseURL != defaultBaseURL {
+		return
+	}
+	if !openAIKeyPattern.MatchString(key) {
+		log.Printf("Warning: OPENAI_API_KEY looks malformed: expected sk- followed by letters, digits, - and _, got %d characters starting with %q\n",
+			len(key), keyPrefix(key))
+	}
+}
+
+// keyPrefix returns just enough of the key to recognize it in a warning.
+func keyPrefix(key string) string {
+	if len(key) > 3 {
+		return key[:3]
+	}
+	return key
+}
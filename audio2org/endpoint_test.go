@@ -0,0 +1,239 @@
+package audio2org
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAPIURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+	}{
+		{"default", defaultBaseURL, "https://api.openai.com/v1/chat/completions"},
+		{"gateway with trailing slash", "https://gateway.internal/openai/v1/", "https://gateway.internal/openai/v1/chat/completions"},
+		{
+			name:    "azure deployment named after the model",
+			baseURL: "https://team.openai.azure.com/openai/deployments/{model}?api-version=2024-06-01",
+			want:    "https://team.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-06-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiURL(Config{BaseURL: tt.baseURL}, "/chat/completions", "gpt-4o"); got != tt.want {
+				t.Errorf("apiURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBaseURL(t *testing.T) {
+	for _, value := range []string{defaultBaseURL, "http://localhost:8080/v1"} {
+		if err := checkBaseURL(value); err != nil {
+			t.Errorf("checkBaseURL(%q) = %v, want nil", value, err)
+		}
+	}
+	for _, value := range []string{"api.openai.com/v1", "ftp://example.com", "https://"} {
+		if err := checkBaseURL(value); err == nil {
+			t.Errorf("checkBaseURL(%q) accepted an invalid URL", value)
+		}
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"sk-abc123", "sk-abc123"},
+		{"sk-abc123\n", "sk-abc123"},
+		{"  sk-abc123\r\n", "sk-abc123"},
+		{`"sk-abc123"`, "sk-abc123"},
+		{"'sk-abc123'\n", "sk-abc123"},
+		{`"sk-abc123`, `"sk-abc123`},
+		{"\n", ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("OPENAI_API_KEY", tt.value)
+		if got, err := apiKey(Config{}); err != nil || got != tt.want {
+			t.Errorf("apiKey() with OPENAI_API_KEY=%q = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	flagFile := write("flag", "sk-from-flag\n")
+	envFile := write("env", "sk-from-env-file \r\n")
+	empty := write("empty", "\n")
+
+	tests := []struct {
+		name    string
+		flag    string
+		envFile string
+		want    string
+		wantErr string
+	}{
+		{"flag file wins", flagFile, envFile, "sk-from-flag", ""},
+		{"env file over env value", "", envFile, "sk-from-env-file", ""},
+		{"env value", "", "", "sk-from-env", ""},
+		{"empty file", empty, "", "", "-api-key-file " + empty + " is empty"},
+		{"missing env file", "", filepath.Join(dir, "missing"), "", "OPENAI_API_KEY_FILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OPENAI_API_KEY", "sk-from-env")
+			t.Setenv("OPENAI_API_KEY_FILE", tt.envFile)
+			got, err := apiKey(Config{APIKeyFile: tt.flag})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("apiKey() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("apiKey() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeySource(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "audio2org.env")
+	if err := os.WriteFile(envFile, []byte("OPENAI_API_KEY=sk-from-dotenv\nAUDIO2ORG_TEST_SOURCE=dotenv\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dotenvVars = map[string]string{} })
+
+	t.Setenv("OPENAI_API_KEY", "sk-from-env")
+	t.Setenv("OPENAI_API_KEY_FILE", "")
+	t.Setenv("AUDIO2ORG_TEST_SOURCE", "")
+	os.Unsetenv("AUDIO2ORG_TEST_SOURCE")
+	if err := loadEnv(envFile); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("OPENAI_API_KEY"); got != "sk-from-env" {
+		t.Errorf("OPENAI_API_KEY = %q, want the environment to win over the file", got)
+	}
+	if got := os.Getenv("AUDIO2ORG_TEST_SOURCE"); got != "dotenv" {
+		t.Errorf("AUDIO2ORG_TEST_SOURCE = %q, want it filled in from the file", got)
+	}
+	if got, want := apiKeySource(Config{}), "OPENAI_API_KEY from the environment"; got != want {
+		t.Errorf("apiKeySource() = %q, want %q", got, want)
+	}
+
+	os.Unsetenv("OPENAI_API_KEY")
+	if err := loadEnv(envFile); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := apiKeySource(Config{}), "OPENAI_API_KEY from "+envFile; got != want {
+		t.Errorf("apiKeySource() = %q, want %q", got, want)
+	}
+	if got, want := apiKeySource(Config{APIKeyFile: "key.txt"}), "-api-key-file key.txt"; got != want {
+		t.Errorf("apiKeySource() = %q, want %q", got, want)
+	}
+
+	if err := loadEnv(filepath.Join(dir, "missing.env")); err == nil || !strings.Contains(err.Error(), "-env-file") {
+		t.Errorf("loadEnv() of a missing -env-file = %v, want an error", err)
+	}
+}
+
+func TestAPIHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   map[string]string
+	}{
+		{"key only", Config{AuthHeader: "bearer"}, map[string]string{"Authorization": "Bearer sk-test"}},
+		{"azure", Config{AuthHeader: "api-key"}, map[string]string{"api-key": "sk-test"}},
+		{
+			"organization and project",
+			Config{AuthHeader: "bearer", OrgID: "org-123", ProjectID: "proj_456"},
+			map[string]string{"Authorization": "Bearer sk-test", "OpenAI-Organization": "org-123", "OpenAI-Project": "proj_456"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiHeaders(tt.config, "sk-test"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apiHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnAPIKeyFormat(t *testing.T) {
+	openAI := Config{AuthHeader: "bearer", BaseURL: defaultBaseURL}
+	gateway := Config{AuthHeader: "bearer", BaseURL: "https://gateway.internal/openai/v1"}
+	azure := Config{AuthHeader: "api-key", BaseURL: defaultBaseURL}
+	tests := []struct {
+		name   string
+		config Config
+		key    string
+		warn   bool
+	}{
+		{"openai key", openAI, "sk-proj-Ab_12-cd", false},
+		{"missing prefix", openAI, "abc123", true},
+		{"pasted with the scheme", openAI, "Bearer sk-abc123", true},
+		{"gateway key", gateway, "gw-abc123", false},
+		{"azure key", azure, "0123456789abcdef", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			captureLog(t, &logs)
+			warnAPIKeyFormat(tt.config, tt.key)
+			if got := strings.Contains(logs.String(), "looks malformed"); got != tt.warn {
+				t.Errorf("warnAPIKeyFormat(%q) warned = %v, want %v; log: %q", tt.key, got, tt.warn, logs.String())
+			}
+			if tt.warn && strings.Contains(logs.String(), tt.key) {
+				t.Errorf("warning %q repeats the whole key", logs.String())
+			}
+		})
+	}
+}
+
+func TestChatRequestToAzure(t *testing.T) {
+	var path, query, apiKey, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.RawQuery
+		apiKey, authorization = r.Header.Get("api-key"), r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "* Notes"}}]}`))
+	}))
+	defer server.Close()
+
+	config := Config{
+		OpenAIAPIKey: "azure-key",
+		BaseURL:      server.URL + "/openai/deployments/{model}?api-version=2024-06-01",
+		AuthHeader:   "api-key",
+	}
+	content, err := sendChatRequest(config, map[string]interface{}{"model": "gpt-4o"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "* Notes" {
+		t.Errorf("content = %q, want %q", content, "* Notes")
+	}
+	if path != "/openai/deployments/gpt-4o/chat/completions" || query != "api-version=2024-06-01" {
+		t.Errorf("request went to %s?%s", path, query)
+	}
+	if apiKey != "azure-key" || authorization != "" {
+		t.Errorf("api-key = %q, Authorization = %q, want only the api-key header", apiKey, authorization)
+	}
+}
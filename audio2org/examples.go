@@ -0,0 +1,69 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const maxExampleTokens = 6000
+
+// loadExampleMessages reads <name>.txt / <name><extension> pairs from
+// -examples-dir, in name order, as alternating user/assistant messages for
+// few-shot prompting. Pairs that would push the examples past
+// maxExampleTokens are skipped.
+func loadExampleMessages(config Config, extension string, examplePrompt func(string) (string, error)) ([]map[string]string, error) {
+	dir := config.ExamplesDir
+	transcripts, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("listing examples: %w", err)
+	}
+	sort.Strings(transcripts)
+
+	var messages []map[string]string
+	budget := maxExampleTokens
+	for _, transcriptPath := range transcripts {
+		notesPath := strings.TrimSuffix(transcriptPath, ".txt") + extension
+		notesBytes, err := os.ReadFile(notesPath)
+		if err != nil {
+			log.Printf("Skipping example %s: no matching %s\n", filepath.Base(transcriptPath), filepath.Base(notesPath))
+			continue
+		}
+		transcriptBytes, err := os.ReadFile(transcriptPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading example: %w", err)
+		}
+
+		prompt, err := examplePrompt(string(transcriptBytes))
+		if err != nil {
+			return nil, err
+		}
+		cost := estimateTokens(prompt) + estimateTokens(string(notesBytes))
+		if cost > budget {
+			log.Printf("Skipping example %s: it would exceed the %d token example budget\n", filepath.Base(transcriptPath), maxExampleTokens)
+			continue
+		}
+		budget -= cost
+
+		messages = append(messages,
+			map[string]string{"role": "user", "content": prompt},
+			map[string]string{"role": "assistant", "content": string(notesBytes)},
+		)
+	}
+
+	log.Printf("Loaded %d few-shot examples from %s\n", len(messages)/2, dir)
+	return messages, nil
+}
+
+// estimateTokens approximates the token count at roughly four characters
+// per token, which is close enough for budgeting English text.
+func estimateTokens(text string) int {
+	return tokensForChars(len(text))
+}
+
+func tokensForChars(n int) int {
+	return (n + 3) / 4
+}
@@ -0,0 +1,84 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadExampleMessages(t *testing.T) {
+	large := strings.Repeat("word ", maxExampleTokens)
+	tests := []struct {
+		name    string
+		files   map[string]string
+		dirs    []string
+		want    []map[string]string
+		wantErr string
+	}{
+		{
+			name:  "pairs in name order",
+			files: map[string]string{"b.txt": "second", "b.org": "* Second", "a.txt": "first", "a.org": "* First"},
+			want: []map[string]string{
+				{"role": "user", "content": "Transcript: first"},
+				{"role": "assistant", "content": "* First"},
+				{"role": "user", "content": "Transcript: second"},
+				{"role": "assistant", "content": "* Second"},
+			},
+		},
+		{
+			name:  "example over the token budget",
+			files: map[string]string{"a.txt": large, "a.org": "* Large", "b.txt": "small", "b.org": "* Small"},
+			want: []map[string]string{
+				{"role": "user", "content": "Transcript: small"},
+				{"role": "assistant", "content": "* Small"},
+			},
+		},
+		{
+			name:  "missing notes file",
+			files: map[string]string{"a.txt": "first", "b.txt": "second", "b.org": "* Second"},
+			want: []map[string]string{
+				{"role": "user", "content": "Transcript: second"},
+				{"role": "assistant", "content": "* Second"},
+			},
+		},
+		{
+			name:    "unreadable transcript",
+			files:   map[string]string{"a.org": "* First"},
+			dirs:    []string{"a.txt"},
+			wantErr: "reading example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range tt.dirs {
+				if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			prompt := func(transcript string) (string, error) { return "Transcript: " + transcript, nil }
+			got, err := loadExampleMessages(Config{ExamplesDir: dir}, ".org", prompt)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadExampleMessages() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadExampleMessages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package audio2org
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// execTemplateData holds the values an -exec argument can use.
+type execTemplateData struct {
+	OutputPath     string // the main output, such as the org notes, or the transcript without -post
+	TranscriptPath string
+}
+
+// execArgs splits the -exec command into arguments on whitespace and then
+// renders each one as a template, so a substituted path stays a single
+// argument whatever characters it contains. No shell is involved.
+func execArgs(command string, data execTemplateData) ([]string, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("-exec is empty")
+	}
+	args := make([]string, len(fields))
+	for i, field := range fields {
+		tmpl, err := template.New("exec").Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("parsing -exec: %w", err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("rendering -exec: %w", err)
+		}
+		args[i] = b.String()
+	}
+	return args, nil
+}
+
+func checkExec(config Config) error {
+	if config.Exec == "" {
+		return nil
+	}
+	if config.NoOutput || config.OutputURI != "" || config.Stdout {
+		return errors.New("-exec runs on the written output file, so it cannot be combined with -no-output, -output-uri, or -stdout")
+	}
+	_, err := execArgs(config.Exec, execTemplateData{OutputPath: "notes.org", TranscriptPath: "talk.txt"})
+	return err
+}
+
+// runExec runs the -exec command once the outputs are written, logs what
+// it printed, and fails the run if it exits non-zero.
+func runExec(config Config, data execTemplateData) error {
+	args, err := execArgs(config.Exec, data)
+	if err != nil {
+		return err
+	}
+	log.Printf("Running -exec command: %s\n", strings.Join(args, " "))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runContext(config), args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	logCommandOutput("stdout", stdout.String())
+	logCommandOutput("stderr", stderr.String())
+	if err != nil {
+		return fmt.Errorf("running -exec command: %w", err)
+	}
+	return nil
+}
+
+func logCommandOutput(stream, output string) {
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line != "" {
+			log.Printf("-exec %s: %s\n", stream, line)
+		}
+	}
+}
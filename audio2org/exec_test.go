@@ -0,0 +1,61 @@
+package audio2org
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecArgs(t *testing.T) {
+	data := execTemplateData{OutputPath: "output/my talk; rm -rf ~.org", TranscriptPath: "output/my talk.txt"}
+	tests := []struct {
+		command string
+		want    []string
+		wantErr string
+	}{
+		{"git add {{.OutputPath}}", []string{"git", "add", "output/my talk; rm -rf ~.org"}, ""},
+		{"cp {{.TranscriptPath}} --target=/srv/notes", []string{"cp", "output/my talk.txt", "--target=/srv/notes"}, ""},
+		{"  ", nil, "-exec is empty"},
+		{"push {{.OutputPath", nil, "parsing -exec"},
+		{"push {{.Output}}", nil, "rendering -exec"},
+	}
+	for _, tt := range tests {
+		got, err := execArgs(tt.command, data)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("execArgs(%q) error = %v, want it to contain %q", tt.command, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("execArgs(%q) = %q, %v, want %q", tt.command, got, err, tt.want)
+		}
+	}
+}
+
+func TestRunExec(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"got $1\"\necho warning >&2\nexit $2\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := execTemplateData{OutputPath: filepath.Join(dir, "talk notes.org")}
+
+	var logs bytes.Buffer
+	captureLog(t, &logs)
+	if err := runExec(Config{Exec: script + " {{.OutputPath}} 0"}, data); err != nil {
+		t.Fatalf("runExec() = %v", err)
+	}
+	for _, want := range []string{"-exec stdout: got " + data.OutputPath + "\n", "-exec stderr: warning\n"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log = %q, want it to contain %q", logs.String(), want)
+		}
+	}
+
+	if err := runExec(Config{Exec: script + " {{.OutputPath}} 3"}, data); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("runExec() with a failing command = %v, want exit status 3", err)
+	}
+}
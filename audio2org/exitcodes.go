@@ -0,0 +1,69 @@
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+)
+
+// Exit codes, so wrapper scripts can tell a failure worth retrying from
+// one that needs fixing first.
+const (
+	exitFailure      = 1 // anything not covered below, including a batch with failed files
+	exitUsage        = 2 // invalid flags or configuration
+	exitAuth         = 3 // no API key, or the API rejected it
+	exitIO           = 4 // a file could not be read or written
+	exitAPITransient = 5 // rate limits, 5xx responses, and network errors that outlasted the retries
+	exitAPIPermanent = 6 // other API errors, which a retry will not fix
+)
+
+var errNoAPIKey = errors.New("OPENAI_API_KEY not set in environment; set it, or name a file holding the key with -api-key-file or OPENAI_API_KEY_FILE")
+
+// apiStatusError is an error response from the OpenAI API.
+type apiStatusError struct {
+	StatusCode int
+	// Code is the error's code, or its type without one, e.g.
+	// insufficient_quota.
+	Code string
+	err  error
+}
+
+func (e *apiStatusError) Error() string { return e.err.Error() }
+func (e *apiStatusError) Unwrap() error { return e.err }
+
+func (e *apiStatusError) exitCode() int {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return exitAuth
+	case e.Code == "insufficient_quota":
+		// Sent with a 429, but it lasts until the billing is sorted out.
+		return exitAPIPermanent
+	case e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusConflict || retryableStatuses[e.StatusCode] || e.StatusCode >= 500:
+		return exitAPITransient
+	}
+	return exitAPIPermanent
+}
+
+// exitCode maps the error run returned to the process exit code.
+func exitCode(err error) int {
+	var config configErrors
+	var status *apiStatusError
+	// Only a *url.Error: syscall errors such as ENOENT are net.Errors too.
+	var netErr *url.Error
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, errNoAPIKey):
+		return exitAuth
+	case errors.As(err, &config):
+		return exitUsage
+	case errors.As(err, &status):
+		return status.exitCode()
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return exitAPITransient
+	case errors.As(err, &pathErr):
+		return exitIO
+	}
+	return exitFailure
+}
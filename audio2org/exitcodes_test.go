@@ -0,0 +1,37 @@
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	_, notFound := os.Open("/nonexistent/talk.mp3")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"other", errors.New("boom"), exitFailure},
+		{"configuration", configErrors{errors.New("-max-tokens must be positive, got 0")}, exitUsage},
+		{"no API key", configErrors{errors.New("-max-tokens must be positive, got 0"), errNoAPIKey}, exitAuth},
+		{"rejected key", fmt.Errorf("transcribing: %w", &apiStatusError{StatusCode: 401, Code: "invalid_api_key", err: errors.New("Whisper API: invalid_api_key")}), exitAuth},
+		{"rate limit", &quotaError{&apiStatusError{StatusCode: 429, Code: "requests", err: errors.New("rate limit")}}, exitAPITransient},
+		{"quota", &apiStatusError{StatusCode: 429, Code: "insufficient_quota", err: errors.New("quota")}, exitAPIPermanent},
+		{"server error", &apiStatusError{StatusCode: 503, err: errors.New("unavailable")}, exitAPITransient},
+		{"bad request", &apiStatusError{StatusCode: 400, Code: "invalid_request_error", err: errors.New("bad")}, exitAPIPermanent},
+		{"network", fmt.Errorf("sending request to OpenAI API: %w", &url.Error{Op: "Post", URL: "https://api.openai.com", Err: context.DeadlineExceeded}), exitAPITransient},
+		{"missing file", fmt.Errorf("reading audio file: %w", notFound), exitIO},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// quotaError is a request that still failed with 429, for a rate limit or
+// insufficient_quota, once its retries ran out.
+type quotaError struct {
+	err error
+}
+
+func (e *quotaError) Error() string { return e.err.Error() }
+func (e *quotaError) Unwrap() error { return e.err }
+
+// quotaFailure marks err as a quotaError when resp is a 429.
+func quotaFailure(resp *resty.Response, err error) error {
+	if err != nil && resp != nil && resp.StatusCode() == http.StatusTooManyRequests {
+		return &quotaError{err}
+	}
+	return err
+}
+
+func checkFallbackSummaryModel(config Config) error {
+	switch {
+	case config.FallbackSummaryModel == "":
+		return nil
+	case strings.TrimSpace(config.FallbackSummaryModel) == "":
+		return errors.New("-fallback-summary-model is empty")
+	case !hasPostStep(config, "create_emacs_org_notes") && !hasPostStep(config, "create_markdown_notes"):
+		return errors.New("-fallback-summary-model requires -post create_emacs_org_notes or create_markdown_notes")
+	case config.SummarizerCmd != "":
+		return errors.New("-fallback-summary-model cannot be combined with -summarizer-cmd, which makes no API request")
+	case config.FallbackSummaryModel == config.SummaryModel:
+		return fmt.Errorf("-fallback-summary-model is the same as -summary-model, %s", config.SummaryModel)
+	}
+	return nil
+}
+
+// sendNotesRequest sends a notes request to -summary-model, and sends it
+// again to -fallback-summary-model when the first one is refused for its
+// rate limit or quota.
+func sendNotesRequest(config Config, reqBody map[string]interface{}, rawResponsePath string) (string, error) {
+	content, err := sendChatRequest(config, reqBody, rawResponsePath)
+	var quota *quotaError
+	if config.FallbackSummaryModel == "" || !errors.As(err, &quota) {
+		return content, err
+	}
+
+	log.Printf("Warning: %s hit its rate or quota limit (%v); downgrading to -fallback-summary-model %s\n",
+		reqBody["model"], err, config.FallbackSummaryModel)
+	reqBody["model"] = config.FallbackSummaryModel
+	content, err = sendChatRequest(config, reqBody, rawResponsePath)
+	if err != nil {
+		return "", fmt.Errorf("with -fallback-summary-model %s: %w", config.FallbackSummaryModel, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,81 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendNotesRequestFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		fallback   string
+		wantModels []string
+		wantErr    string
+	}{
+		{"quota", http.StatusTooManyRequests, "gpt-4o-mini", []string{"gpt-4o", "gpt-4o-mini"}, ""},
+		{"no fallback", http.StatusTooManyRequests, "", []string{"gpt-4o"}, "insufficient_quota"},
+		{"other error", http.StatusBadRequest, "gpt-4o-mini", []string{"gpt-4o"}, "invalid_request_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var models []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var reqBody map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&reqBody)
+				model, _ := reqBody["model"].(string)
+				models = append(models, model)
+				w.Header().Set("Content-Type", "application/json")
+				if model == "gpt-4o" {
+					w.WriteHeader(tt.status)
+					kind := "insufficient_quota"
+					if tt.status != http.StatusTooManyRequests {
+						kind = "invalid_request_error"
+					}
+					w.Write([]byte(`{"error": {"message": "refused", "type": "` + kind + `"}}`))
+					return
+				}
+				w.Write([]byte(`{"choices": [{"message": {"content": "* Notes"}, "finish_reason": "stop"}]}`))
+			}))
+			defer server.Close()
+
+			config := Config{OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", FallbackSummaryModel: tt.fallback}
+			content, err := sendNotesRequest(config, map[string]interface{}{"model": "gpt-4o"}, "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+			} else if err != nil || content != "* Notes" {
+				t.Errorf("sendNotesRequest() = %q, %v, want the fallback's notes", content, err)
+			}
+			if strings.Join(models, ",") != strings.Join(tt.wantModels, ",") {
+				t.Errorf("models requested = %v, want %v", models, tt.wantModels)
+			}
+		})
+	}
+}
+
+func TestCheckFallbackSummaryModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"off", Config{}, false},
+		{"ok", Config{FallbackSummaryModel: "gpt-4o-mini", SummaryModel: "gpt-4o", PostProcessCmd: "create_emacs_org_notes"}, false},
+		{"markdown", Config{FallbackSummaryModel: "gpt-4o-mini", SummaryModel: "gpt-4o", PostProcessCmd: "create_markdown_notes"}, false},
+		{"no notes", Config{FallbackSummaryModel: "gpt-4o-mini", SummaryModel: "gpt-4o", PostProcessCmd: "create_glossary"}, true},
+		{"same model", Config{FallbackSummaryModel: "gpt-4o", SummaryModel: "gpt-4o", PostProcessCmd: "create_emacs_org_notes"}, true},
+		{"summarizer cmd", Config{FallbackSummaryModel: "gpt-4o-mini", SummaryModel: "gpt-4o", PostProcessCmd: "create_emacs_org_notes", SummarizerCmd: "llm"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkFallbackSummaryModel(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkFallbackSummaryModel() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
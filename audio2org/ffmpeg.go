@@ -0,0 +1,186 @@
+package audio2org
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const silenceFilter = "silenceremove=start_periods=1:start_threshold=-50dB:start_silence=0.5," +
+	"areverse," +
+	"silenceremove=start_periods=1:start_threshold=-50dB:start_silence=0.5," +
+	"areverse"
+
+func requireFFmpeg(feature string) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("%s requires ffmpeg, but it was not found on PATH", feature)
+	}
+	return nil
+}
+
+func runFFmpeg(args ...string) error {
+	cmd := exec.Command("ffmpeg", append([]string{"-hide_banner", "-loglevel", "error", "-y"}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running ffmpeg: %w\n%s", err, output)
+	}
+	return nil
+}
+
+func trimSilence(audioFilePath string) (string, error) {
+	if err := requireFFmpeg("-trim-silence"); err != nil {
+		return "", err
+	}
+
+	tmpPath, err := createTempFile("trimmed", filepath.Ext(audioFilePath))
+	if err != nil {
+		return "", err
+	}
+
+	log.Println("Trimming leading and trailing silence...")
+	if err := runFFmpeg("-i", audioFilePath, "-af", silenceFilter, tmpPath); err != nil {
+		removeTempFile(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+func requireFFprobe(feature string) error {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return fmt.Errorf("%s requires ffprobe (part of ffmpeg), but it was not found on PATH", feature)
+	}
+	return nil
+}
+
+func probeDuration(audioFilePath string) (time.Duration, error) {
+	output, err := exec.Command("ffprobe", "-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		audioFilePath).Output()
+	if err != nil {
+		return 0, fmt.Errorf("probing audio duration: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing audio duration %q: %w", strings.TrimSpace(string(output)), err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+type audioInfo struct {
+	Duration   time.Duration
+	Codec      string
+	SampleRate int
+	Channels   int
+	BitRate    int
+	Size       int64
+}
+
+// probeAudioInfo reads the container and first audio stream details with
+// ffprobe. Codec is empty when the file has no audio stream.
+func probeAudioInfo(audioFilePath string) (audioInfo, error) {
+	output, err := exec.Command("ffprobe", "-v", "error",
+		"-select_streams", "a:0",
+		"-show_entries", "format=duration,bit_rate,size:stream=codec_name,sample_rate,channels",
+		"-of", "json",
+		audioFilePath).Output()
+	if err != nil {
+		return audioInfo{}, fmt.Errorf("ffprobe could not read the file: %v", err)
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+			Size     string `json:"size"`
+		} `json:"format"`
+		Streams []struct {
+			CodecName  string `json:"codec_name"`
+			SampleRate string `json:"sample_rate"`
+			Channels   int    `json:"channels"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return audioInfo{}, fmt.Errorf("error parsing ffprobe output: %v", err)
+	}
+
+	var info audioInfo
+	seconds, _ := strconv.ParseFloat(probe.Format.Duration, 64)
+	info.Duration = time.Duration(seconds * float64(time.Second))
+	info.BitRate, _ = strconv.Atoi(probe.Format.BitRate)
+	info.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	if len(probe.Streams) > 0 {
+		info.Codec = probe.Streams[0].CodecName
+		info.SampleRate, _ = strconv.Atoi(probe.Streams[0].SampleRate)
+		info.Channels = probe.Streams[0].Channels
+	}
+	return info, nil
+}
+
+func printAudioInfo(config Config) error {
+	if err := requireFFprobe("-info"); err != nil {
+		return err
+	}
+
+	info, err := probeAudioInfo(config.AudioFilePath)
+	if err != nil {
+		return fmt.Errorf("probing %s: %w", config.AudioFilePath, err)
+	}
+	if info.Codec == "" {
+		return fmt.Errorf("%s has no audio stream", config.AudioFilePath)
+	}
+
+	fmt.Printf("File:        %s\n", config.AudioFilePath)
+	fmt.Printf("Duration:    %s (%.1fs)\n", formatTimestamp(info.Duration.Seconds()), info.Duration.Seconds())
+	fmt.Printf("Codec:       %s\n", info.Codec)
+	fmt.Printf("Sample rate: %d Hz\n", info.SampleRate)
+	fmt.Printf("Channels:    %d\n", info.Channels)
+	fmt.Printf("Bitrate:     %d kb/s\n", info.BitRate/1000)
+	fmt.Printf("Size:        %.1f MB (%d bytes)\n", float64(info.Size)/(1024*1024), info.Size)
+	return nil
+}
+
+func sampleTranscription(config Config) error {
+	if err := requireFFmpeg("-sample"); err != nil {
+		return err
+	}
+
+	log.Printf("Transcribing the first %s of %s...\n", config.Sample, config.AudioFilePath)
+	uploadPath, cleanup, err := uploadSource(config)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	samplePath, err := extractRegion(uploadPath, audioRegion{Start: 0, End: config.Sample.Seconds()})
+	if err != nil {
+		return err
+	}
+	defer removeTempFile(samplePath)
+
+	audioBytes, err := os.ReadFile(samplePath)
+	if err != nil {
+		return fmt.Errorf("reading audio sample: %w", err)
+	}
+
+	// Only whisper-1 reports the detected language, via verbose_json.
+	var extraForm map[string]string
+	if config.TranscribeModel == "whisper-1" {
+		extraForm = map[string]string{"response_format": "verbose_json"}
+	}
+	transcription, err := transcribeCached(config, uploadFileName(config, samplePath), bytes.NewReader(audioBytes), extraForm)
+	if err != nil {
+		return err
+	}
+	if transcription.Language != "" {
+		log.Printf("Detected language: %s\n", transcription.Language)
+	}
+	fmt.Println(strings.TrimSpace(transcription.Text))
+	return nil
+}
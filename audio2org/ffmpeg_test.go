@@ -0,0 +1,50 @@
+package audio2org
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrimSilence(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg is not installed")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// A second of tone between a second of silence on either side.
+	input := filepath.Join(t.TempDir(), "talk.wav")
+	if err := runFFmpeg("-f", "lavfi", "-i", "sine=frequency=440:duration=1", "-af", "adelay=1000,apad=pad_dur=1", input); err != nil {
+		t.Fatal(err)
+	}
+
+	trimmed, err := trimSilence(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeTempFile(trimmed)
+
+	if !strings.HasPrefix(filepath.Base(trimmed), tempFilePrefix+"trimmed-") || filepath.Ext(trimmed) != ".wav" {
+		t.Errorf("trimmed file name = %q, want a .wav temp file", trimmed)
+	}
+	info, err := os.Stat(trimmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() == 0 || info.Size() >= before.Size() {
+		t.Errorf("trimmed file is %d bytes, want it smaller than the %d byte input", info.Size(), before.Size())
+	}
+
+	tempFilesMu.Lock()
+	_, registered := tempFiles[trimmed]
+	tempFilesMu.Unlock()
+	if !registered {
+		t.Errorf("%s is not registered for cleanup", trimmed)
+	}
+}
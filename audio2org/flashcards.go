@@ -0,0 +1,110 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const maxFlashcards = 200
+
+var cardDifficultyPrompts = map[string]string{
+	"basic":        "Ask about key terms, names, and facts stated directly in the content.",
+	"intermediate": "Mix recall of key facts with questions about how and why things work as described.",
+	"advanced":     "Focus on reasoning, comparisons, trade-offs, and applying the ideas to new situations, rather than simple recall.",
+}
+
+type flashcard struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+func createFlashcards(config Config, transcriptionText, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_flashcards command...")
+
+	message := map[string]string{
+		"role":    "user",
+		"content": createFlashcardsPrompt(transcriptionText, config.Cards, config.CardDifficulty),
+	}
+
+	reqBody := map[string]interface{}{
+		"model":           config.SummaryModel,
+		"messages":        []map[string]string{message},
+		"max_tokens":      4000,
+		"temperature":     0.5,
+		"response_format": map[string]string{"type": "json_object"},
+	}
+
+	content, err := sendChatRequest(config, reqBody, chatResponsePath(config, baseFilePath, ""))
+	if err != nil {
+		return "", err
+	}
+	cards, err := parseFlashcards(content)
+	if err != nil {
+		return "", fmt.Errorf("invalid flashcards from OpenAI API: %w", err)
+	}
+	if len(cards) > config.Cards {
+		cards = cards[:config.Cards]
+	} else if len(cards) < config.Cards {
+		log.Printf("The model returned %d of the %d requested cards\n", len(cards), config.Cards)
+	}
+
+	tsv := formatFlashcardsTSV(cards)
+	outputFilePath := generateDerivedFilePath(baseFilePath, "_cards.tsv")
+	if err := writeToFile(config, outputFilePath, tsv); err != nil {
+		return "", err
+	}
+	return tsv, nil
+}
+
+// parseFlashcards reads the model's {"cards": [...]} reply, dropping cards
+// with an empty question or answer.
+func parseFlashcards(content string) ([]flashcard, error) {
+	var reply struct {
+		Cards []flashcard `json:"cards"`
+	}
+	if err := json.Unmarshal([]byte(content), &reply); err != nil {
+		return nil, err
+	}
+
+	var cards []flashcard
+	for _, card := range reply.Cards {
+		card.Question, card.Answer = tsvField(card.Question), tsvField(card.Answer)
+		if card.Question != "" && card.Answer != "" {
+			cards = append(cards, card)
+		}
+	}
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("no cards with both a question and an answer")
+	}
+	return cards, nil
+}
+
+// formatFlashcardsTSV writes one question<TAB>answer line per card, the
+// plain-text layout Anki imports with the tab separator.
+func formatFlashcardsTSV(cards []flashcard) string {
+	var b strings.Builder
+	for _, card := range cards {
+		fmt.Fprintf(&b, "%s\t%s\n", tsvField(card.Question), tsvField(card.Answer))
+	}
+	return b.String()
+}
+
+// tsvField collapses tabs and line breaks, which would otherwise split a
+// card across fields or notes, into single spaces.
+func tsvField(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func createFlashcardsPrompt(transcriptionText string, count int, difficulty string) string {
+	return fmt.Sprintf(`Write %d flashcards for studying the following content, as a single JSON object of the form {"cards": [{"question": "...", "answer": "..."}]}.
+
+Each question should be answerable from the content alone and make sense without seeing the other cards. Keep answers short, a phrase or one or two sentences. %s Do not ask about the speakers or the recording itself.
+
+Respond with the JSON object only.
+
+Here is the content:
+
+%s`, count, cardDifficultyPrompts[difficulty], transcriptionText)
+}
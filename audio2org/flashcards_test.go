@@ -0,0 +1,42 @@
+package audio2org
+
+import "testing"
+
+func TestParseFlashcards(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "cards",
+			content: `{"cards": [{"question": "What does GC stand for?", "answer": "Garbage collection"}, {"question": "Who wrote it?", "answer": "The runtime team"}]}`,
+			want:    "What does GC stand for?\tGarbage collection\nWho wrote it?\tThe runtime team\n",
+		},
+		{
+			name:    "tabs and newlines collapsed",
+			content: `{"cards": [{"question": "Name\tthe phases", "answer": "Mark,\nthen sweep"}]}`,
+			want:    "Name the phases\tMark, then sweep\n",
+		},
+		{
+			name:    "incomplete cards dropped",
+			content: `{"cards": [{"question": "What is a heap?", "answer": " "}, {"question": "What is a stack?", "answer": "Per-goroutine memory"}]}`,
+			want:    "What is a stack?\tPer-goroutine memory\n",
+		},
+		{name: "no cards", content: `{"cards": []}`, wantErr: true},
+		{name: "not JSON", content: "Q: What is Go?", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards, err := parseFlashcards(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlashcards() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := formatFlashcardsTSV(cards); !tt.wantErr && got != tt.want {
+				t.Errorf("formatFlashcardsTSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
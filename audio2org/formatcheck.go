@@ -0,0 +1,152 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const maxUploadMB = 25
+
+// supportedExtensions are the file extensions Whisper accepts; files with
+// any other extension are rejected before upload.
+var (
+	supportedExtensions = []string{".flac", ".m4a", ".mp3", ".mp4", ".mpeg", ".mpga", ".oga", ".ogg", ".wav", ".webm"}
+	supportedCodecs     = []string{"aac", "flac", "mp3", "opus", "vorbis"}
+)
+
+// checkAudioFile rejects a -file input Whisper would refuse before any of
+// it is read or uploaded, and warns when it is over the upload limit and
+// has to be split.
+func checkAudioFile(config Config) error {
+	path := config.AudioFilePath
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("reading audio file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory; pass it as the only -file to transcribe the audio files in it", path)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if needsTranscode(config) {
+		return requireTranscodeFFmpeg(path)
+	}
+	if !slices.Contains(supportedExtensions, ext) {
+		what := fmt.Sprintf("the extension %q", ext)
+		if ext == "" {
+			what = "no extension"
+		}
+		return fmt.Errorf("%s has %s, which Whisper does not accept; supported: %s, or use -transcode to convert it with ffmpeg", path, what, strings.Join(supportedExtensions, " "))
+	}
+
+	if info.Size() > maxUploadMB*1024*1024 && config.Backend != "local" && !config.VAD && !config.Multilang {
+		if err := requireFFmpeg(fmt.Sprintf("%s is %.1f MB, over the %d MB Whisper upload limit, and splitting it", path, float64(info.Size())/(1024*1024), maxUploadMB)); err != nil {
+			return err
+		}
+		log.Printf("Warning: %s is %.1f MB, over the %d MB Whisper upload limit; it will be split into chunks of at most %d MB\n",
+			path, float64(info.Size())/(1024*1024), maxUploadMB, config.MaxChunkMB)
+	}
+	return nil
+}
+
+type checkResult struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// formatCheck runs the pre-flight checks on the -file input without
+// calling the API, and reports whether the file is ready to transcribe.
+func formatCheck(config Config) []checkResult {
+	path := config.AudioFilePath
+	var results []checkResult
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return append(results, checkResult{"exists", false, err.Error()})
+	}
+	if info.IsDir() {
+		return append(results, checkResult{"exists", false, path + " is a directory"})
+	}
+	results = append(results, checkResult{"exists", true, path})
+
+	if info.Size() == 0 {
+		return append(results, checkResult{"non-empty", false, "the file is empty"})
+	}
+	results = append(results, checkResult{"non-empty", true, fmt.Sprintf("%d bytes", info.Size())})
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if needsTranscode(config) {
+		err := requireTranscodeFFmpeg(path)
+		detail := fmt.Sprintf("%q, converted to MP3 with -transcode", ext)
+		if err != nil {
+			detail = err.Error()
+		}
+		results = append(results, checkResult{"extension", err == nil, detail})
+	} else {
+		results = append(results, checkResult{"extension", slices.Contains(supportedExtensions, ext),
+			fmt.Sprintf("%q (supported: %s)", ext, strings.Join(supportedExtensions, " "))})
+	}
+
+	sizeMB := float64(info.Size()) / (1024 * 1024)
+	switch {
+	case config.VAD || config.Multilang:
+		results = append(results, checkResult{"size", true, fmt.Sprintf("%.1f MB, uploaded in chunks", sizeMB)})
+	case info.Size() <= maxChunkBytes(config):
+		results = append(results, checkResult{"size", true, fmt.Sprintf("%.1f MB (limit %d MB per upload)", sizeMB, config.MaxChunkMB)})
+	default:
+		_, err := exec.LookPath("ffmpeg")
+		results = append(results, checkResult{"size", err == nil,
+			fmt.Sprintf("%.1f MB, over %d MB, so it is split into chunks, which requires ffmpeg", sizeMB, config.MaxChunkMB)})
+	}
+
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return append(results, checkResult{"codec", false, "ffprobe not found on PATH; cannot inspect the audio"})
+	}
+
+	probe, err := probeAudioInfo(path)
+	switch {
+	case err != nil:
+		results = append(results, checkResult{"codec", false, err.Error()})
+	case probe.Codec == "":
+		results = append(results, checkResult{"codec", false, "no audio stream found"})
+	default:
+		results = append(results, checkResult{"codec", slices.Contains(supportedCodecs, probe.Codec) || strings.HasPrefix(probe.Codec, "pcm_"), probe.Codec})
+	}
+
+	if err != nil || probe.Duration <= 0 {
+		results = append(results, checkResult{"duration", false, "could not read the duration"})
+	} else {
+		results = append(results, checkResult{"duration", true, formatTimestamp(probe.Duration.Seconds())})
+	}
+
+	return results
+}
+
+func printCheckResults(results []checkResult) bool {
+	color := isTerminal(os.Stdout)
+	ready := true
+	for _, result := range results {
+		status, code := "ok  ", "32"
+		if !result.OK {
+			status, code = "FAIL", "31"
+			ready = false
+		}
+		if color {
+			status = "\033[" + code + "m" + status + "\033[0m"
+		}
+		fmt.Printf("%s  %-9s  %s\n", status, result.Name, result.Detail)
+	}
+
+	if ready {
+		fmt.Println("Ready to transcribe.")
+	} else {
+		fmt.Println("Not ready to transcribe.")
+	}
+	return ready
+}
@@ -0,0 +1,45 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckAudioFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"talk.mp3", "talk.M4A", "notes.txt", "README"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{"supported extension", "talk.mp3", ""},
+		{"extension case ignored", "talk.M4A", ""},
+		{"text file", "notes.txt", `".txt", which Whisper does not accept`},
+		{"no extension", "README", "has no extension"},
+		{"missing file", "missing.mp3", "reading audio file"},
+		{"directory", ".", "is a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAudioFile(Config{AudioFilePath: filepath.Join(dir, tt.file), MaxChunkMB: 24})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkAudioFile() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkAudioFile() = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
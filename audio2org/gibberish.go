@@ -0,0 +1,105 @@
+package audio2org
+
+import (
+	"io"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// The repetition detector works on phrases of this many words.
+const gibberishPhraseWords = 3
+
+// Transcripts with fewer phrases than this are too short to judge.
+const gibberishMinPhrases = 20
+
+// Sampling at a higher temperature usually breaks Whisper out of a loop.
+const gibberishRetryTemperature = "0.4"
+
+// retryGibberish transcribes the audio once more when the transcription
+// looks like Whisper got stuck repeating itself, and keeps whichever of the
+// two attempts repeats less. The retry drops the previous chunk's text from
+// the prompt, which is a common trigger for these loops.
+func retryGibberish(config Config, filePath string, audio io.ReadSeeker, extraForm map[string]string, transcription TranscriptionResponse) (TranscriptionResponse, error) {
+	ratio := repetitionRatio(transcription.Text)
+	if ratio <= config.GibberishThreshold {
+		return transcription, nil
+	}
+	log.Printf("Transcription looks repetitive: %.2f of its %d-word phrases are repeats, over the -gibberish-threshold of %.2f; retrying at temperature %s\n",
+		ratio, gibberishPhraseWords, config.GibberishThreshold, gibberishRetryTemperature)
+
+	form := map[string]string{}
+	for key, value := range extraForm {
+		form[key] = value
+	}
+	delete(form, "prompt")
+	form["temperature"] = gibberishRetryTemperature
+
+	retried, err := newTranscriber(config).Transcribe(filePath, audio, form)
+	if err != nil {
+		log.Printf("Warning: retrying the repetitive transcription failed, keeping the first one: %v\n", err)
+		return transcription, nil
+	}
+
+	retriedRatio := repetitionRatio(retried.Text)
+	if retriedRatio >= ratio {
+		log.Printf("Warning: the retry was no better (%.2f of its phrases are repeats); keeping the first transcription\n", retriedRatio)
+		return transcription, nil
+	}
+	if retriedRatio > config.GibberishThreshold {
+		log.Printf("Warning: the retry still looks repetitive (%.2f of its phrases are repeats); keeping it since it repeats less\n", retriedRatio)
+	} else {
+		log.Printf("Retry looks fine (%.2f of its phrases are repeats)\n", retriedRatio)
+	}
+	return retried, nil
+}
+
+// repetitionRatio returns the fraction of the text's three-word phrases that
+// already appeared earlier in it. Ordinary speech stays well under 0.2, while
+// a transcript stuck repeating one sentence approaches 1. Texts too short to
+// judge return 0.
+func repetitionRatio(text string) float64 {
+	words := repetitionWords(text)
+	phrases := len(words) - gibberishPhraseWords + 1
+	if phrases < gibberishMinPhrases {
+		return 0
+	}
+
+	seen := map[string]bool{}
+	repeats := 0
+	for i := 0; i < phrases; i++ {
+		phrase := strings.Join(words[i:i+gibberishPhraseWords], " ")
+		if seen[phrase] {
+			repeats++
+		}
+		seen[phrase] = true
+	}
+	return float64(repeats) / float64(phrases)
+}
+
+// repetitionWords lowercases text and splits it into words, ignoring
+// punctuation, with each character of scripts written without spaces
+// counted as its own word, as countWords does.
+func repetitionWords(text string) []string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			flush()
+			words = append(words, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
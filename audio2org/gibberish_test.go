@@ -0,0 +1,34 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepetitionRatio(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		atLeast float64
+		atMost  float64
+	}{
+		{"too short to judge", "thank you thank you thank you", 0, 0},
+		{"ordinary speech", "Today we are going to look at how the compiler turns source into machine code. " +
+			"First the parser builds a tree, then the type checker walks it and reports errors, " +
+			"and finally the back end lowers everything to instructions for the target.", 0, 0.1},
+		{"stuck on one sentence", strings.Repeat("Thanks for watching, see you next time. ", 20), 0.9, 1},
+		{"loop after real speech", "We looked at the results of the second experiment and they were promising. " +
+			strings.Repeat("I'm going to go ahead and ", 12), 0.5, 0.9},
+		{"case and punctuation ignored", strings.Repeat("Okay. OKAY, okay! ", 10), 0.9, 1},
+		{"japanese loop", strings.Repeat("ご視聴ありがとうございました。", 5), 0.7, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repetitionRatio(tt.text)
+			if got < tt.atLeast || got > tt.atMost {
+				t.Errorf("repetitionRatio() = %.2f, want between %.2f and %.2f", got, tt.atLeast, tt.atMost)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package audio2org
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const indexSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at      TEXT NOT NULL,
+	source          TEXT NOT NULL,
+	transcript_path TEXT NOT NULL,
+	transcript      TEXT NOT NULL,
+	post_command    TEXT,
+	summary         TEXT,
+	model           TEXT NOT NULL,
+	summary_model   TEXT,
+	duration_secs   REAL,
+	cost_usd        REAL
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS runs_fts USING fts5(transcript, summary);
+`
+
+func indexRun(config Config, transcription TranscriptionResponse, transcriptPath, summary string) error {
+	// Files of a -concurrency batch record their runs from separate
+	// processes, so wait for the write lock instead of failing.
+	db, err := sql.Open("sqlite", config.IndexDB+"?_pragma=busy_timeout(10000)")
+	if err != nil {
+		return fmt.Errorf("opening index database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(indexSchema); err != nil {
+		return fmt.Errorf("creating index schema: %w", err)
+	}
+
+	source := inputSource(config)
+
+	if !transcriptOnDisk(config) {
+		transcriptPath = ""
+	}
+
+	var summaryModel, postCommand interface{}
+	if config.PostProcessCmd != "" {
+		postCommand = config.PostProcessCmd
+		summaryModel = config.SummaryModel
+	}
+	var duration interface{}
+	if transcription.Duration > 0 {
+		duration = transcription.Duration
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting index transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO runs
+		(created_at, source, transcript_path, transcript, post_command, summary, model, summary_model, duration_secs)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().UTC().Format(time.RFC3339), source, transcriptPath, transcription.Text,
+		postCommand, summary, config.TranscribeModel, summaryModel, duration)
+	if err != nil {
+		return fmt.Errorf("inserting into index database: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("reading index row id: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO runs_fts (rowid, transcript, summary) VALUES (?, ?, ?)`,
+		id, transcription.Text, summary); err != nil {
+		return fmt.Errorf("inserting into index search table: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing index transaction: %w", err)
+	}
+	log.Printf("Run recorded in %s (id %d)\n", config.IndexDB, id)
+	return nil
+}
@@ -0,0 +1,42 @@
+package audio2org
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexRunIsSearchable(t *testing.T) {
+	config := Config{
+		IndexDB:        filepath.Join(t.TempDir(), "index.sqlite"),
+		AudioFilePath:  "standup.mp3",
+		PostProcessCmd: "create_emacs_org_notes",
+	}
+
+	if err := indexRun(config, TranscriptionResponse{Text: "we discussed the kubernetes migration"}, "output/standup.txt", "* Summary\nMigration plan"); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexRun(config, TranscriptionResponse{Text: "budget review for next quarter"}, "output/budget.txt", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", config.IndexDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var source string
+	err = db.QueryRow(`SELECT runs.source FROM runs_fts JOIN runs ON runs.id = runs_fts.rowid WHERE runs_fts MATCH 'kubernetes'`).Scan(&source)
+	if err != nil {
+		t.Fatalf("full-text query failed: %v", err)
+	}
+	if source != "standup.mp3" {
+		t.Fatalf("source = %q, want standup.mp3", source)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM runs`).Scan(&count); err != nil || count != 2 {
+		t.Fatalf("runs count = %d (err %v), want 2", count, err)
+	}
+}
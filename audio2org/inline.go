@@ -0,0 +1,111 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const inlineSectionSeconds = 120.0
+
+type transcriptSection struct {
+	Start    float64
+	End      float64
+	Segments []TranscriptionSegment
+}
+
+type inlineSummaryResponse struct {
+	Sections []struct {
+		Section int      `json:"section"`
+		Bullets []string `json:"bullets"`
+	} `json:"sections"`
+}
+
+func groupSegments(segments []TranscriptionSegment, sectionSeconds float64) []transcriptSection {
+	var sections []transcriptSection
+	for _, segment := range segments {
+		if len(sections) == 0 || segment.Start-sections[len(sections)-1].Start >= sectionSeconds {
+			sections = append(sections, transcriptSection{Start: segment.Start})
+		}
+		current := &sections[len(sections)-1]
+		current.Segments = append(current.Segments, segment)
+		current.End = segment.End
+	}
+	return sections
+}
+
+func createInlineSummary(config Config, segments []TranscriptionSegment, baseFilePath string) error {
+	log.Println("Creating transcript with inline summary comments...")
+
+	sections := groupSegments(segments, inlineSectionSeconds)
+	if len(sections) == 0 {
+		log.Println("Skipping -inline-summary: the transcription has no segments")
+		return nil
+	}
+
+	message := map[string]string{
+		"role":    "user",
+		"content": createInlineSummaryPrompt(sections),
+	}
+
+	reqBody := map[string]interface{}{
+		"model":           config.SummaryModel,
+		"messages":        []map[string]string{message},
+		"max_tokens":      3000,
+		"temperature":     0.3,
+		"response_format": map[string]string{"type": "json_object"},
+	}
+
+	content, err := sendChatRequest(config, reqBody, chatResponsePath(config, baseFilePath, "_inline"))
+	if err != nil {
+		return err
+	}
+	var summary inlineSummaryResponse
+	if err := json.Unmarshal([]byte(content), &summary); err != nil {
+		return fmt.Errorf("unmarshalling inline summary: %w", err)
+	}
+
+	bullets := map[int][]string{}
+	for _, section := range summary.Sections {
+		bullets[section.Section] = section.Bullets
+	}
+
+	outputFilePath := generateDerivedFilePath(baseFilePath, "_inline.org")
+	return writeToFile(config, outputFilePath, formatInlineSummary(sections, bullets))
+}
+
+func formatInlineSummary(sections []transcriptSection, bullets map[int][]string) string {
+	var b strings.Builder
+	b.WriteString("#+title: Transcript\n")
+
+	for i, section := range sections {
+		fmt.Fprintf(&b, "\n# Summary %s-%s:\n", formatTimestamp(section.Start), formatTimestamp(section.End))
+		for _, bullet := range bullets[i+1] {
+			fmt.Fprintf(&b, "# - %s\n", strings.TrimSpace(bullet))
+		}
+		for _, segment := range section.Segments {
+			fmt.Fprintf(&b, "[%s] %s\n", formatTimestamp(segment.Start), strings.TrimSpace(segment.Text))
+		}
+	}
+
+	return b.String()
+}
+
+func createInlineSummaryPrompt(sections []transcriptSection) string {
+	var b strings.Builder
+	for i, section := range sections {
+		fmt.Fprintf(&b, "Section %d [%s-%s]:\n", i+1, formatTimestamp(section.Start), formatTimestamp(section.End))
+		for _, segment := range section.Segments {
+			b.WriteString(strings.TrimSpace(segment.Text))
+			b.WriteString(" ")
+		}
+		b.WriteString("\n\n")
+	}
+
+	return fmt.Sprintf(`The following transcript is split into numbered sections. For each section, write one to three short bullet points summarizing what is said in it.
+
+Respond with a JSON object of the form {"sections": [{"section": 1, "bullets": ["..."]}]}, with one entry per section, using the section numbers given below.
+
+%s`, b.String())
+}
@@ -0,0 +1,86 @@
+package audio2org
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupSegments(t *testing.T) {
+	segment := func(start, end float64) TranscriptionSegment {
+		return TranscriptionSegment{Start: start, End: end}
+	}
+
+	tests := []struct {
+		name     string
+		segments []TranscriptionSegment
+		want     []transcriptSection
+	}{
+		{"no segments", nil, nil},
+		{
+			"one section",
+			[]TranscriptionSegment{segment(0, 4), segment(4, 9)},
+			[]transcriptSection{{Start: 0, End: 9, Segments: []TranscriptionSegment{segment(0, 4), segment(4, 9)}}},
+		},
+		{
+			"new section at the section length",
+			[]TranscriptionSegment{segment(0, 60), segment(60, 119.5), segment(120, 130), segment(130, 250)},
+			[]transcriptSection{
+				{Start: 0, End: 119.5, Segments: []TranscriptionSegment{segment(0, 60), segment(60, 119.5)}},
+				{Start: 120, End: 250, Segments: []TranscriptionSegment{segment(120, 130), segment(130, 250)}},
+			},
+		},
+		{
+			"sections start at the first segment after a gap",
+			[]TranscriptionSegment{segment(5, 10), segment(300, 310), segment(415, 425), segment(420, 430)},
+			[]transcriptSection{
+				{Start: 5, End: 10, Segments: []TranscriptionSegment{segment(5, 10)}},
+				{Start: 300, End: 425, Segments: []TranscriptionSegment{segment(300, 310), segment(415, 425)}},
+				{Start: 420, End: 430, Segments: []TranscriptionSegment{segment(420, 430)}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupSegments(tt.segments, 120); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupSegments() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatInlineSummary(t *testing.T) {
+	sections := []transcriptSection{
+		{Start: 0, End: 95, Segments: []TranscriptionSegment{{Start: 0, End: 40, Text: " Welcome everyone. "}, {Start: 40, End: 95, Text: "First, the budget."}}},
+		{Start: 125, End: 190, Segments: []TranscriptionSegment{{Start: 125, End: 190, Text: "Any questions?"}}},
+	}
+
+	tests := []struct {
+		name    string
+		bullets map[int][]string
+		want    string
+	}{
+		{
+			"bullets per section",
+			map[int][]string{1: {" Introductions ", "Budget review"}, 2: {"Q&A"}},
+			"#+title: Transcript\n" +
+				"\n# Summary 00:00:00-00:01:35:\n# - Introductions\n# - Budget review\n[00:00:00] Welcome everyone.\n[00:00:40] First, the budget.\n" +
+				"\n# Summary 00:02:05-00:03:10:\n# - Q&A\n[00:02:05] Any questions?\n",
+		},
+		{
+			"missing and unknown sections",
+			map[int][]string{2: {"Q&A"}, 7: {"Not a section"}},
+			"#+title: Transcript\n" +
+				"\n# Summary 00:00:00-00:01:35:\n[00:00:00] Welcome everyone.\n[00:00:40] First, the budget.\n" +
+				"\n# Summary 00:02:05-00:03:10:\n# - Q&A\n[00:02:05] Any questions?\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatInlineSummary(sections, tt.bullets); got != tt.want {
+				t.Errorf("formatInlineSummary() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Package audio2org transcribes audio with Whisper and turns transcripts
+// into notes with a chat model. Main is the go-audio2org command line tool;
+// Transcribe and Summarize let other Go programs do the same without
+// running it.
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// TranscribeOptions configures Transcribe. A zero field uses the default of
+// the matching command line flag, named in its comment.
+type TranscribeOptions struct {
+	AudioFile string // -file, a local audio file; required
+	APIKey    string // OPENAI_API_KEY when empty
+	BaseURL   string // -base-url
+	Model     string // -transcribe-model
+	Language  string // -language
+	// VocabPrompt lists names and jargon for Whisper to spell as written.
+	VocabPrompt string // -vocab-prompt
+	OrgID       string // -org-id
+	ProjectID   string // -project-id
+	Timeout     time.Duration
+	// Transcode converts formats Whisper does not accept to MP3 first,
+	// which needs ffmpeg.
+	Transcode bool
+}
+
+// Transcript is what Whisper returned for the audio. Segments and Words
+// are only filled in when the model reports them.
+type Transcript struct {
+	Text     string
+	Language string
+	Duration float64
+	Segments []TranscriptionSegment
+	Words    []TranscriptionWord
+}
+
+// SummarizeOptions configures Summarize. A zero field uses the default of
+// the matching command line flag, named in its comment.
+type SummarizeOptions struct {
+	Transcript string // the text to summarize; required
+	// Command is the -post command to run, create_emacs_org_notes when
+	// empty. create_chapters and create_org_transcript need Whisper's
+	// segments and are not available.
+	Command     string
+	APIKey      string // OPENAI_API_KEY when empty
+	BaseURL     string // -base-url
+	Model       string // -summary-model
+	DetailLevel string // -detail-level
+	MaxTokens   int    // -max-tokens, or the DetailLevel's limit
+	// RecordingDate is the #+date: of the notes, today when zero.
+	RecordingDate time.Time
+	OrgID         string // -org-id
+	ProjectID     string // -project-id
+	Timeout       time.Duration
+}
+
+// Transcribe uploads the audio file to Whisper, in chunks when it is over
+// Whisper's size limit, and returns the transcription. Nothing is written
+// to disk except temp files for chunking and transcoding, which are removed
+// before it returns. Progress is logged with the log package.
+func Transcribe(ctx context.Context, opts TranscribeOptions) (Transcript, error) {
+	if opts.AudioFile == "" {
+		return Transcript{}, errors.New("TranscribeOptions.AudioFile is required")
+	}
+	config := defaultConfig()
+	config.AudioFilePath = opts.AudioFile
+	config.Transcode = opts.Transcode
+	setString(&config.TranscribeModel, opts.Model)
+	setString(&config.Language, opts.Language)
+	setString(&config.VocabPrompt, opts.VocabPrompt)
+	if err := libraryConfig(ctx, &config, opts.APIKey, opts.BaseURL, opts.OrgID, opts.ProjectID, opts.Timeout); err != nil {
+		return Transcript{}, err
+	}
+	var err error
+	if config.VocabPrompt, err = loadVocabPrompt(config); err != nil {
+		return Transcript{}, err
+	}
+	if err := checkAudioFile(config); err != nil {
+		return Transcript{}, err
+	}
+
+	uploadPath, cleanup, err := uploadSource(config)
+	if err != nil {
+		return Transcript{}, err
+	}
+	defer cleanup()
+	transcription, err := transcribeFile(config, uploadPath)
+	if err != nil {
+		return Transcript{}, err
+	}
+	return Transcript{
+		Text:     transcription.Text,
+		Language: transcription.Language,
+		Duration: transcription.Duration,
+		Segments: transcription.Segments,
+		Words:    transcription.Words,
+	}, nil
+}
+
+// Summarize runs a post-processing command on the transcript and returns
+// its output, such as the org notes, without writing any file.
+func Summarize(ctx context.Context, opts SummarizeOptions) (string, error) {
+	config := defaultConfig()
+	setString(&config.PostProcessCmd, opts.Command)
+	if config.PostProcessCmd == "" {
+		config.PostProcessCmd = "create_emacs_org_notes"
+	}
+	switch {
+	case !slices.Contains(postCommands, config.PostProcessCmd):
+		return "", fmt.Errorf("unknown SummarizeOptions.Command %q", config.PostProcessCmd)
+	case config.PostProcessCmd == "create_chapters" || config.PostProcessCmd == "create_org_transcript":
+		return "", fmt.Errorf("%s needs Whisper's segments, which Summarize does not have", config.PostProcessCmd)
+	}
+	setString(&config.SummaryModel, opts.Model)
+	setString(&config.DetailLevel, opts.DetailLevel)
+	if err := checkDetailLevel(config); err != nil {
+		return "", err
+	}
+	config.MaxTokens = notesDetail(config.DetailLevel).maxTokens
+	if opts.MaxTokens > 0 {
+		config.MaxTokens = opts.MaxTokens
+	}
+	if !opts.RecordingDate.IsZero() {
+		config.RecordingDate = opts.RecordingDate.Format(recordingDateLayout)
+	}
+	if err := libraryConfig(ctx, &config, opts.APIKey, opts.BaseURL, opts.OrgID, opts.ProjectID, opts.Timeout); err != nil {
+		return "", err
+	}
+
+	transcription := TranscriptionResponse{Text: opts.Transcript}
+	return runPostStep(config, transcription, opts.Transcript, transcription, "transcript.txt")
+}
+
+// defaultConfig is the Config the command line tool runs with when no
+// flags are given.
+func defaultConfig() Config {
+	var config Config
+	fs := flag.NewFlagSet("audio2org", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	defineFlags(fs, &config)
+	config.MaxTokens = notesDetail(config.DetailLevel).maxTokens
+	return config
+}
+
+// libraryConfig applies the options every library call shares and turns
+// off what only makes sense for a command line run: output files and the
+// transcription cache.
+func libraryConfig(ctx context.Context, config *Config, key, baseURL, orgID, projectID string, timeout time.Duration) error {
+	setString(&config.BaseURL, baseURL)
+	if err := checkBaseURL(config.BaseURL); err != nil {
+		return err
+	}
+	setString(&config.OrgID, orgID)
+	setString(&config.ProjectID, projectID)
+	if timeout > 0 {
+		config.Timeout = timeout
+	}
+	config.OpenAIAPIKey = key
+	if config.OpenAIAPIKey == "" {
+		var err error
+		if config.OpenAIAPIKey, err = apiKey(*config); err != nil {
+			return err
+		}
+	}
+	if config.OpenAIAPIKey == "" {
+		return errNoAPIKey
+	}
+	config.NoOutput = true
+	config.NoCache = true
+	config.usage = &runUsage{}
+	config.ctx = ctx
+	return nil
+}
+
+func setString(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
@@ -0,0 +1,129 @@
+package audio2org
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscribe(t *testing.T) {
+	var path, model, project string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, model, project = r.URL.Path, r.FormValue("model"), r.Header.Get("OpenAI-Project")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Hello from the library.", "language": "english", "duration": 2.5}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "talk.mp3")
+	if err := os.WriteFile(audio, []byte("ID3 audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	transcript, err := Transcribe(context.Background(), TranscribeOptions{
+		AudioFile: audio,
+		APIKey:    "sk-test",
+		BaseURL:   server.URL + "/v1",
+		ProjectID: "proj_456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/audio/transcriptions" || model != "whisper-1" || project != "proj_456" {
+		t.Errorf("request to %s with model %q and OpenAI-Project %q", path, model, project)
+	}
+	if transcript.Text != "Hello from the library." || transcript.Duration != 2.5 {
+		t.Errorf("Transcribe() = %+v", transcript)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Transcribe() wrote files next to the audio: %v", entries)
+	}
+
+	if _, err := Transcribe(context.Background(), TranscribeOptions{APIKey: "sk-test"}); err == nil {
+		t.Error("Transcribe() without an AudioFile succeeded")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var body struct {
+		MaxTokens int                        `json:"max_tokens"`
+		Messages  []struct{ Content string } `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "#+title: Sync\n\n* Decisions\nShip it."}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	notes, err := Summarize(context.Background(), SummarizeOptions{
+		Transcript:    "We agreed to ship it on Friday.",
+		APIKey:        "sk-test",
+		BaseURL:       server.URL + "/v1",
+		DetailLevel:   "brief",
+		RecordingDate: time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#+title: Sync\n#+date: <2024-03-05 Tue>"; !strings.HasPrefix(notes, want) {
+		t.Errorf("Summarize() =\n%s\nwant it to start with\n%s", notes, want)
+	}
+	if body.MaxTokens != 1500 {
+		t.Errorf("max_tokens = %d, want the brief level's 1500", body.MaxTokens)
+	}
+	var prompts []string
+	for _, message := range body.Messages {
+		prompts = append(prompts, message.Content)
+	}
+	if prompt := strings.Join(prompts, "\n"); !strings.Contains(prompt, "Keep the notes brief.") || !strings.Contains(prompt, "ship it on Friday") {
+		t.Errorf("prompt does not ask for brief notes of the transcript:\n%s", prompt)
+	}
+	if _, err := os.Stat("transcript_emacs_org_notes.org"); err == nil {
+		os.Remove("transcript_emacs_org_notes.org")
+		t.Error("Summarize() wrote the notes to a file")
+	}
+
+	if _, err := Summarize(context.Background(), SummarizeOptions{Command: "create_chapters", APIKey: "sk-test"}); err == nil {
+		t.Error("Summarize() with create_chapters succeeded")
+	}
+}
+
+func TestTranscribeLongVocabPromptInChunks(t *testing.T) {
+	fakeChunkTools(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prompts = append(prompts, r.FormValue("prompt"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"text": "Chunk %d talks about tachycardia."}`, len(prompts))
+	}))
+	defer server.Close()
+
+	audio := filepath.Join(t.TempDir(), "talk.mp3")
+	writeSparseAudio(t, audio, 30<<20)
+	transcript, err := Transcribe(context.Background(), TranscribeOptions{
+		AudioFile:   audio,
+		APIKey:      "sk-test",
+		BaseURL:     server.URL + "/v1",
+		VocabPrompt: strings.Repeat("Okonkwo tachycardia ", 60),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) < 2 || !strings.HasPrefix(transcript.Text, "Chunk 1") {
+		t.Fatalf("Transcribe() sent %d requests and returned %q, want the audio in chunks", len(prompts), transcript.Text)
+	}
+	for i, prompt := range prompts {
+		if !strings.HasPrefix(prompt, "Okonkwo tachycardia") || len(prompt) > maxPromptTailChars+len(" ") {
+			t.Errorf("chunk %d prompt is %d characters, want the vocabulary prompt cut to fit %d", i+1, len(prompt), maxPromptTailChars)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package audio2org
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var logLevels = map[string]slog.Level{
+	"error": slog.LevelError,
+	"warn":  slog.LevelWarn,
+	"info":  slog.LevelInfo,
+	"debug": slog.LevelDebug,
+}
+
+// lineOrientedLogs is set when every log record must stay on its own line,
+// as with JSON or debug output or the interleaved lines of a -concurrency
+// batch, so the progress spinner is not drawn.
+var lineOrientedLogs bool
+
+// Headers whose values are replaced in debug output.
+var redactedHeaders = []string{"Authorization", "Api-Key", "X-Api-Key", "X-Amz-Security-Token", "Cookie"}
+
+// setupLogging sends the log package's output through a slog handler at
+// -log-level, as text in the usual log format or as JSON lines. With
+// -log-file, the records are appended to the file instead and only errors
+// are also written to stderr.
+func setupLogging(config Config) error {
+	level, ok := logLevels[config.LogLevel]
+	if !ok {
+		return fmt.Errorf("unknown -log-level %q: expected error, warn, info, or debug", config.LogLevel)
+	}
+	if config.LogFormat != "text" && config.LogFormat != "json" {
+		return fmt.Errorf("unknown -log-format %q: expected text or json", config.LogFormat)
+	}
+
+	handler := logHandler(config, os.Stderr, level)
+	if config.LogFile != "" {
+		file, err := os.OpenFile(config.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("opening -log-file: %w", err)
+		}
+		handler = errorTeeHandler{
+			Handler: logHandler(config, file, level),
+			stderr:  logHandler(config, os.Stderr, max(level, slog.LevelError)),
+		}
+	}
+	lineOrientedLogs = config.LogFormat == "json" || level == slog.LevelDebug || config.LogFile != "" || config.Concurrency > 1
+
+	slog.SetDefault(slog.New(handler))
+	log.SetFlags(0)
+	log.SetOutput(levelWriter{handler})
+	return nil
+}
+
+// logHandler writes records at level to w in the -log-format.
+func logHandler(config Config, w io.Writer, level slog.Level) slog.Handler {
+	if config.LogFormat == "text" {
+		return &logLineHandler{w: w, level: level, mu: &sync.Mutex{}}
+	}
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+}
+
+// errorTeeHandler sends every record to the -log-file handler and errors
+// to stderr as well, so a failed unattended run still says why.
+type errorTeeHandler struct {
+	slog.Handler
+	stderr slog.Handler
+}
+
+func (h errorTeeHandler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.Handler.Handle(ctx, r)
+	if h.stderr.Enabled(ctx, r.Level) {
+		if stderrErr := h.stderr.Handle(ctx, r.Clone()); err == nil {
+			err = stderrErr
+		}
+	}
+	return err
+}
+
+func (h errorTeeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return errorTeeHandler{Handler: h.Handler.WithAttrs(attrs), stderr: h.stderr.WithAttrs(attrs)}
+}
+
+func (h errorTeeHandler) WithGroup(name string) slog.Handler {
+	return errorTeeHandler{Handler: h.Handler.WithGroup(name), stderr: h.stderr.WithGroup(name)}
+}
+
+// levelWriter turns each line written by the log package into a slog
+// record. Lines starting with "Warning" or "Error" get those levels, and
+// the rest are info.
+type levelWriter struct {
+	handler slog.Handler
+}
+
+func (w levelWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSuffix(string(p), "\n")
+	level := slog.LevelInfo
+	switch {
+	case strings.HasPrefix(message, "Warning"):
+		level = slog.LevelWarn
+	case strings.HasPrefix(message, "Error"):
+		level = slog.LevelError
+	}
+
+	ctx := context.Background()
+	if !w.handler.Enabled(ctx, level) {
+		return len(p), nil
+	}
+	if err := w.handler.Handle(ctx, slog.NewRecord(time.Now(), level, message, 0)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logLineHandler writes records in the log package's format, with debug
+// records marked and attributes appended as key=value.
+type logLineHandler struct {
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+	mu    *sync.Mutex
+}
+
+func (h *logLineHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *logLineHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	if r.Level == slog.LevelDebug {
+		b.WriteString("Debug: ")
+	}
+	b.WriteString(r.Message)
+	writeAttr := func(attr slog.Attr) bool {
+		value := attr.Value.String()
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", attr.Key, value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	r.Attrs(writeAttr)
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *logLineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not used by this tool, so group names are dropped.
+func (h *logLineHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// logExchange logs each HTTP request and its response at debug level, with
+// credentials redacted. The uploaded audio is left out; form fields are
+// logged instead.
+func logExchange(_ *resty.Client, resp *resty.Response) error {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return nil
+	}
+
+	req := resp.Request
+	attrs := []any{
+		"method", req.Method,
+		"url", req.URL,
+		"status", resp.StatusCode(),
+		"duration", resp.Time().Round(time.Millisecond).String(),
+	}
+	if req.RawRequest != nil {
+		attrs = append(attrs, "request_headers", formatHeaders(redactHeaders(req.RawRequest.Header)))
+	}
+	upload, isUpload := requestUpload(req)
+	switch {
+	case isUpload:
+		attrs = append(attrs, "request_form", upload.fields.Encode())
+	case len(req.FormData) > 0:
+		attrs = append(attrs, "request_form", req.FormData.Encode())
+	case req.Body != nil:
+		if body, err := json.Marshal(req.Body); err == nil {
+			attrs = append(attrs, "request_body", string(body))
+		}
+	}
+	attrs = append(attrs, "response_body", string(resp.Body()))
+	slog.Debug("HTTP exchange", attrs...)
+	return nil
+}
+
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if values := redacted.Values(name); len(values) > 0 {
+			scheme, _, found := strings.Cut(values[0], " ")
+			if found && name == "Authorization" {
+				redacted.Set(name, scheme+" [REDACTED]")
+			} else {
+				redacted.Set(name, "[REDACTED]")
+			}
+		}
+	}
+	return redacted
+}
+
+func formatHeaders(header http.Header) string {
+	var parts []string
+	for name, values := range header {
+		parts = append(parts, name+": "+strings.Join(values, ", "))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, "; ")
+}
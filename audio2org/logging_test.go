@@ -0,0 +1,111 @@
+package audio2org
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLevelWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := levelWriter{&logLineHandler{w: &out, level: slog.LevelWarn, mu: &sync.Mutex{}}}
+
+	for _, line := range []string{"Reading audio file: talk.mp3\n", "Warning: the file is 24 MB\n", "Error: request failed\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := out.String()
+	if strings.Contains(got, "Reading audio file") {
+		t.Errorf("info line logged at -log-level warn:\n%s", got)
+	}
+	if !strings.Contains(got, " Warning: the file is 24 MB\n") || !strings.Contains(got, " Error: request failed\n") {
+		t.Errorf("warning or error missing:\n%s", got)
+	}
+}
+
+func TestLevelWriterJSON(t *testing.T) {
+	var out bytes.Buffer
+	w := levelWriter{slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo})}
+	w.Write([]byte("Warning: retrying\n"))
+
+	var record struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, out.String())
+	}
+	if record.Level != "WARN" || record.Msg != "Warning: retrying" {
+		t.Errorf("record = %+v, want a WARN record", record)
+	}
+}
+
+func TestErrorTeeHandler(t *testing.T) {
+	var file, stderr bytes.Buffer
+	w := levelWriter{errorTeeHandler{
+		Handler: &logLineHandler{w: &file, level: slog.LevelInfo, mu: &sync.Mutex{}},
+		stderr:  &logLineHandler{w: &stderr, level: slog.LevelError, mu: &sync.Mutex{}},
+	}}
+	for _, line := range []string{"Reading audio file: talk.mp3\n", "Warning: the file is 24 MB\n", "Error: request failed\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if lines := strings.Split(strings.TrimSpace(file.String()), "\n"); len(lines) != 3 {
+		t.Errorf("log file =\n%s\nwant all three lines", file.String())
+	}
+	if got := stderr.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, " Error: request failed\n") {
+		t.Errorf("stderr =\n%s\nwant only the error", got)
+	}
+}
+
+func TestSetupLoggingLogFile(t *testing.T) {
+	defer func(lineOriented bool) { lineOrientedLogs = lineOriented }(lineOrientedLogs)
+	defer log.SetFlags(log.Flags())
+	defer log.SetOutput(log.Writer())
+	defer slog.SetDefault(slog.Default())
+	path := filepath.Join(t.TempDir(), "audio2org.log")
+	if err := os.WriteFile(path, []byte("earlier run\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setupLogging(Config{LogLevel: "info", LogFormat: "text", LogFile: path}); err != nil {
+		t.Fatal(err)
+	}
+	log.Println("Reading audio file: talk.mp3")
+	got, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(got), "earlier run\n") || !strings.Contains(string(got), " Reading audio file: talk.mp3\n") {
+		t.Errorf("log file = %q, want the line appended", got)
+	}
+
+	err := setupLogging(Config{LogLevel: "info", LogFormat: "text", LogFile: filepath.Join(path, "nested.log")})
+	if err == nil || !strings.Contains(err.Error(), "-log-file") {
+		t.Errorf("setupLogging() with an unwritable -log-file = %v", err)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer sk-secret")
+	header.Set("Api-Key", "azure-secret")
+	header.Set("User-Agent", "go-audio2org/dev")
+
+	got := formatHeaders(redactHeaders(header))
+	want := "Api-Key: [REDACTED]; Authorization: Bearer [REDACTED]; User-Agent: go-audio2org/dev"
+	if got != want {
+		t.Errorf("redacted headers = %q, want %q", got, want)
+	}
+	if header.Get("Authorization") != "Bearer sk-secret" {
+		t.Error("redactHeaders() changed the request's own headers")
+	}
+}
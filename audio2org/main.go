@@ -0,0 +1,2106 @@
+package audio2org
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"flag"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/joho/godotenv"
+)
+
+const (
+	deterministicSeed  = 42
+	maxTitleSlugLength = 60
+)
+
+type Config struct {
+	AudioFilePath         string
+	TranscriptionFilePath string
+	OutputFileName        string
+	PostProcessCmd        string
+	OpenAIAPIKey          string
+	WrapWidth             int
+	Deterministic         bool
+	TrimSilence           bool
+	TitleFromContent      bool
+	Clock                 bool
+	InsecureSkipVerify    bool
+	CAFile                string
+	VAD                   bool
+	DebugBundleDir        string
+	Edit                  bool
+	StructuredOutput      bool
+	SpeakSummary          bool
+	NoCache               bool
+	CPUProfile            string
+	MemProfile            string
+	OutputURI             string
+	S3Endpoint            string
+	LinePrefix            string
+	EmacsLint             bool
+	ExamplesDir           string
+	NoEnv                 bool
+	EnvFile               string
+	Multilang             bool
+	UserAgent             string
+	Sample                time.Duration
+	InlineSummary         bool
+	SummarizerCmd         string
+	IndexDB               string
+	TranscriptStyle       string
+	QuietSuccess          bool
+	HeadingOffset         int
+	FormatCheck           bool
+	OrgDateStyle          string
+	SummaryLanguages      string
+	NoOutput              bool
+	Abstract              bool
+	Versioning            string
+	Info                  bool
+	MaxChunkMB            int
+	MaxTranscriptChars    int
+	WebhookURL            string
+	WebhookHeaders        headerFlags
+	MaxRetries            int
+	RetryBaseDelay        time.Duration
+	RetryLog              string
+	Resume                string
+	TranscribeModel       string
+	SummaryModel          string
+	MaxTokens             int
+	Temperature           float64
+	Format                string
+	Language              string
+	Compare               string
+	Open                  bool
+	OpenWith              string
+	AudioFiles            fileFlags
+	Concurrency           int
+	RetryOnGibberish      bool
+	GibberishThreshold    float64
+	Cards                 int
+	CardDifficulty        string
+	PromptTemplate        string
+	SystemPrompt          string
+	FallbackSummaryModel  string
+	SystemPromptFile      string
+	DryRun                bool
+	KeepRawResponse       bool
+	Extensions            string
+	StdinFormat           string
+	RedactPII             bool
+	RedactPIIChat         bool
+	BaseURL               string
+	AuthHeader            string
+	Bench                 bool
+	ShutdownGrace         time.Duration
+	OrgIndex              string
+	OutputDir             string
+	Timeout               time.Duration
+	ConnectTimeout        time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	Backend               string
+	WhisperCpp            string
+	WhisperModel          string
+	Timestamps            bool
+	VocabPrompt           string
+	VocabPromptFile       string
+	ConfigFile            string
+	Diarize               bool
+	RecordingDate         string
+	LogLevel              string
+	LogFormat             string
+	ClearCache            bool
+	Stdout                bool
+	Translate             bool
+	SummaryChunkTokens    int
+	Manifest              string
+	PrependMetadata       bool
+	WordTimestamps        bool
+	WhisperParams         paramFlags
+	Exec                  string
+	Transcode             bool
+	Version               bool
+	WhisperResponseFormat string
+	OrgPathTemplate       string
+	Stream                bool
+	StreamEcho            bool
+	MinTranscriptChars    int
+	FailOnEmpty           bool
+	Redact                bool
+	RedactPatterns        string
+	NoTranscriptFile      bool
+	KeepTemp              bool
+	Append                string
+	Bundle                bool
+	Overwrite             bool
+	APIKeyFile            string
+	Since                 string
+	RPM                   float64
+	Context               string
+	ContextFile           string
+	LogFile               string
+	AppendTranscript      bool
+	Check                 bool
+	UploadFilename        string
+	OrgTags               string
+	ExtractTodos          bool
+	Timings               bool
+	Unique                bool
+	DropLowConfidence     bool
+	NoSpeechThreshold     float64
+	LogprobThreshold      float64
+	Checksum              string
+	MaxDownloadMB         int
+	DetailLevel           string
+	OrgID                 string
+	ProjectID             string
+	MergeOutputs          string
+	RetryBudget           time.Duration
+	Limit                 int
+	KeepDuplicates        bool
+	Provider              string
+	KeepChunks            bool
+
+	promptTemplate *template.Template
+	// postPipeline is set on the copy of the config for each step when
+	// -post lists more than one command; see postStepConfigs.
+	postPipeline bool
+	// markdownNotes is set on the copy of the config for each step when
+	// the notes -post writes are Markdown; see writesMarkdownNotes.
+	markdownNotes bool
+	piiRules      []piiRule
+	usage         *runUsage
+	// stages holds the stage timings of the input, for -timings, the
+	// -format json result, and the -bundle metadata; guarded by benchMu.
+	stages map[string]stageStats
+	// stdoutOutputs holds the content of each file a -stdout run would
+	// have written, by path, until the main output is printed.
+	stdoutOutputs map[string]string
+	// transcript is the whole transcript, before -max-transcript-chars and
+	// -redact, for -append-transcript.
+	transcript string
+	// sourceURL is the -file URL the audio was downloaded from.
+	sourceURL string
+	// ctx cancels API requests and commands when the run is aborted; see
+	// handleShutdown.
+	ctx context.Context
+	// client is the HTTP client shared by the inputs of the run; see
+	// newHTTPClient.
+	client *resty.Client
+}
+
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Param   string `json:"param"`
+	Code    string `json:"code"`
+}
+
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+type OpenAIResponse struct {
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+			Refusal string `json:"refusal"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	// PromptFilterResults is sent by Azure OpenAI, whose content filter
+	// can reject a prompt without returning any choices.
+	PromptFilterResults []struct {
+		ContentFilterResults map[string]struct {
+			Filtered bool   `json:"filtered"`
+			Severity string `json:"severity"`
+		} `json:"content_filter_results"`
+	} `json:"prompt_filter_results"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+type TranscriptionResponse struct {
+	Text     string                 `json:"text"`
+	Language string                 `json:"language,omitempty"`
+	Duration float64                `json:"duration,omitempty"`
+	Segments []TranscriptionSegment `json:"segments,omitempty"`
+	Words    []TranscriptionWord    `json:"words,omitempty"`
+	// Subtitles is the SRT or VTT file Whisper returned for
+	// -whisper-response-format srt or vtt.
+	Subtitles string `json:"subtitles,omitempty"`
+}
+
+type TranscriptionSegment struct {
+	ID      int     `json:"id"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker,omitempty"`
+	// AvgLogprob and NoSpeechProb are Whisper's confidence in the
+	// segment, for -drop-low-confidence.
+	AvgLogprob   float64 `json:"avg_logprob,omitempty"`
+	NoSpeechProb float64 `json:"no_speech_prob,omitempty"`
+}
+
+var postCommands = []string{"create_emacs_org_notes", "create_markdown_notes", "create_glossary", "create_json_summary", "create_topic_org", "create_chapters", "create_org_transcript", "create_flashcards"}
+
+var transcribeModels = []string{"whisper-1", "gpt-4o-transcribe", "gpt-4o-mini-transcribe"}
+
+var transcriptStylePrompts = map[string]string{
+	"formal":   "Good afternoon, everyone. Today we will review the quarterly results, discuss the roadmap, and agree on next steps with Dr. Patel and Ms. Nguyen.",
+	"verbatim": "Um, so, like, I was, uh, thinking we could, you know, maybe start with the, um, the first item? Yeah. Okay, so, hmm.",
+}
+
+var languageCodePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]+)*$`)
+
+// whisperLanguages are the ISO-639-1 codes Whisper accepts as the language
+// of the audio.
+var whisperLanguages = []string{
+	"af", "ar", "az", "be", "bg", "bs", "ca", "cs", "cy", "da", "de", "el", "en", "es", "et", "fa",
+	"fi", "fr", "gl", "he", "hi", "hr", "hu", "hy", "id", "is", "it", "ja", "kk", "kn", "ko", "lt",
+	"lv", "mi", "mk", "mr", "ms", "ne", "nl", "no", "pl", "pt", "ro", "ru", "sk", "sl", "sr", "sv",
+	"sw", "ta", "th", "tl", "tr", "uk", "ur", "vi", "zh",
+}
+
+var segmentTimestampForm = map[string]string{
+	"response_format":           "verbose_json",
+	"timestamp_granularities[]": "segment",
+}
+
+// Main runs the command line tool on os.Args and exits with its status. The
+// go-audio2org command is only a call to it; programs embedding the tool
+// use Transcribe and Summarize instead.
+func Main() {
+	defer func() {
+		r := recover()
+		cleanupTempFiles()
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	config, err := parseFlags()
+	if err == nil && config.Version {
+		fmt.Println(versionString())
+		return
+	}
+	if err == nil {
+		err = setupLogging(config)
+	}
+	if err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(exitUsage)
+	}
+
+	if config.QuietSuccess && os.Getenv(quietChildEnv) == "" {
+		code, err := runQuietly(config.ShutdownGrace)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(code)
+	}
+
+	if err := run(config); err != nil {
+		cleanupTempFiles()
+		log.Printf("Error: %v", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// run does the whole job and returns the first error; Main is the only
+// place that exits.
+func run(config Config) error {
+	keepTempFiles = config.KeepTemp
+	if config.NoEnv {
+		log.Println("Skipping .env file (-no-env)")
+	} else if err := loadEnv(config.EnvFile); err != nil {
+		return err
+	}
+
+	if !isSetOnCommandLine("post") {
+		if post := os.Getenv("AUDIO2ORG_DEFAULT_POST"); post != "" {
+			if err := checkPostSteps("AUDIO2ORG_DEFAULT_POST", post); err != nil {
+				return err
+			}
+			config.PostProcessCmd = post
+		}
+	}
+
+	applyEnvOverrides(&config)
+	// Checked once the environment is loaded, since it supplies the API
+	// key and some of the flags, and before anything is read or written.
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+
+	apiLimiter = newRateLimiter(config.RPM)
+	// The inputs of a -concurrency batch share one client and its
+	// connections.
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	config.client = client
+
+	stopProfiling, err := startProfiling(config)
+	if err != nil {
+		return err
+	}
+	defer stopProfiling()
+
+	logProxy(config)
+	if config.Check {
+		return runCheck(config)
+	}
+
+	if config.ClearCache {
+		if err := clearCache(); err != nil {
+			return err
+		}
+		if len(config.AudioFiles) == 0 && config.TranscriptionFilePath == "" {
+			return nil
+		}
+	}
+
+	files, err := expandFileArgs(config.AudioFiles, parseExtensions(config.Extensions))
+	if err != nil {
+		return err
+	}
+	if config.Since != "" {
+		cutoff, err := parseSince(config.Since, time.Now())
+		if err != nil {
+			return err
+		}
+		if files, err = filterSince(files, cutoff); err != nil {
+			return err
+		}
+		// A nightly run over a folder with nothing new has nothing to do.
+		if len(files) == 0 {
+			log.Println("No -file inputs were modified since the -since cutoff; nothing to do")
+			return nil
+		}
+	}
+	if config.Limit > 0 {
+		files = limitFiles(files, config.Limit)
+	}
+
+	ctx, abort, stopShutdown := handleShutdown(config.ShutdownGrace)
+	defer stopShutdown()
+	config.ctx = abort
+
+	switch {
+	case config.Bench:
+		err = runBench(ctx, config, files)
+	case len(files) > 1 || config.Manifest != "":
+		err = runBatch(ctx, config, files)
+	default:
+		if len(files) == 1 {
+			config.AudioFilePath = files[0]
+		}
+		err = runInput(config)
+	}
+	err = cancelled(abort, err)
+
+	// Written even when some inputs failed, for the ones that finished.
+	if config.OrgIndex != "" {
+		if indexErr := writeOrgIndex(config, files); err == nil {
+			err = indexErr
+		}
+	}
+	if config.MergeOutputs != "" {
+		if mergeErr := writeMergedOutputs(config, files); err == nil {
+			err = mergeErr
+		}
+	}
+	return err
+}
+
+// runInput processes the single -file or -transcription input.
+func runInput(config Config) error {
+	var err error
+	// Nothing is written to disk; writeToFile keeps the content to print.
+	if config.Stdout {
+		config.NoOutput = true
+	}
+	// The chunk cache would keep the transcript on disk after all.
+	if config.NoTranscriptFile {
+		config.NoCache = true
+	}
+	if config.AudioFilePath == stdinPath {
+		path, err := spoolStdin(config.StdinFormat)
+		if err != nil {
+			return err
+		}
+		defer removeTempFile(path)
+		config.AudioFilePath = path
+		// The spooled file's name means nothing to the user.
+		if config.OutputFileName == "" && !config.TitleFromContent {
+			config.OutputFileName = "transcription" + transcriptExtension(config.Format)
+		}
+	}
+	if isAudioURL(config.AudioFilePath) {
+		path, err := downloadAudio(config)
+		if err != nil {
+			return err
+		}
+		defer removeTempFile(path)
+		config.sourceURL = config.AudioFilePath
+		config.AudioFilePath = path
+		// Name the outputs after the file in the URL, not the temp file.
+		if config.OutputFileName == "" && !config.TitleFromContent {
+			config.OutputFileName = audioOutputName(config, urlBaseName(config.sourceURL))
+		}
+	}
+
+	if config.Info {
+		return printAudioInfo(config)
+	}
+
+	if config.FormatCheck {
+		if !printCheckResults(formatCheck(config)) {
+			return errors.New("-format-check found problems with the audio file")
+		}
+		return nil
+	}
+
+	if config.DryRun {
+		return printCostEstimates([]Config{config})
+	}
+
+	if needsAPIKey(config) {
+		if config.OpenAIAPIKey, err = apiKey(config); err != nil {
+			return err
+		}
+		warnAPIKeyFormat(config, config.OpenAIAPIKey)
+		log.Printf("Using the API key from %s\n", apiKeySource(config))
+	}
+	writeDebugConfig(config)
+	config.usage = &runUsage{}
+	config.stages = map[string]stageStats{}
+	if config.Stdout {
+		config.stdoutOutputs = map[string]string{}
+	}
+
+	if config.VocabPrompt, err = loadVocabPrompt(config); err != nil {
+		return err
+	}
+	if config.Redact || config.RedactPII {
+		if config.piiRules, err = loadPIIRules(config.RedactPatterns); err != nil {
+			return err
+		}
+	}
+	if config.SystemPrompt, err = loadSystemPrompt(config); err != nil {
+		return err
+	}
+	if config.Context, err = loadPromptContext(config); err != nil {
+		return err
+	}
+	if config.PromptTemplate != "" {
+		if config.promptTemplate, err = loadPromptTemplate(config.PromptTemplate); err != nil {
+			return err
+		}
+	}
+	// Only warn: the API may accept codes added after this list.
+	if config.Language != "" && !slices.Contains(whisperLanguages, config.Language) {
+		log.Printf("Warning: -language %q is not an ISO-639-1 code Whisper is known to support; sending it anyway\n", config.Language)
+	}
+
+	if config.Sample > 0 {
+		return sampleTranscription(config)
+	}
+
+	if config.Compare != "" {
+		models, err := compareModels(config.Compare)
+		if err != nil {
+			return err
+		}
+		return compareTranscriptions(config, models)
+	}
+
+	if config.Resume != "" {
+		source := config.AudioFilePath
+		if source == "" {
+			source = config.TranscriptionFilePath
+		}
+		if err := loadResumeState(config.Resume, source); err != nil {
+			return err
+		}
+	}
+
+	// With -bundle, this input's outputs all go in a directory of their
+	// own, under fixed names.
+	if config.Bundle {
+		if config.OutputDir, err = createBundleDir(config); err != nil {
+			return err
+		}
+		if config.OutputFileName == "" && !config.TitleFromContent {
+			config.OutputFileName = "transcript" + transcriptExtension(config.Format)
+		}
+		config.Versioning = "overwrite"
+	}
+	started := time.Now()
+	timings := map[string]time.Duration{}
+
+	stopStage := timeStage(config, "transcribe")
+	transcription, outputFilePath, err := processTranscription(config)
+	stopStage()
+	if err != nil {
+		return err
+	}
+	timings["transcribe"] = time.Since(started)
+	recordBenchFile(config, transcription)
+	transcriptionText := transcription.Text
+	// Only verbose_json reports the duration; ffprobe fills in for the rest.
+	audioDuration := time.Duration(transcription.Duration * float64(time.Second))
+	if audioDuration == 0 && config.AudioFilePath != "" && requireFFprobe("the audio duration") == nil {
+		audioDuration, _ = probeDuration(config.AudioFilePath)
+	}
+	if audioDuration > 0 {
+		log.Printf("Audio duration: %s\n", audioDuration.Round(time.Second))
+	}
+
+	if config.Edit && config.OutputURI != "" {
+		log.Println("Skipping -edit: the transcript was uploaded to object storage")
+	} else if config.Edit && config.NoOutput && config.AudioFilePath != "" {
+		log.Println("Skipping -edit: no transcript file is written with -no-output")
+	} else if config.Edit && isSubtitleFormat(config.Format) && config.AudioFilePath != "" {
+		log.Printf("Skipping -edit: the transcript file is written as %s subtitles\n", config.Format)
+	} else if config.Edit && config.Format == "json" && config.AudioFilePath != "" {
+		log.Println("Skipping -edit: the transcript file is written as JSON")
+	} else if config.Edit {
+		editPath := outputFilePath
+		if config.AudioFilePath == "" {
+			editPath = config.TranscriptionFilePath
+		}
+		transcriptionText, err = editTranscript(editPath, transcriptionText)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Nothing is sent to the chat API for a muted recording; the outputs
+	// are the transcript alone.
+	if skip, err := checkTranscriptLength(config, transcriptionText); err != nil {
+		return err
+	} else if skip {
+		config.PostProcessCmd, config.InlineSummary, config.SpeakSummary = "", false, false
+	}
+
+	if !config.NoOutput {
+		if err := checkOutputCollisions(config, outputFilePath); err != nil {
+			return err
+		}
+	}
+
+	postText, postTranscription := transcriptionText, transcription
+	if config.MaxTranscriptChars > 0 && len(transcriptionText) > config.MaxTranscriptChars {
+		log.Printf("Warning: the transcript is %d characters; only the first %d are used for post-processing (-max-transcript-chars)\n",
+			len(transcriptionText), config.MaxTranscriptChars)
+		postText = truncateText(transcriptionText, config.MaxTranscriptChars)
+		postTranscription.Segments = truncateSegments(transcription.Segments, config.MaxTranscriptChars)
+	}
+	if len(postSteps(config)) > 0 || config.InlineSummary {
+		postText, postTranscription = redactForChat(config, postText, postTranscription)
+	}
+
+	config.transcript = transcriptionText
+
+	// The steps run in order and the first failure stops the run; the
+	// outputs after the steps are given the first step's output.
+	var postOutput string
+	postStarted := time.Now()
+	for i, step := range postStepConfigs(config) {
+		output, err := runPostStep(step, transcription, postText, postTranscription, outputFilePath)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			postOutput = output
+		}
+	}
+	if len(postSteps(config)) > 0 {
+		timings["post_process"] = time.Since(postStarted)
+	}
+
+	if config.Format == "json" && config.AudioFilePath != "" && len(postSteps(config)) > 0 && !config.NoTranscriptFile {
+		content, err := formatResult(config, transcription, primaryOutput(config, outputFilePath))
+		if err != nil {
+			return err
+		}
+		if err := writeToFile(config, outputFilePath, content); err != nil {
+			return err
+		}
+	}
+
+	if config.InlineSummary {
+		if err := createInlineSummary(config, postTranscription.Segments, outputFilePath); err != nil {
+			return err
+		}
+	}
+
+	if config.Exec != "" {
+		data := execTemplateData{OutputPath: primaryOutput(config, outputFilePath), TranscriptPath: outputFilePath}
+		if !transcriptOnDisk(config) {
+			data.TranscriptPath = ""
+		}
+		if err := runExec(config, data); err != nil {
+			return err
+		}
+	}
+
+	if config.IndexDB != "" {
+		if err := indexRun(config, transcription, outputFilePath, postOutput); err != nil {
+			return err
+		}
+	}
+
+	if config.WebhookURL != "" {
+		if err := postWebhook(config, transcription, outputFilePath, postOutput); err != nil {
+			return err
+		}
+	}
+
+	recordOrgIndexEntry(config, transcription, outputFilePath, postOutput)
+
+	if config.SpeakSummary {
+		speakSummary(config, transcriptionText, outputFilePath)
+	}
+
+	if config.Open {
+		if config.NoOutput || config.OutputURI != "" {
+			log.Println("Skipping -open: no local output file was written")
+		} else {
+			openOutput(config, primaryOutput(config, outputFilePath))
+		}
+	}
+
+	if config.Bundle {
+		timings["total"] = time.Since(started)
+		if err := finishBundle(config, audioDuration, timings); err != nil {
+			return err
+		}
+	}
+
+	if config.Timings {
+		log.Println(formatStageTimings(config, time.Since(started)))
+	}
+	log.Println(usageSummary(config, config.usage, audioDuration))
+
+	if config.Stdout {
+		return printStdoutOutput(os.Stdout, config, outputFilePath, transcriptionText)
+	}
+	return nil
+}
+
+// runPostStep runs the one -post command of config, or returns its output
+// recorded in the -resume state by an earlier run.
+func runPostStep(config Config, transcription TranscriptionResponse, postText string, postTranscription TranscriptionResponse, outputFilePath string) (string, error) {
+	if output, ok := resumedOutput(config.PostProcessCmd); ok {
+		log.Printf("Skipping %s: already done according to %s\n", config.PostProcessCmd, config.Resume)
+		return output, nil
+	}
+
+	var postOutput string
+	var err error
+	stopStage := timeStage(config, config.PostProcessCmd)
+	switch config.PostProcessCmd {
+	case "create_emacs_org_notes":
+		postOutput, err = createEmacsOrgNotes(config, postText, outputFilePath)
+	case "create_markdown_notes":
+		postOutput, err = createMarkdownNotes(config, postText, outputFilePath)
+	case "create_glossary":
+		postOutput, err = createGlossary(config, postText, outputFilePath)
+	case "create_json_summary":
+		postOutput, err = createJSONSummary(config, postText, outputFilePath)
+	case "create_topic_org":
+		postOutput, err = createTopicOrg(config, postText, outputFilePath)
+	case "create_chapters":
+		postOutput, err = createChapters(config, postTranscription, outputFilePath)
+	case "create_org_transcript":
+		// No API call is made, so the full transcript is used regardless
+		// of -max-transcript-chars.
+		postOutput, err = createOrgTranscript(config, transcription, outputFilePath)
+	case "create_flashcards":
+		postOutput, err = createFlashcards(config, postText, outputFilePath)
+	}
+	stopStage()
+	if err != nil {
+		return "", err
+	}
+	return postOutput, recordOutput(config.PostProcessCmd, postOutput)
+}
+
+func parseFlags() (Config, error) {
+	config := Config{}
+	defineFlags(flag.CommandLine, &config)
+
+	flag.Parse()
+	if config.ConfigFile != "" && !config.Version {
+		applied, err := applyConfigFile(flag.CommandLine, config.ConfigFile)
+		if err != nil {
+			return config, err
+		}
+		for _, name := range applied {
+			configFileFlags[name] = true
+		}
+	}
+	// Subtitles from Whisper are the transcript file, so they pick its
+	// format unless -format says otherwise.
+	if isSubtitleFormat(config.WhisperResponseFormat) && !isFlagSet("format") {
+		config.Format = config.WhisperResponseFormat
+	}
+	if !isFlagSet("max-tokens") {
+		config.MaxTokens = notesDetail(config.DetailLevel).maxTokens
+	}
+	return config, nil
+}
+
+// defineFlags registers every flag on fs, each storing into its config
+// field.
+func defineFlags(fs *flag.FlagSet, config *Config) {
+	fs.BoolVar(&config.Version, "version", false, "Print the version, git commit, and build date, and exit (optional)")
+	fs.StringVar(&config.LogLevel, "log-level", "info", "Log verbosity: error, warn, info, or debug, which also logs every HTTP request and response with credentials redacted (optional)")
+	fs.StringVar(&config.LogFormat, "log-format", "text", "Log format: text, or json for one JSON object per line (optional)")
+	fs.StringVar(&config.LogFile, "log-file", "", "Append the log to this file instead of stderr, which then only gets errors (optional)")
+	fs.BoolVar(&config.Check, "check", false, "Check the connection, proxy, TLS, and API key with a request to the models endpoint, and exit (optional)")
+	fs.StringVar(&config.ConfigFile, "config", "", "TOML file of flag values to use when they are not given on the command line (optional)")
+	fs.Var(&config.AudioFiles, "file", "Audio file, glob, or directory of audio files to transcribe; repeat for a batch (required)")
+	fs.StringVar(&config.StdinFormat, "stdin-format", "", "Format of the audio piped in with -file -, e.g. mp3 or wav (required with -file -)")
+	fs.StringVar(&config.Checksum, "checksum", "", "SHA-256 the audio downloaded from a -file URL must match, as hex or sha256:<hex> (optional)")
+	fs.IntVar(&config.MaxDownloadMB, "max-download-mb", 2048, "Largest audio file to download from a -file URL, in MB (optional)")
+	fs.StringVar(&config.UploadFilename, "upload-filename", "", "File name to send with the audio instead of the input's base name, e.g. audio.m4a; Whisper reads the format from its extension (optional)")
+	fs.StringVar(&config.Since, "since", "", "Only process -file inputs modified after this date, e.g. 2024-03-05, or this long ago, e.g. 24h or 7d (optional)")
+	fs.StringVar(&config.Extensions, "ext", "", "Comma-separated extensions, e.g. m4a,mp3, to pick from -file directories and globs instead of every supported format (optional)")
+	fs.BoolVar(&config.Bench, "bench", false, "Process the -file inputs and print files per minute, audio duration processed, and the average time of each stage (optional)")
+	fs.BoolVar(&config.Timings, "timings", false, "Log how long each stage of every input took, such as the Whisper and chat requests and writing the outputs (optional)")
+	fs.IntVar(&config.Concurrency, "concurrency", 1, "Number of batch files to process at the same time (optional)")
+	fs.Float64Var(&config.RPM, "rpm", 0, "Send at most this many API requests a minute, spaced evenly and shared by -concurrency, to stay under the account's rate limit; 0 is no limit (optional)")
+	fs.DurationVar(&config.ShutdownGrace, "shutdown-grace", 25*time.Second, "How long work in progress may run after SIGINT or SIGTERM before the run is stopped (optional)")
+	fs.StringVar(&config.Manifest, "manifest", "", "JSON file tracking each -file input as pending, done, or failed; a rerun skips inputs done with unchanged content (optional)")
+	fs.StringVar(&config.Exec, "exec", "", "Command to run once the outputs are written, with {{.OutputPath}} and {{.TranscriptPath}} substituted; run without a shell (optional)")
+	fs.StringVar(&config.OrgIndex, "org-index", "", "Write an org table linking the notes of every -file input, with title, date, and duration, to this file (optional)")
+	fs.StringVar(&config.TranscriptionFilePath, "transcription", "", "Path to the existing transcription file (optional)")
+	fs.StringVar(&config.OutputFileName, "output", "", "Name of the output transcription file (optional)")
+	fs.StringVar(&config.OutputDir, "output-dir", "output", "Directory to write the transcript and post-processing outputs to, created if missing (optional)")
+	fs.StringVar(&config.Format, "format", "text", "Transcript file format: text, srt or vtt subtitles with segment timestamps, or json with the run's metadata (optional)")
+	fs.StringVar(&config.Versioning, "versioning", "", "How outputs are named across runs: overwrite, timestamp, or increment (default: timestamp new transcripts, keep reprocessed outputs' names and refuse to replace existing files)")
+	fs.BoolVar(&config.Overwrite, "overwrite", false, "Replace output files that already exist, the same as -versioning overwrite (optional)")
+	fs.BoolVar(&config.Unique, "unique", false, "Write under the next free _v2, _v3, ... name when an output already exists, the same as -versioning increment (optional)")
+	fs.BoolVar(&config.Timestamps, "timestamps", false, "Start each segment of the text transcript on its own line after its [HH:MM:SS] start time (optional)")
+	fs.BoolVar(&config.DropLowConfidence, "drop-low-confidence", false, "Drop the segments Whisper likely invented during silence, by -no-speech-threshold and -logprob-threshold, before the text is assembled (optional)")
+	fs.Float64Var(&config.NoSpeechThreshold, "no-speech-threshold", 0.6, "With -drop-low-confidence, drop segments whose no_speech_prob is above this (optional)")
+	fs.Float64Var(&config.LogprobThreshold, "logprob-threshold", -1.0, "With -drop-low-confidence, drop segments whose avg_logprob is below this (optional)")
+	fs.BoolVar(&config.WordTimestamps, "word-timestamps", false, "Also write <name>_words.json, every word of the transcript with its start and end in seconds (optional)")
+	fs.StringVar(&config.LinePrefix, "line-prefix", "", "String to prepend to every non-empty line of the transcript file (optional)")
+	fs.BoolVar(&config.PrependMetadata, "prepend-metadata", false, "Start the text transcript with a --- fenced block of its source, model, language, and creation time (optional)")
+	fs.BoolVar(&config.Stdout, "stdout", false, "Print the notes, or the transcript without -post, to stdout instead of writing files; logs stay on stderr (optional)")
+	fs.BoolVar(&config.Bundle, "bundle", false, "Write each input's transcript, notes, a copy of the audio, and metadata.json into a run_<timestamp> directory of its own in -output-dir (optional)")
+	fs.StringVar(&config.Append, "append", "", "Add the org notes as a new top-level heading at the end of this org file, created if missing, instead of writing a notes file per input (optional)")
+	fs.BoolVar(&config.KeepTemp, "keep-temp", false, "Keep the temp files made for uploads, such as transcoded audio and chunks, and log their paths (optional)")
+	fs.BoolVar(&config.KeepChunks, "keep-chunks", false, "Keep the per-chunk transcriptions of a file over -max-chunk-mb after the run succeeds; they are always kept after a failure so a rerun skips them (optional)")
+	fs.BoolVar(&config.NoTranscriptFile, "no-transcript-file", false, "Keep the transcript of -file in memory and write only the post-processing outputs (optional)")
+	fs.BoolVar(&config.NoOutput, "no-output", false, "Do not write transcript or notes files; still transcribe, post-process, and run -index-db and -speak-summary (optional)")
+	fs.StringVar(&config.OutputURI, "output-uri", "", "Upload outputs to object storage, e.g. s3://bucket/prefix, instead of writing local files (optional)")
+	fs.StringVar(&config.S3Endpoint, "s3-endpoint", "", "Endpoint URL for S3-compatible storage such as MinIO, using path-style addressing (optional)")
+	fs.StringVar(&config.PostProcessCmd, "post", "", "Post-processing command, or comma-separated commands run in order, after transcription, overriding AUDIO2ORG_DEFAULT_POST (optional)")
+	fs.IntVar(&config.MinTranscriptChars, "min-transcript-chars", 1, "Skip post-processing when the transcript has fewer characters than this (optional)")
+	fs.BoolVar(&config.FailOnEmpty, "fail-on-empty", false, "Fail instead of warning when the transcript is shorter than -min-transcript-chars (optional)")
+	fs.BoolVar(&config.Redact, "redact", false, "Apply the -redact-pii patterns to the text sent to the chat API only, keeping the transcript file as transcribed (optional)")
+	fs.StringVar(&config.RedactPatterns, "redact-patterns", "", "File of extra regular expressions for -redact-pii and -redact to replace with [REDACTED], one per line (optional)")
+	fs.BoolVar(&config.Stream, "stream", false, "Stream chat completions instead of waiting for the whole response (optional)")
+	fs.BoolVar(&config.StreamEcho, "stream-echo", false, "With -stream, echo the generated text to stderr as it arrives (optional)")
+	fs.StringVar(&config.OrgPathTemplate, "org-path-template", "", "Go template for the org notes path, e.g. ~/org/{{.Year}}/{{.Month}}/{{.BaseName}}.org, with .Date, .Year, .Month, .Day, .BaseName, .Dir, and .Language (optional)")
+	fs.StringVar(&config.SummaryLanguages, "summary-languages", "", "Comma-separated language codes, e.g. en,es, to write one set of org notes per language (optional)")
+	fs.BoolVar(&config.StructuredOutput, "structured-output", false, "Use strict JSON schema structured outputs for create_json_summary (optional)")
+	fs.IntVar(&config.Cards, "cards", 20, "Number of flashcards for create_flashcards to write (optional)")
+	fs.StringVar(&config.CardDifficulty, "card-difficulty", "intermediate", "Difficulty of the create_flashcards questions: basic, intermediate, or advanced (optional)")
+	fs.BoolVar(&config.SpeakSummary, "speak-summary", false, "Speak a short status line via the TTS API when the run finishes (optional)")
+	fs.BoolVar(&config.InlineSummary, "inline-summary", false, "Write the transcript to org with summary bullets as comments by each section (optional)")
+	fs.BoolVar(&config.Edit, "edit", false, "Open the transcript in $EDITOR, or vi or nano, before post-processing (optional)")
+	fs.StringVar(&config.UserAgent, "user-agent", "go-audio2org/"+version, "User-Agent header sent with API requests (optional)")
+	fs.StringVar(&config.BaseURL, "base-url", defaultBaseURL, "Base URL of the OpenAI-compatible API, for gateways and Azure; OPENAI_BASE_URL overrides it (optional)")
+	fs.StringVar(&config.APIKeyFile, "api-key-file", "", "File holding the API key, read instead of OPENAI_API_KEY_FILE or OPENAI_API_KEY (optional)")
+	fs.StringVar(&config.OrgID, "org-id", "", "OpenAI organization ID to send as OpenAI-Organization; OPENAI_ORG_ID overrides it (optional)")
+	fs.StringVar(&config.ProjectID, "project-id", "", "OpenAI project ID to send as OpenAI-Project; OPENAI_PROJECT_ID overrides it (optional)")
+	fs.BoolVar(&config.KeepDuplicates, "keep-duplicates", false, "In a batch, also process inputs with the same audio as another input, which are skipped by default (optional)")
+	fs.IntVar(&config.Limit, "limit", 0, "Process at most this many -file inputs, the first ones after -since, e.g. to try settings on part of a directory; 0 for all (optional)")
+	fs.StringVar(&config.MergeOutputs, "merge-outputs", "", "Also write the notes of every -file input, each under a heading naming the input, in the order given, to this one file (optional)")
+	fs.StringVar(&config.Provider, "provider", "openai", "Preset for the chat API's quirks: openai, or compatible to drop the seed, n, and stream_options fields and send a charset for servers that reject them (optional)")
+	fs.StringVar(&config.AuthHeader, "auth-header", "bearer", "How the API key is sent: bearer (Authorization: Bearer) or api-key (Azure) (optional)")
+	fs.DurationVar(&config.Timeout, "timeout", 10*time.Minute, "Limit on each API request as a whole, including the time spent transcribing (optional)")
+	fs.DurationVar(&config.ConnectTimeout, "connect-timeout", 10*time.Second, "Limit on connecting to the API (optional)")
+	fs.DurationVar(&config.TLSHandshakeTimeout, "tls-handshake-timeout", 10*time.Second, "Limit on the TLS handshake with the API (optional)")
+	fs.DurationVar(&config.ResponseHeaderTimeout, "response-header-timeout", 0, "Limit on waiting for the response headers after a request is sent, 0 leaves it to -timeout (optional)")
+	fs.BoolVar(&config.InsecureSkipVerify, "insecure-skip-verify", false, "Disable TLS certificate verification (unsafe, for testing gateways only)")
+	fs.StringVar(&config.CAFile, "ca-file", "", "PEM file with additional CA certificates to trust (optional)")
+	fs.BoolVar(&config.EmacsLint, "emacs-lint", false, "Check written org files with org-lint via emacs --batch (optional)")
+	fs.StringVar(&config.RecordingDate, "recording-date", "", "Date of the recording as YYYY-MM-DD for the #+date: line of the org notes, instead of the audio file's modification time (optional)")
+	fs.StringVar(&config.OrgDateStyle, "org-date-style", "active", "Style of the #+date: line in the org notes: active, inactive, or iso (optional)")
+	fs.BoolVar(&config.Abstract, "abstract", false, "Require a 2-3 sentence #+subtitle: abstract at the top of the org notes (optional)")
+	fs.StringVar(&config.OrgTags, "org-tags", "", "Comma-separated tags for the org notes' #+filetags: line, e.g. meeting,apollo; include auto to add topic tags chosen by the model (optional)")
+	fs.BoolVar(&config.ExtractTodos, "extract-todos", false, "Add the action items to the org notes as TODO headings, with SCHEDULED: dates when the content gives them (optional)")
+	fs.BoolVar(&config.Clock, "clock", false, "Add a :LOGBOOK: CLOCK entry spanning the recording to the org notes (optional)")
+	fs.BoolVar(&config.TitleFromContent, "title-from-content", false, "Name output files after a short title generated from the transcript (optional)")
+	fs.BoolVar(&config.Info, "info", false, "Print the -file input's duration, codec, sample rate, channels, bitrate, and size via ffprobe, and exit (optional)")
+	fs.BoolVar(&config.DryRun, "dry-run", false, "Print the estimated API cost of the run and exit without calling any API (optional)")
+	fs.BoolVar(&config.FormatCheck, "format-check", false, "Check that the -file input is ready to transcribe, print a summary, and exit without calling the API (optional)")
+	fs.DurationVar(&config.Sample, "sample", 0, "Transcribe only this much of the start of the audio, e.g. 1m, print it, and exit (optional)")
+	fs.BoolVar(&config.Multilang, "multilang", false, "Transcribe in short chunks with per-chunk language detection for code-switched audio (optional)")
+	fs.StringVar(&config.Backend, "backend", "openai", "Transcription backend: openai, or local to run whisper.cpp on this machine (optional)")
+	fs.StringVar(&config.WhisperCpp, "whisper-cpp", "whisper-cli", "whisper.cpp binary that -backend local runs (optional)")
+	fs.BoolVar(&config.Diarize, "diarize", false, "Label each segment with its speaker, e.g. Speaker 1:, with -backend local and a tinydiarize model (optional)")
+	fs.StringVar(&config.WhisperModel, "whisper-model", "", "Path to the whisper.cpp ggml model file (required with -backend local)")
+	fs.StringVar(&config.TranscribeModel, "transcribe-model", "whisper-1", "Transcription model: "+strings.Join(transcribeModels, ", ")+" (optional)")
+	fs.BoolVar(&config.Translate, "translate", false, "Translate the speech into English with Whisper's translations endpoint instead of transcribing it (optional)")
+	fs.StringVar(&config.Language, "language", "", "ISO-639-1 code of the spoken language, e.g. en, instead of auto-detecting it (optional)")
+	fs.StringVar(&config.Compare, "compare", "", "Transcribe with two models, e.g. whisper-1,gpt-4o-transcribe, write both transcripts and a diff, and exit (optional)")
+	fs.StringVar(&config.TranscriptStyle, "transcript-style", "", "Whisper style preset: formal or verbatim (optional)")
+	fs.StringVar(&config.VocabPrompt, "vocab-prompt", "", "Names, acronyms, and jargon for Whisper to spell as written, e.g. \"Kubernetes, SRE, PagerDuty\" (optional)")
+	fs.StringVar(&config.VocabPromptFile, "vocab-prompt-file", "", "File to read the -vocab-prompt from (optional)")
+	fs.IntVar(&config.MaxChunkMB, "max-chunk-mb", 24, "Split audio files larger than this many MB into chunks at pauses and transcribe them in order (optional)")
+	fs.BoolVar(&config.VAD, "vad", false, "Transcribe only the speech regions detected with ffmpeg, with timestamps (optional)")
+	fs.BoolVar(&config.NoCache, "no-cache", false, "Always re-transcribe instead of reusing cached transcriptions of the same audio (optional)")
+	fs.BoolVar(&config.ClearCache, "clear-cache", false, "Remove every cached transcription before the run, or just clear the cache without -file or -transcription (optional)")
+	fs.BoolVar(&config.Transcode, "transcode", false, "Convert a -file input in a format Whisper does not accept to MP3 with ffmpeg before uploading it (optional)")
+	fs.BoolVar(&config.TrimSilence, "trim-silence", false, "Strip leading and trailing silence with ffmpeg before uploading (optional)")
+	fs.StringVar(&config.SummaryModel, "summary-model", "gpt-4o", "Chat model for the -post commands, -title-from-content, -inline-summary, and -redact-pii-chat (optional)")
+	fs.StringVar(&config.FallbackSummaryModel, "fallback-summary-model", "", "Chat model to send a -summary-model request to when it still fails with 429, for a rate limit or exhausted quota, after its retries (optional)")
+	fs.IntVar(&config.SummaryChunkTokens, "summary-chunk-tokens", 0, "Summarize transcripts longer than this many tokens in parts of this size first, then the parts into the notes; 0 sends the whole transcript (optional)")
+	fs.IntVar(&config.MaxTokens, "max-tokens", 3000, "Maximum tokens in the create_emacs_org_notes and create_markdown_notes responses; the -detail-level sets it when not given (optional)")
+	fs.StringVar(&config.DetailLevel, "detail-level", "normal", "Length of the create_emacs_org_notes and create_markdown_notes notes: brief, normal, or detailed (optional)")
+	fs.Float64Var(&config.Temperature, "temperature", 0.7, "Sampling temperature for create_emacs_org_notes and create_markdown_notes, 0.0 to 2.0 (optional)")
+	fs.StringVar(&config.SummarizerCmd, "summarizer-cmd", "", "External command that reads the notes prompt on stdin and writes the notes to stdout, instead of the OpenAI API (optional)")
+	fs.StringVar(&config.ExamplesDir, "examples-dir", "", "Directory of <name>.txt/<name>.org (or <name>.md) pairs to include as few-shot examples for notes (optional)")
+	fs.StringVar(&config.SystemPrompt, "system-prompt", "", "System prompt with the formatting rules for the org notes, sent before the transcript, replacing the built-in one (optional)")
+	fs.StringVar(&config.SystemPromptFile, "system-prompt-file", "", "File to read the -system-prompt from (optional)")
+	fs.BoolVar(&config.AppendTranscript, "append-transcript", false, "Add the whole transcript to the end of the org notes under a Full Transcript heading, for searching (optional)")
+	fs.StringVar(&config.Context, "context", "", "Standing background sent with every org or Markdown notes request, such as the project and its terms, kept apart from the prompt (optional)")
+	fs.StringVar(&config.ContextFile, "context-file", "", "File to read the -context from (optional)")
+	fs.StringVar(&config.PromptTemplate, "prompt-template", "", "Go text/template file to use as the org notes prompt, with {{.Transcription}} for the transcript (optional)")
+	fs.BoolVar(&config.KeepRawResponse, "keep-raw-response", false, "Save the full JSON chat response behind each post-processing output as <name>_chat_response.json (optional)")
+	fs.BoolVar(&config.Deterministic, "deterministic", false, "Use temperature 0 and a fixed seed for reproducible notes (optional)")
+	fs.BoolVar(&config.Open, "open", false, "Open the notes, or the transcript without -post, when the run succeeds (optional)")
+	fs.StringVar(&config.OpenWith, "open-with", "", "Command to open the output with for -open, e.g. emacsclient -n, instead of the platform default (optional)")
+	fs.BoolVar(&config.QuietSuccess, "quiet-success", false, "Print nothing on success and the full log only if the run fails (optional)")
+	fs.BoolVar(&config.NoEnv, "no-env", false, "Do not load a .env file; use only the process environment (optional)")
+	fs.StringVar(&config.EnvFile, "env-file", "", "Load environment variables from this file instead of ./.env; it must exist (optional)")
+	fs.StringVar(&config.WebhookURL, "webhook-url", "", "POST the notes, transcript, and run metadata as JSON to this URL when the run finishes (optional)")
+	fs.StringVar(&config.WhisperResponseFormat, "whisper-response-format", "", "response_format to ask Whisper for: json, verbose_json, text, or srt or vtt subtitles written as the transcript file (optional)")
+	fs.Var(&config.WhisperParams, "whisper-param", "Extra form field to send with each transcription request, as key=value; repeatable, and the dedicated flags win (optional)")
+	fs.Var(&config.WebhookHeaders, "webhook-header", "Header to send with the webhook request, as \"Name: value\"; repeatable (optional)")
+	fs.StringVar(&config.Resume, "resume", "", "JSON file to record finished chunks and steps in, and to skip them when the run is restarted (optional)")
+	fs.IntVar(&config.MaxRetries, "max-retries", 3, "Retry API requests that fail with 429, a 5xx, or a network error this many times, 0 disables retries (optional)")
+	fs.DurationVar(&config.RetryBaseDelay, "retry-base-delay", time.Second, "Delay before the first retry, doubled with jitter for each further retry unless the API sends Retry-After (optional)")
+	fs.DurationVar(&config.RetryBudget, "retry-budget", 0, "Stop retrying a request once this much time has passed since its first attempt, even if -max-retries are left, 0 for no limit (optional)")
+	fs.StringVar(&config.RetryLog, "retry-log", "normal", "How much retry detail to log: quiet (only the final failure), normal (each retry), or verbose (each retry and its backoff) (optional)")
+	fs.BoolVar(&config.RetryOnGibberish, "retry-on-gibberish", false, "Transcribe audio again when the transcription repeats itself more than -gibberish-threshold (optional)")
+	fs.Float64Var(&config.GibberishThreshold, "gibberish-threshold", 0.5, "Fraction of repeated three-word phrases above which -retry-on-gibberish retries (optional)")
+	fs.StringVar(&config.IndexDB, "index-db", "", "SQLite database to record each run in for full-text search (optional)")
+	fs.StringVar(&config.CPUProfile, "cpuprofile", "", "Write a pprof CPU profile of the run to this file (optional)")
+	fs.StringVar(&config.MemProfile, "memprofile", "", "Write a pprof heap profile at the end of the run to this file (optional)")
+	fs.StringVar(&config.DebugBundleDir, "debug-bundle", "", "Directory to write prompts, redacted requests, raw responses, and config to (optional)")
+	fs.IntVar(&config.MaxTranscriptChars, "max-transcript-chars", 0, "Only post-process the first this many characters of the transcript to bound cost, 0 uses all of it (optional)")
+	fs.BoolVar(&config.RedactPII, "redact-pii", false, "Replace email addresses, card numbers, API keys, and phone numbers in the transcript with placeholders before it is saved or post-processed (optional)")
+	fs.BoolVar(&config.RedactPIIChat, "redact-pii-chat", false, "With -redact-pii, also have the chat model replace names and addresses (optional)")
+	fs.IntVar(&config.HeadingOffset, "heading-offset", 0, "Demote every generated org heading by this many levels, to nest the notes under a parent heading (optional)")
+	fs.IntVar(&config.WrapWidth, "wrap", 0, "Hard-wrap generated notes to this many columns, 0 disables wrapping (optional)")
+
+}
+
+// dotenvVars maps each variable whose value came from the .env file to that
+// file, so the API key's source can be logged.
+var dotenvVars = map[string]string{}
+
+// loadEnv fills in the variables from path, or from .env in the working
+// directory when path is empty, that are not already set. A missing .env is
+// only logged, but a missing -env-file is an error.
+func loadEnv(path string) error {
+	name := path
+	if name == "" {
+		name = ".env"
+		log.Println("Loading environment variables...")
+	} else {
+		log.Printf("Loading environment variables from %s...\n", name)
+	}
+	vars, err := godotenv.Read(name)
+	if err != nil {
+		if path != "" {
+			return fmt.Errorf("reading -env-file: %w", err)
+		}
+		log.Printf("No .env file found: %v\n", err)
+		return nil
+	}
+	for key, value := range vars {
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+		}
+		// A -quiet-success child inherits what the parent loaded, so a
+		// matching value is credited to the file too.
+		if os.Getenv(key) == value {
+			dotenvVars[key] = name
+		}
+	}
+	return nil
+}
+
+// isFlagSet reports whether the named flag was given on the command line
+// or in the -config file, even if it was set to its default value.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func checkInputs(config Config) error {
+	switch {
+	case len(audioInputs(config)) == 0 && config.TranscriptionFilePath == "":
+		// -check and -clear-cache also run on their own.
+		if config.Check || config.ClearCache {
+			return nil
+		}
+		return errors.New("the -file or -transcription argument is required")
+	case len(audioInputs(config)) > 0 && config.TranscriptionFilePath != "":
+		return errors.New("specify only one of -file or -transcription")
+	}
+	return nil
+}
+
+// audioInputs are the -file arguments as given, before directories and
+// globs are expanded, or the one input of a batch that is being processed.
+func audioInputs(config Config) []string {
+	if config.AudioFilePath != "" {
+		return []string{config.AudioFilePath}
+	}
+	return config.AudioFiles
+}
+
+// inputSource names the input in the index and webhook: the audio file,
+// stdin, or the existing transcription.
+func inputSource(config Config) string {
+	switch {
+	case config.StdinFormat != "":
+		return "stdin"
+	case config.sourceURL != "":
+		return redactURL(config.sourceURL)
+	case config.AudioFilePath != "":
+		return config.AudioFilePath
+	}
+	return config.TranscriptionFilePath
+}
+
+// segmentFeatures lists the requested features that need segment or word
+// timing, which only a verbose_json transcription provides.
+func segmentFeatures(config Config) []string {
+	var features []string
+	if config.InlineSummary {
+		features = append(features, "-inline-summary")
+	}
+	for _, step := range postSteps(config) {
+		switch step {
+		case "create_chapters", "create_org_transcript":
+			features = append(features, step)
+		}
+	}
+	if isSubtitleFormat(config.Format) && config.WhisperResponseFormat != config.Format {
+		features = append(features, "-format "+config.Format)
+	}
+	if config.Timestamps {
+		features = append(features, "-timestamps")
+	}
+	if config.Diarize {
+		features = append(features, "-diarize")
+	}
+	if config.WordTimestamps {
+		features = append(features, "-word-timestamps")
+	}
+	if config.DropLowConfidence {
+		features = append(features, "-drop-low-confidence")
+	}
+	return features
+}
+
+func needsSegments(config Config) bool {
+	return len(segmentFeatures(config)) > 0
+}
+
+func processTranscription(config Config) (TranscriptionResponse, string, error) {
+	var transcription TranscriptionResponse
+	var outputFilePath string
+	var err error
+
+	if resumed, path, ok := resumedTranscription(); ok {
+		log.Printf("Using the transcription recorded in %s\n", config.Resume)
+		return resumed, path, nil
+	}
+
+	if config.AudioFilePath != "" {
+		if err := checkAudioFile(config); err != nil {
+			return transcription, "", err
+		}
+
+		stopStage := timeStage(config, "prepare audio")
+		uploadPath, cleanup, err := uploadSource(config)
+		if err != nil {
+			return transcription, "", err
+		}
+		defer cleanup()
+		if config.TrimSilence {
+			uploadPath, err = trimSilence(uploadPath)
+			if err != nil {
+				return transcription, "", err
+			}
+			defer removeTempFile(uploadPath)
+		}
+		stopStage()
+
+		if config.VAD {
+			log.Println("Transcribing speech regions...")
+			transcription.Text, err = transcribeSpeechRegions(config, uploadPath)
+		} else if config.Multilang {
+			log.Println("Transcribing with per-chunk language detection...")
+			transcription.Text, err = transcribeMultilang(config, uploadPath)
+		} else {
+			transcription, err = transcribeFile(config, uploadPath)
+		}
+		var partial *partialTranscriptionError
+		if errors.As(err, &partial) {
+			if writeErr := writePartialTranscription(config, partial); writeErr != nil {
+				log.Printf("Warning: writing the partial transcript: %v\n", writeErr)
+			}
+		}
+		if err != nil {
+			return transcription, "", err
+		}
+		if config.DropLowConfidence {
+			transcription = dropLowConfidence(config, transcription)
+		}
+		if config.Diarize {
+			transcription.Text = formatSpeakerTurns(transcription.Segments)
+		}
+		if config.RedactPII {
+			if transcription, err = redactTranscription(config, transcription); err != nil {
+				return transcription, "", err
+			}
+		}
+
+		outputDir := config.OutputDir
+		if config.OutputURI == "" && !config.NoOutput {
+			if outputDir, err = createOutputDir(config.OutputDir); err != nil {
+				return transcription, "", err
+			}
+		}
+		outputFileName := config.OutputFileName
+		if outputFileName == "" {
+			outputFileName = audioOutputName(config, config.AudioFilePath)
+			if config.TitleFromContent && !transcriptTooShort(config, transcription.Text) {
+				slug, err := generateTitleSlug(config, transcription.Text)
+				if err != nil {
+					return transcription, "", err
+				}
+				outputFileName = slug + transcriptExtension(config.Format)
+			}
+		}
+		outputFilePath = versionOutputPath(config, filepath.Join(outputDir, outputFileName))
+		if err := checkExistingOutputs(config, outputFilePath); err != nil {
+			return transcription, "", err
+		}
+
+		content := prefixLines(transcription.Text, config.LinePrefix)
+		if config.Timestamps {
+			content = prefixLines(formatTimestampedText(transcription.Segments), config.LinePrefix)
+		} else if transcription.Subtitles != "" {
+			content = transcription.Subtitles
+		} else if isSubtitleFormat(config.Format) {
+			content = formatSubtitles(config.Format, transcription.Segments)
+		} else if config.Format == "json" {
+			if content, err = formatResult(config, transcription, ""); err != nil {
+				return transcription, "", err
+			}
+		}
+		if config.PrependMetadata {
+			content = metadataHeader(config, transcription, time.Now()) + content
+		}
+		if config.NoTranscriptFile {
+			log.Printf("Skipping write of %s (-no-transcript-file)\n", outputFilePath)
+		} else if err := writeToFile(config, outputFilePath, content); err != nil {
+			return transcription, "", err
+		}
+		if config.WordTimestamps {
+			if err := writeWordTimestamps(config, outputFilePath, transcription.Words); err != nil {
+				return transcription, "", err
+			}
+		}
+		if err := recordTranscription(transcription, outputFilePath); err != nil {
+			return transcription, "", err
+		}
+	} else if config.TranscriptionFilePath != "" {
+		transcription.Text, err = readExistingTranscription(config.TranscriptionFilePath)
+		if err != nil {
+			return transcription, "", err
+		}
+		if config.RedactPII {
+			if transcription, err = redactTranscription(config, transcription); err != nil {
+				return transcription, "", err
+			}
+		}
+		outputFilePath = config.TranscriptionFilePath
+		if config.TitleFromContent && !transcriptTooShort(config, transcription.Text) {
+			// Only used to name the notes; the existing transcript is left in place.
+			slug, err := generateTitleSlug(config, transcription.Text)
+			if err != nil {
+				return transcription, "", err
+			}
+			outputFilePath = filepath.Join(filepath.Dir(config.TranscriptionFilePath), slug+filepath.Ext(config.TranscriptionFilePath))
+		}
+		outputFilePath = versionOutputPath(config, outputFilePath)
+		if err := checkExistingOutputs(config, outputFilePath); err != nil {
+			return transcription, "", err
+		}
+		if config.RedactPII {
+			if err := writeToFile(config, redactedTranscriptPath(outputFilePath), transcription.Text); err != nil {
+				return transcription, "", err
+			}
+		}
+	}
+
+	return transcription, outputFilePath, nil
+}
+
+// transcribeFile uploads the whole file in one request, or in chunks when
+// it is over -max-chunk-mb.
+func transcribeFile(config Config, uploadPath string) (TranscriptionResponse, error) {
+	var extraForm map[string]string
+	if config.WordTimestamps {
+		extraForm = wordTimestampForm
+	} else if needsSegments(config) {
+		extraForm = segmentTimestampForm
+	}
+
+	info, err := os.Stat(uploadPath)
+	if err != nil {
+		return TranscriptionResponse{}, fmt.Errorf("reading audio file: %w", err)
+	}
+	if config.Backend != "local" && info.Size() > maxChunkBytes(config) {
+		return transcribeLargeAudio(config, uploadPath, info.Size(), extraForm)
+	}
+
+	log.Printf("Reading audio file: %s\n", uploadPath)
+	audio, err := os.Open(uploadPath)
+	if err != nil {
+		return TranscriptionResponse{}, fmt.Errorf("reading audio file: %w", err)
+	}
+	defer audio.Close()
+	log.Println("Transcribing audio file...")
+	return transcribeCached(config, uploadFileName(config, uploadPath), audio, extraForm)
+}
+
+// newHTTPClient returns a client whose -timeout bounds each request as a
+// whole, including the time the API spends transcribing, while connecting
+// and the TLS handshake fail fast after their own timeouts. Within run, the
+// one client built for the whole run is returned.
+func newHTTPClient(config Config) (*resty.Client, error) {
+	if config.client != nil {
+		return config.client, nil
+	}
+	client := resty.New()
+	client.SetTransport(newTransport(config))
+	client.SetTimeout(config.Timeout)
+	client.SetHeader("User-Agent", config.UserAgent)
+	client.OnAfterResponse(logExchange)
+	client.SetPreRequestHook(attachUpload)
+	configureRetries(client, config)
+	limitAPIRequests(client, config)
+
+	if config.InsecureSkipVerify || config.CAFile != "" {
+		tlsConfig, err := createTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		client.SetTLSClientConfig(tlsConfig)
+	}
+	return client, nil
+}
+
+func newTransport(config Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   config.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	return transport
+}
+
+func createTLSConfig(config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if config.InsecureSkipVerify {
+		log.Println("WARNING: TLS certificate verification is disabled, connections are open to interception")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if config.CAFile != "" {
+		pemBytes, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file: %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+func transcribeAudio(config Config, filePath string, audio io.ReadSeeker, extraForm map[string]string) (TranscriptionResponse, error) {
+	transcription, err := newTranscriber(config).Transcribe(filePath, audio, extraForm)
+	if err != nil || !config.RetryOnGibberish {
+		return transcription, err
+	}
+	return retryGibberish(config, filePath, audio, extraForm, transcription)
+}
+
+func sendTranscription(config Config, filePath string, audio io.ReadSeeker, extraForm map[string]string) (TranscriptionResponse, error) {
+	var transcriptionResp TranscriptionResponse
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return transcriptionResp, err
+	}
+
+	formData := config.WhisperParams.form()
+	formData["model"] = config.TranscribeModel
+	if prompt := whisperPrompt(config); prompt != "" {
+		formData["prompt"] = prompt
+	}
+	if config.Language != "" {
+		formData["language"] = config.Language
+	}
+	if config.WhisperResponseFormat != "" {
+		formData["response_format"] = config.WhisperResponseFormat
+	}
+	for key, value := range extraForm {
+		formData[key] = value
+	}
+
+	upload, err := newMultipartUpload(transcriptionFormValues(formData), multipartFileName(config, filePath), audio)
+	if err != nil {
+		return transcriptionResp, err
+	}
+
+	log.Println("Sending request to Whisper API...")
+	request := client.R().
+		SetContext(runContext(config)).
+		SetHeaders(apiHeaders(config, config.OpenAIAPIKey)).
+		SetError(&OpenAIErrorResponse{})
+	setUpload(request, upload)
+	if !isPlainResponseFormat(formData["response_format"]) {
+		expectJSON(request)
+	}
+
+	url := apiURL(config, transcriptionEndpoint(config), config.TranscribeModel)
+	stopProgress, stopStage := startProgress("Whisper API"), timeStage(config, "Whisper API request")
+	resp, err := request.Post(url)
+	stopStage()
+	stopProgress()
+	if err != nil {
+		return transcriptionResp, fmt.Errorf("sending request to Whisper API: %w", err)
+	}
+
+	saveDebugExchange(config, "transcription", url, map[string]interface{}{
+		"form":  formData,
+		"file":  multipartFileName(config, filePath),
+		"bytes": upload.size,
+	}, "", resp.Body())
+
+	if resp.IsError() {
+		return transcriptionResp, apiError("Whisper API", resp)
+	}
+
+	return parseTranscriptionBody(formData["response_format"], resp.Body())
+}
+
+// createOutputDir creates outputDir, along with any missing parents, unless
+// it already exists.
+func createOutputDir(outputDir string) (string, error) {
+	info, err := os.Stat(outputDir)
+	switch {
+	case err == nil && !info.IsDir():
+		return "", fmt.Errorf("-output-dir %s exists but is not a directory", outputDir)
+	case err == nil:
+		return outputDir, nil
+	case !os.IsNotExist(err):
+		return "", fmt.Errorf("checking output directory: %w", err)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("creating output directory: %w", err)
+	}
+	return outputDir, nil
+}
+
+// generateTimestampedFilePath appends the run time and a random suffix to
+// the name, e.g. talk_20240305_101500_3f9a1c.txt. The suffix keeps apart
+// inputs with the same name that start in the same second, which
+// -concurrency makes likely and no existence check can rule out.
+func generateTimestampedFilePath(outputDir, baseFileName string) string {
+	timestamp := time.Now().Format("20060102_150405")
+	suffix := make([]byte, 3)
+	// crypto/rand does not fail on the supported platforms; a zero suffix
+	// still leaves a valid name.
+	rand.Read(suffix)
+	ext := filepath.Ext(baseFileName)
+	name := baseFileName[:len(baseFileName)-len(ext)]
+	return filepath.Join(outputDir, fmt.Sprintf("%s_%s_%x%s", name, timestamp, suffix, ext))
+}
+
+func writeToFile(config Config, filePath, content string) error {
+	if config.Stdout {
+		config.stdoutOutputs[filePath] = content
+		return nil
+	}
+	if config.NoOutput {
+		log.Printf("Skipping write of %s (-no-output)\n", filePath)
+		return nil
+	}
+	defer timeStage(config, "write outputs")()
+
+	if isCloudURI(config.OutputURI) {
+		return uploadToS3(config, filePath, content)
+	}
+
+	if err := writeFileAtomic(filePath, []byte(content)); err != nil {
+		return fmt.Errorf("writing to file: %w", err)
+	}
+	log.Printf("Content successfully written to %s\n", filePath)
+	return nil
+}
+
+// writeFileAtomic writes data to a temp file next to filePath and renames
+// it into place, so a run killed mid-write, or a full disk, never leaves a
+// truncated file behind. An existing file keeps its permissions.
+func writeFileAtomic(filePath string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// truncateText cuts text to at most maxChars bytes, at the last word
+// boundary before the limit when there is one.
+func truncateText(text string, maxChars int) string {
+	if len(text) <= maxChars {
+		return text
+	}
+
+	// Back up to the start of a rune rather than re-validating the whole
+	// prefix, which is quadratic on text that is not valid UTF-8.
+	end := maxChars
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	cut := text[:end]
+	if i := strings.LastIndexAny(cut, " \n\t"); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
+
+// truncateSegments keeps the leading segments whose text fits in maxChars.
+func truncateSegments(segments []TranscriptionSegment, maxChars int) []TranscriptionSegment {
+	total := 0
+	for i, segment := range segments {
+		total += len(strings.TrimSpace(segment.Text)) + 1
+		if total > maxChars {
+			return segments[:i]
+		}
+	}
+	return segments
+}
+
+func prefixLines(text, prefix string) string {
+	if prefix == "" {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func readExistingTranscription(filePath string) (string, error) {
+	log.Printf("Reading existing transcription file: %s\n", filePath)
+
+	transcriptionBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("reading transcription file: %w", err)
+	}
+
+	return stripMetadataHeader(normalizeTranscript(filePath, transcriptionBytes)), nil
+}
+
+// normalizeTranscript cleans up what other tools leave in exported
+// transcripts: a leading UTF-8 byte order mark and CRLF line endings are
+// removed, and invalid UTF-8 is reported and replaced with U+FFFD rather
+// than reaching the prompt as mojibake.
+func normalizeTranscript(filePath string, data []byte) string {
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !utf8.ValidString(text) {
+		var b strings.Builder
+		invalid := 0
+		for i := 0; i < len(text); {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if r == utf8.RuneError && size == 1 {
+				invalid++
+			}
+			b.WriteRune(r)
+			i += size
+		}
+		log.Printf("Warning: %s is not valid UTF-8; replaced %d invalid byte(s) with U+FFFD. Re-export it as UTF-8 for accurate notes.\n", filePath, invalid)
+		text = b.String()
+	}
+	return text
+}
+
+func generateTitleSlug(config Config, transcriptionText string) (string, error) {
+	log.Println("Generating title from transcript...")
+
+	excerpt, _ := redactForChat(config, truncateText(transcriptionText, 8000), TranscriptionResponse{})
+
+	message := map[string]string{
+		"role":    "user",
+		"content": "Write a short, descriptive title of at most eight words for the following transcript. Respond with the title only, without quotes or punctuation at the end.\n\n" + excerpt,
+	}
+
+	reqBody := map[string]interface{}{
+		"model":       config.SummaryModel,
+		"messages":    []map[string]string{message},
+		"max_tokens":  30,
+		"temperature": 0.2,
+	}
+
+	title, err := sendChatRequest(config, reqBody, "")
+	if err != nil {
+		return "", fmt.Errorf("generating title: %w", err)
+	}
+	slug := slugify(title)
+	if slug == "" {
+		log.Println("Generated title was empty, falling back to the default file name")
+		return "transcription", nil
+	}
+	log.Printf("Using title: %s\n", slug)
+	return slug, nil
+}
+
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= maxTitleSlugLength {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+func createEmacsOrgNotes(config Config, transcriptionText, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_emacs_org_notes command...")
+
+	transcriptionText, err := condenseTranscript(config, transcriptionText)
+	if err != nil {
+		return "", err
+	}
+
+	languages := summaryLanguages(config)
+	if len(languages) == 0 {
+		return writeEmacsOrgNotes(config, transcriptionText, generateOrgFilePath(config, baseFilePath), "", chatResponsePath(config, baseFilePath, ""))
+	}
+
+	var first string
+	var paths []string
+	for _, language := range languages {
+		log.Printf("Generating org notes in %s...\n", language)
+		outputFilePath := generateOrgLanguageFilePath(config, baseFilePath, language)
+		orgContent, err := writeEmacsOrgNotes(config, transcriptionText, outputFilePath, language, chatResponsePath(config, baseFilePath, "_"+language))
+		if err != nil {
+			return "", fmt.Errorf("org notes in %s: %w", language, err)
+		}
+		if first == "" {
+			first = orgContent
+		}
+		paths = append(paths, outputFilePath)
+	}
+
+	log.Printf("Generated org notes in %d languages:\n  %s\n", len(paths), strings.Join(paths, "\n  "))
+	return first, nil
+}
+
+func writeEmacsOrgNotes(config Config, transcriptionText, outputFilePath, language, rawResponsePath string) (string, error) {
+	system := orgNotesSystemPrompt(config)
+	prompt, err := orgNotesPrompt(config, transcriptionText)
+	if err != nil {
+		return "", err
+	}
+	// The extra instructions go with the others: in the system prompt, or
+	// in the -prompt-template prompt when there is none.
+	instructions := &prompt
+	if system != "" {
+		instructions = &system
+	}
+	if config.Abstract {
+		*instructions += "\n\n" + abstractInstruction
+	}
+	fixedTags, autoTags := orgTags(config.OrgTags)
+	if autoTags {
+		*instructions += "\n\n" + autoTagsInstruction
+	}
+	if config.ExtractTodos {
+		*instructions += "\n\n" + todoInstruction
+	}
+	if language != "" {
+		*instructions += fmt.Sprintf("\n\nWrite the entire file, including the title and headings, in the language with the code %q, whatever language the content is in.", language)
+	}
+
+	messages, err := notesMessages(config, system, prompt, ".org", func(text string) (string, error) {
+		return orgNotesPrompt(config, text)
+	})
+	if err != nil {
+		return "", err
+	}
+	generate := func(messages []map[string]string) (string, error) {
+		content, err := completeNotes(config, messages, rawResponsePath)
+		return stripCodeFence(content), err
+	}
+
+	orgContent, err := generate(messages)
+	if err != nil {
+		return "", err
+	}
+	// A response with a preamble or no #+title: is asked for again once,
+	// with the bad response and a correction in the conversation.
+	if err := validateOrgNotes(orgContent); err != nil {
+		log.Printf("Retrying org notes: %v\n", err)
+		correction := append(messages[:len(messages):len(messages)],
+			map[string]string{"role": "assistant", "content": orgContent},
+			map[string]string{"role": "user", "content": orgCorrection})
+		if orgContent, err = generate(correction); err != nil {
+			return "", err
+		}
+		if err := validateOrgNotes(orgContent); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
+	}
+	if config.Abstract {
+		if err := validateAbstract(orgContent); err != nil {
+			log.Printf("Retrying org notes: %v\n", err)
+			if orgContent, err = generate(messages); err != nil {
+				return "", err
+			}
+			if err := validateAbstract(orgContent); err != nil {
+				log.Printf("Warning: %v\n", err)
+			}
+		}
+	}
+	orgContent = setOrgDate(orgContent, config.OrgDateStyle, recordingDate(config))
+	if config.OrgTags != "" {
+		orgContent = setOrgFiletags(orgContent, fixedTags)
+	}
+	if config.Clock {
+		if config.AudioFilePath == "" {
+			log.Println("Skipping -clock: recording time is only known when transcribing with -file")
+		} else {
+			clock, err := recordingClock(config)
+			if err != nil {
+				return "", err
+			}
+			orgContent = insertLogbook(orgContent, clock)
+		}
+	}
+	orgContent = shiftOrgHeadings(orgContent, config.HeadingOffset)
+	if config.WrapWidth > 0 {
+		orgContent = wrapText(orgContent, config.WrapWidth)
+	}
+	if config.AppendTranscript {
+		orgContent = appendTranscriptSection(orgContent, config.transcript, config.HeadingOffset, config.WrapWidth)
+	}
+
+	if config.Append != "" {
+		if err := appendOrgNotes(config, orgContent); err != nil {
+			return "", err
+		}
+	} else {
+		if err := createOrgPathDir(config, outputFilePath); err != nil {
+			return "", err
+		}
+		if err := writeToFile(config, outputFilePath, orgContent); err != nil {
+			return "", err
+		}
+	}
+
+	if config.EmacsLint {
+		lintOrgFile(config, outputFilePath)
+	}
+	return orgContent, nil
+}
+
+// generateNotes sends the notes prompt to -summary-model, or to
+// -summarizer-cmd, and returns the response.
+func generateNotes(config Config, system, prompt, extension, rawResponsePath string, examplePrompt func(string) (string, error)) (string, error) {
+	messages, err := notesMessages(config, system, prompt, extension, examplePrompt)
+	if err != nil {
+		return "", err
+	}
+	return completeNotes(config, messages, rawResponsePath)
+}
+
+// notesMessages builds the notes conversation. A non-empty system prompt
+// is sent first. With -examples-dir, the <name>.txt transcripts and their
+// <name><extension> notes are sent next, each transcript wrapped by
+// examplePrompt.
+func notesMessages(config Config, system, prompt, extension string, examplePrompt func(string) (string, error)) ([]map[string]string, error) {
+	var messages []map[string]string
+	if system != "" {
+		messages = append(messages, map[string]string{
+			"role":    "system",
+			"content": system,
+		})
+	}
+	if config.Context != "" {
+		messages = append(messages, contextMessage(config))
+	}
+	if config.ExamplesDir != "" {
+		examples, err := loadExampleMessages(config, extension, examplePrompt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, examples...)
+	}
+	messages = append(messages, map[string]string{
+		"role":    "user",
+		"content": prompt,
+	})
+	return messages, nil
+}
+
+// completeNotes sends the messages to -summary-model, or to
+// -summarizer-cmd, with the notes' token limit and temperature.
+func completeNotes(config Config, messages []map[string]string, rawResponsePath string) (string, error) {
+	reqBody := map[string]interface{}{
+		"model":       config.SummaryModel, // Ref: https://platform.openai.com/docs/models + https://openai.com/api/pricing/
+		"messages":    messages,
+		"max_tokens":  config.MaxTokens,
+		"temperature": config.Temperature,
+	}
+	if config.SummarizerCmd != "" {
+		return runSummarizerCmd(config, chatPromptText(reqBody))
+	}
+	return sendNotesRequest(config, reqBody, rawResponsePath)
+}
+
+// apiError describes a failed response by the type and message of
+// OpenAI's error body, which resty decodes into an OpenAIErrorResponse. A
+// body of another shape, such as a proxy's HTML error page, is shown as is
+// with the status.
+func apiError(api string, resp *resty.Response) error {
+	errorResponse, _ := resp.Error().(*OpenAIErrorResponse)
+	return openAIError(api, resp.StatusCode(), resp.Status(), errorResponse, resp.String())
+}
+
+func openAIError(api string, statusCode int, status string, errorResponse *OpenAIErrorResponse, body string) error {
+	if errorResponse == nil || errorResponse.Error.Message == "" {
+		return &apiStatusError{StatusCode: statusCode, err: fmt.Errorf("%s returned %s: %s", api, status, strings.TrimSpace(body))}
+	}
+
+	apiErr := errorResponse.Error
+	code := apiErr.Code
+	if code == "" {
+		code = apiErr.Type
+	}
+	kind := apiErr.Type
+	if apiErr.Code != "" && apiErr.Code != apiErr.Type {
+		kind = strings.TrimSpace(kind + " (" + apiErr.Code + ")")
+	}
+	err := fmt.Errorf("%s: %s: %s", api, kind, apiErr.Message)
+	if kind == "" {
+		err = fmt.Errorf("%s: %s", api, apiErr.Message)
+	}
+	return &apiStatusError{StatusCode: statusCode, Code: code, err: err}
+}
+
+// sendChatRequest returns the content of the first choice of the chat
+// completion, and saves the response body to rawResponsePath unless it is
+// empty.
+func sendChatRequest(config Config, reqBody map[string]interface{}, rawResponsePath string) (string, error) {
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return "", err
+	}
+
+	if config.Deterministic {
+		reqBody["temperature"] = 0
+		reqBody["seed"] = deterministicSeed
+	}
+
+	if config.Stream {
+		reqBody["stream"] = true
+		reqBody["stream_options"] = map[string]bool{"include_usage": true}
+	}
+
+	log.Println("Sending request to OpenAI API...")
+	model, _ := reqBody["model"].(string)
+	url := apiURL(config, "/chat/completions", model)
+	contentType := shapeChatRequest(config, reqBody)
+	stopProgress, stopStage := startProgress("OpenAI API"), timeStage(config, "OpenAI API request")
+	resp, err := client.R().
+		SetContext(runContext(config)).
+		SetHeaders(apiHeaders(config, config.OpenAIAPIKey)).
+		SetHeader("Content-Type", contentType).
+		SetBody(reqBody).
+		SetError(&OpenAIErrorResponse{}).
+		SetDoNotParseResponse(config.Stream).
+		Post(url)
+	// With -stream, Post returns once the headers are in, and the content
+	// is read, and echoed with -stream-echo, below.
+	stopProgress()
+	if err != nil {
+		stopStage()
+		return "", fmt.Errorf("sending request to OpenAI API: %w", err)
+	}
+
+	body := resp.Body()
+	if config.Stream {
+		body, err = streamedChatBody(config, resp)
+	}
+	stopStage()
+	saveDebugExchange(config, "chat", url, reqBody, chatPromptText(reqBody), body)
+	if err != nil {
+		return "", quotaFailure(resp, err)
+	}
+
+	if resp.IsError() {
+		return "", quotaFailure(resp, apiError("OpenAI API", resp))
+	}
+
+	if rawResponsePath != "" {
+		if err := writeToFile(config, rawResponsePath, string(body)); err != nil {
+			return "", err
+		}
+	}
+
+	log.Println("Parsing OpenAI API response...")
+	var aiResponse OpenAIResponse
+	if err := json.Unmarshal(body, &aiResponse); err != nil {
+		return "", fmt.Errorf("unmarshalling OpenAI response: %w", err)
+	}
+
+	if config.Deterministic {
+		log.Printf("System fingerprint: %s\n", aiResponse.SystemFingerprint)
+	}
+	config.usage.addChat(model, aiResponse.Usage)
+
+	return chatContent(config, aiResponse, reqBody)
+}
+
+// chatContent returns the message of the first choice, or an error saying
+// why there is none. A reply cut off at max_tokens is returned with a
+// warning, since the output may be truncated.
+func chatContent(config Config, aiResponse OpenAIResponse, reqBody map[string]interface{}) (string, error) {
+	if len(aiResponse.Choices) == 0 {
+		if filtered := filteredCategories(aiResponse); len(filtered) > 0 {
+			return "", fmt.Errorf("OpenAI API returned no choices: the prompt was blocked by the content filter (%s)", strings.Join(filtered, ", "))
+		}
+		return "", errors.New("OpenAI API returned no choices; rerun with -keep-raw-response or -log-level debug to see the response")
+	}
+
+	choice := aiResponse.Choices[0]
+	if choice.Message.Refusal != "" {
+		return "", fmt.Errorf("model refused: %s", choice.Message.Refusal)
+	}
+	switch choice.FinishReason {
+	case "content_filter":
+		return "", errors.New("the response was stopped by the content filter (finish_reason content_filter)")
+	case "length":
+		hint := ""
+		if reqBody["max_tokens"] == config.MaxTokens {
+			hint = "; raise -max-tokens or use -summary-chunk-tokens"
+		}
+		log.Printf("Warning: the response reached its max_tokens limit of %v (finish_reason length) and may be truncated%s\n", reqBody["max_tokens"], hint)
+	}
+	if strings.TrimSpace(choice.Message.Content) == "" {
+		return "", fmt.Errorf("OpenAI API returned an empty message (finish_reason %q)", choice.FinishReason)
+	}
+	return choice.Message.Content, nil
+}
+
+// filteredCategories lists the content filter categories, such as
+// "violence (medium)", that blocked the prompt.
+func filteredCategories(aiResponse OpenAIResponse) []string {
+	var categories []string
+	for _, result := range aiResponse.PromptFilterResults {
+		for category, filter := range result.ContentFilterResults {
+			if filter.Filtered {
+				categories = append(categories, fmt.Sprintf("%s (%s)", category, filter.Severity))
+			}
+		}
+	}
+	slices.Sort(categories)
+	return categories
+}
+
+func createGlossary(config Config, transcriptionText, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_glossary command...")
+
+	message := map[string]string{
+		"role":    "user",
+		"content": createGlossaryPrompt(transcriptionText, config.markdownNotes),
+	}
+
+	reqBody := map[string]interface{}{
+		"model":       config.SummaryModel,
+		"messages":    []map[string]string{message},
+		"max_tokens":  3000,
+		"temperature": 0.3,
+	}
+
+	glossary, err := sendChatRequest(config, reqBody, chatResponsePath(config, baseFilePath, ""))
+	if err != nil {
+		return "", err
+	}
+	glossary = stripCodeFence(glossary)
+	if !config.markdownNotes {
+		glossary = shiftOrgHeadings(glossary, config.HeadingOffset)
+	}
+	if config.WrapWidth > 0 {
+		glossary = wrapText(glossary, config.WrapWidth)
+	}
+
+	outputFilePath := generateGlossaryFilePath(baseFilePath, config.markdownNotes)
+	if err := writeToFile(config, outputFilePath, glossary); err != nil {
+		return "", err
+	}
+
+	if config.EmacsLint && !config.markdownNotes {
+		lintOrgFile(config, outputFilePath)
+	}
+	return glossary, nil
+}
+
+func createTopicOrg(config Config, transcriptionText, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_topic_org command...")
+
+	message := map[string]string{
+		"role":    "user",
+		"content": createTopicPrompt(transcriptionText),
+	}
+
+	reqBody := map[string]interface{}{
+		"model":       config.SummaryModel,
+		"messages":    []map[string]string{message},
+		"max_tokens":  8000,
+		"temperature": 0.3,
+	}
+
+	topicOrg, err := sendChatRequest(config, reqBody, chatResponsePath(config, baseFilePath, ""))
+	if err != nil {
+		return "", err
+	}
+	topicOrg = shiftOrgHeadings(topicOrg, config.HeadingOffset)
+	if config.WrapWidth > 0 {
+		topicOrg = wrapText(topicOrg, config.WrapWidth)
+	}
+
+	outputFilePath := generateDerivedFilePath(baseFilePath, "_topics.org")
+	if err := writeToFile(config, outputFilePath, topicOrg); err != nil {
+		return "", err
+	}
+
+	if config.EmacsLint {
+		lintOrgFile(config, outputFilePath)
+	}
+	return topicOrg, nil
+}
+
+// chatResponsePath is where -keep-raw-response saves the chat response
+// behind an output, or "" when the flag is off.
+func chatResponsePath(config Config, baseFilePath, suffix string) string {
+	if !config.KeepRawResponse {
+		return ""
+	}
+	// Two -post steps would otherwise save to the same file.
+	if config.postPipeline {
+		suffix = "_" + strings.TrimPrefix(config.PostProcessCmd, "create_") + suffix
+	}
+	return generateDerivedFilePath(baseFilePath, suffix+"_chat_response.json")
+}
+
+func generateOrgFilePath(config Config, baseFilePath string) string {
+	if config.Append != "" {
+		return config.Append
+	}
+	if config.Bundle {
+		return filepath.Join(filepath.Dir(baseFilePath), "notes.org")
+	}
+	if path, ok := templatedOrgPath(config, baseFilePath, ""); ok {
+		return path
+	}
+	return generateDerivedFilePath(baseFilePath, "_emacs_org_notes.org")
+}
+
+func generateOrgLanguageFilePath(config Config, baseFilePath, language string) string {
+	if path, ok := templatedOrgPath(config, baseFilePath, language); ok {
+		return path
+	}
+	return generateDerivedFilePath(baseFilePath, "_emacs_org_notes_"+language+".org")
+}
+
+func summaryLanguages(config Config) []string {
+	var languages []string
+	for _, language := range strings.Split(config.SummaryLanguages, ",") {
+		if language = strings.TrimSpace(language); language != "" {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
+
+func generateDerivedFilePath(baseFilePath, suffix string) string {
+	dir := filepath.Dir(baseFilePath)
+	baseName := strings.TrimSuffix(filepath.Base(baseFilePath), filepath.Ext(baseFilePath))
+	return filepath.Join(dir, baseName+suffix)
+}
+
+// defaultOrgSystemPrompt is the create_emacs_org_notes system prompt
+// when -system-prompt is not given; the transcript is the user message.
+func defaultOrgSystemPrompt(date time.Time, level string) string {
+	recorded := orgPromptDate(date)
+	detail := notesDetail(level)
+
+	return fmt.Sprintf(`I need you to summarize the content in the user's message and convert it into an Emacs Org file format. Please do not include any extra commentary or explanations.
+
+%s
+
+The response should only contain the Emacs Org formatted output.
+
+Use the following structure:
+
+1. The file should have a #+title: and #+author: and #+date: header with the #+date: header as %s
+2. Include a "Summary" section that gives a brief overview of the key points, %s
+3. Include a "Notes" section, with **subsections** that organize the content logically. %s
+
+Please format the response as a valid Emacs Org file.`, detail.guidance, recorded, detail.summary, detail.note)
+}
+
+// generateGlossaryFilePath names the create_glossary output, in the format
+// of the notes.
+func generateGlossaryFilePath(baseFilePath string, markdown bool) string {
+	if markdown {
+		return generateDerivedFilePath(baseFilePath, "_glossary.md")
+	}
+	return generateDerivedFilePath(baseFilePath, "_glossary.org")
+}
+
+func createGlossaryPrompt(transcriptionText string, markdown bool) string {
+	if markdown {
+		return fmt.Sprintf(`Identify the domain-specific terms, jargon, acronyms, and proper names used in the following content and define each one in one or two sentences, based on how it is used in the content. Please do not include any extra commentary or explanations.
+
+The response should only contain the Markdown formatted output, using this structure:
+
+1. A single "# Glossary" heading.
+2. A bulleted list sorted alphabetically, one entry per term, formatted as "- **Term**: Definition".
+
+Here is the content:
+
+%s`, transcriptionText)
+	}
+	return fmt.Sprintf(`Identify the domain-specific terms, jargon, acronyms, and proper names used in the following content and define each one in one or two sentences, based on how it is used in the content. Please do not include any extra commentary or explanations.
+
+The response should only contain the Emacs Org formatted output, using this structure:
+
+1. A #+title: header naming the glossary.
+2. A single "Glossary" heading followed by an Org description list sorted alphabetically, one entry per term, formatted as "- Term :: Definition".
+
+Here is the content:
+
+%s`, transcriptionText)
+}
+
+func createTopicPrompt(transcriptionText string) string {
+	return fmt.Sprintf(`Reorganize the following transcript into an Emacs Org file grouped by topic. This is not a summary: keep nearly all of the original content and wording, only removing filler words, false starts, and repetition. Please do not include any extra commentary or explanations.
+
+Use the following structure:
+
+1. A #+title: header describing the transcript.
+2. One top-level "* Topic" heading per distinct topic, named after the topic, in the order the topics first come up. If a topic comes back later, place that content under its existing heading.
+3. Under each heading, the transcript content for that topic as plain paragraphs.
+
+Here is the transcript:
+
+%s`, transcriptionText)
+}
@@ -0,0 +1,500 @@
+package audio2org
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text     string
+		maxChars int
+		want     string
+	}{
+		{"short", 10, "short"},
+		{"alpha beta gamma", 12, "alpha beta"},
+		{"alpha beta gamma", 11, "alpha beta"},
+		{"unbroken", 4, "unbr"},
+		{"naïve café", 3, "na"},
+		{"日本語のテキスト", 7, "日本"},
+		{"\xff\xfe\xfd" + strings.Repeat("a", 20), 10, "\xff\xfe\xfdaaaaaaa"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateText(tt.text, tt.maxChars); got != tt.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.maxChars, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateTextIsLinearOnInvalidUTF8(t *testing.T) {
+	text := strings.Repeat("\xff", 1<<20)
+	if got := truncateText(text, len(text)-1); len(got) != len(text)-1 {
+		t.Errorf("truncateText() kept %d bytes, want %d", len(got), len(text)-1)
+	}
+}
+
+func TestCheckInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"audio file", Config{AudioFilePath: "talk.mp3"}, false},
+		{"existing transcript", Config{TranscriptionFilePath: "talk.txt"}, false},
+		{"neither", Config{}, true},
+		{"both", Config{AudioFilePath: "talk.mp3", TranscriptionFilePath: "talk.txt"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkInputs(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkInputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileErrorsAreReturned(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing", "talk.txt")
+
+	if _, err := readExistingTranscription(missing); err == nil {
+		t.Error("readExistingTranscription() of a missing file returned no error")
+	}
+	if err := writeToFile(Config{}, missing, "hello"); err == nil {
+		t.Error("writeToFile() into a missing directory returned no error")
+	}
+	if _, _, err := processTranscription(Config{TranscriptionFilePath: missing}); err == nil {
+		t.Error("processTranscription() of a missing transcript returned no error")
+	}
+}
+
+func TestCreateOutputDir(t *testing.T) {
+	dir := t.TempDir()
+
+	nested := filepath.Join(dir, "notes", "2024", "04")
+	if got, err := createOutputDir(nested); err != nil || got != nested {
+		t.Fatalf("createOutputDir(%q) = %q, %v", nested, got, err)
+	}
+	if info, err := os.Stat(nested); err != nil || !info.IsDir() {
+		t.Fatalf("createOutputDir() did not create %s: %v", nested, err)
+	}
+	if _, err := createOutputDir(nested); err != nil {
+		t.Errorf("createOutputDir() of an existing directory: %v", err)
+	}
+
+	file := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := createOutputDir(file); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("createOutputDir() of a file = %v, want a not a directory error", err)
+	}
+}
+
+func TestSendTranscription(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    TranscriptionResponse
+		wantErr string
+	}{
+		{
+			name:   "success",
+			status: http.StatusOK,
+			body:   `{"text": "Hello there.", "language": "english", "duration": 2.5}`,
+			want:   TranscriptionResponse{Text: "Hello there.", Language: "english", Duration: 2.5},
+		},
+		{
+			name:    "error status",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": {"message": "Incorrect API key provided", "type": "invalid_request_error"}}`,
+			wantErr: "Incorrect API key provided",
+		},
+		{
+			name:    "quota",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error": {"message": "You exceeded your current quota.", "type": "insufficient_quota", "code": "insufficient_quota"}}`,
+			wantErr: "Whisper API: insufficient_quota: You exceeded your current quota.",
+		},
+		{
+			name:    "malformed JSON",
+			status:  http.StatusOK,
+			body:    `{"text": "Hello`,
+			wantErr: "unmarshalling JSON response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, model, authorization, upload string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, model, authorization = r.URL.Path, r.FormValue("model"), r.Header.Get("Authorization")
+				if file, _, err := r.FormFile("file"); err == nil {
+					data, _ := io.ReadAll(file)
+					upload = string(data)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL + "/v1", TranscribeModel: "whisper-1", RetryLog: "quiet"}
+			got, err := sendTranscription(config, "talk.mp3", strings.NewReader("audio"), nil)
+
+			if path != "/v1/audio/transcriptions" || model != "whisper-1" || authorization != "Bearer test-key" || upload != "audio" {
+				t.Errorf("request to %s with model %q, Authorization %q, file %q", path, model, authorization, upload)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("sendTranscription() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Text != tt.want.Text || got.Language != tt.want.Language || got.Duration != tt.want.Duration {
+				t.Errorf("sendTranscription() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatContentTruncated(t *testing.T) {
+	var logs bytes.Buffer
+	captureLog(t, &logs)
+
+	var aiResponse OpenAIResponse
+	if err := json.Unmarshal([]byte(`{"choices": [{"message": {"content": "* Notes\n** Par"}, "finish_reason": "length"}]}`), &aiResponse); err != nil {
+		t.Fatal(err)
+	}
+	got, err := chatContent(Config{MaxTokens: 3000}, aiResponse, map[string]interface{}{"max_tokens": 3000})
+	if err != nil || got != "* Notes\n** Par" {
+		t.Errorf("chatContent() = %q, %v, want the partial notes", got, err)
+	}
+	if want := "limit of 3000 (finish_reason length) and may be truncated; raise -max-tokens"; !strings.Contains(logs.String(), want) {
+		t.Errorf("log = %q, want it to contain %q", logs.String(), want)
+	}
+}
+
+func TestSendChatRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "success",
+			status: http.StatusOK,
+			body:   `{"choices": [{"message": {"role": "assistant", "content": "* Notes"}}]}`,
+			want:   "* Notes",
+		},
+		{
+			name:    "error status",
+			status:  http.StatusBadRequest,
+			body:    `{"error": {"message": "maximum context length exceeded", "type": "invalid_request_error", "code": "context_length_exceeded"}}`,
+			wantErr: "OpenAI API: invalid_request_error (context_length_exceeded): maximum context length exceeded",
+		},
+		{
+			name:    "malformed error body",
+			status:  http.StatusBadGateway,
+			body:    `<html>Bad Gateway</html>`,
+			wantErr: "OpenAI API returned 502 Bad Gateway: <html>Bad Gateway</html>",
+		},
+		{
+			name:    "malformed JSON",
+			status:  http.StatusOK,
+			body:    `{"choices": [`,
+			wantErr: "unmarshalling OpenAI response",
+		},
+		{
+			name:    "refusal",
+			status:  http.StatusOK,
+			body:    `{"choices": [{"message": {"role": "assistant", "refusal": "I can't help with that."}}]}`,
+			wantErr: "model refused: I can't help with that.",
+		},
+		{
+			name:    "no choices",
+			status:  http.StatusOK,
+			body:    `{"choices": []}`,
+			wantErr: "OpenAI API returned no choices",
+		},
+		{
+			name:   "prompt blocked by the Azure content filter",
+			status: http.StatusOK,
+			body: `{"choices": [], "prompt_filter_results": [{"prompt_index": 0, "content_filter_results": {
+				"hate": {"filtered": false, "severity": "safe"}, "violence": {"filtered": true, "severity": "medium"}}}]}`,
+			wantErr: "blocked by the content filter (violence (medium))",
+		},
+		{
+			name:    "response stopped by the content filter",
+			status:  http.StatusOK,
+			body:    `{"choices": [{"message": {"role": "assistant", "content": "* Notes"}, "finish_reason": "content_filter"}]}`,
+			wantErr: "stopped by the content filter",
+		},
+		{
+			name:    "empty message",
+			status:  http.StatusOK,
+			body:    `{"choices": [{"message": {"role": "assistant", "content": ""}, "finish_reason": "stop"}]}`,
+			wantErr: `empty message (finish_reason "stop")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, contentType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL + "/v1", RetryLog: "quiet"}
+			got, err := sendChatRequest(config, map[string]interface{}{"model": "gpt-4o"}, "")
+
+			if path != "/v1/chat/completions" || contentType != "application/json" {
+				t.Errorf("request to %s with Content-Type %q", path, contentType)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("sendChatRequest() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("sendChatRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.org")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("* Notes\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "* Notes\n" {
+		t.Errorf("file = %q, want the new content", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want the existing 0600 kept", info.Mode().Perm())
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "notes.org"), []byte("x")); err == nil {
+		t.Error("writeFileAtomic() into a missing directory succeeded")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only notes.org without temp files", len(entries))
+	}
+}
+
+func TestRunInputExistingTranscript(t *testing.T) {
+	var paths []string
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var body struct {
+			Messages []struct{ Content string } `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if n := len(body.Messages); n > 0 {
+			prompt = body.Messages[n-1].Content
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "#+title: Sync\n\n* Decisions\nShip it."}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "recordings", "sync.txt")
+	if err := os.MkdirAll(filepath.Dir(transcript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(transcript, []byte("We agreed to ship it on Friday.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := validConfig()
+	config.TranscriptionFilePath = transcript
+	config.OutputDir = filepath.Join(dir, "output")
+	config.PostProcessCmd = "create_emacs_org_notes"
+	config.BaseURL = server.URL + "/v1"
+	config.RetryLog = "quiet"
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	if err := runInput(config); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/v1/chat/completions" {
+		t.Errorf("requests to %v, want only /v1/chat/completions", paths)
+	}
+	if !strings.Contains(prompt, "We agreed to ship it on Friday.") {
+		t.Errorf("prompt %q does not contain the transcript", prompt)
+	}
+	notes, err := os.ReadFile(filepath.Join(dir, "recordings", "sync_emacs_org_notes.org"))
+	if err != nil {
+		t.Fatalf("notes are not next to the transcript: %v", err)
+	}
+	if !strings.Contains(string(notes), "* Decisions") {
+		t.Errorf("notes = %q, want the model's output", notes)
+	}
+	if got, _ := os.ReadFile(transcript); string(got) != "We agreed to ship it on Friday.\n" {
+		t.Errorf("transcript was changed to %q", got)
+	}
+	if _, err := os.Stat(config.OutputDir); !os.IsNotExist(err) {
+		t.Errorf("-output-dir was created (%v), want nothing written there", err)
+	}
+}
+
+func TestNormalizeTranscript(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"clean", "Hello.\nBye.\n", "Hello.\nBye.\n"},
+		{"byte order mark", "\xef\xbb\xbfHello.\n", "Hello.\n"},
+		{"CRLF", "Hello.\r\nBye.\r\n", "Hello.\nBye.\n"},
+		{"lone CR kept", "a\rb", "a\rb"},
+		{"invalid bytes", "caf\xe9 \xff\xfeok", "caf\uFFFD \uFFFD\uFFFDok"},
+		{"BOM only at the start", "a\uFEFFb", "a\uFEFFb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeTranscript("talk.txt", []byte(tt.data)); got != tt.want {
+				t.Errorf("normalizeTranscript(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTimestampedFilePath(t *testing.T) {
+	pattern := regexp.MustCompile(`^output/talk_\d{8}_\d{6}_[0-9a-f]{6}\.txt$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		path := filepath.ToSlash(generateTimestampedFilePath("output", "talk.txt"))
+		if !pattern.MatchString(path) {
+			t.Fatalf("generateTimestampedFilePath() = %q, want talk_<date>_<time>_<suffix>.txt", path)
+		}
+		if seen[path] {
+			t.Fatalf("generateTimestampedFilePath() returned %q twice in the same second", path)
+		}
+		seen[path] = true
+	}
+}
+
+func TestChatRequestsUseSummaryModel(t *testing.T) {
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "friday-release"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "sync.txt")
+	if err := os.WriteFile(transcript, []byte(strings.Repeat("We agreed to ship it on Friday. ", 20)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := validConfig()
+	config.TranscriptionFilePath = transcript
+	config.PostProcessCmd = "create_glossary,create_topic_org"
+	config.TitleFromContent = true
+	config.SummaryModel = "gpt-4o-mini"
+	config.BaseURL = server.URL + "/v1"
+	config.RetryLog = "quiet"
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	if err := runInput(config); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(models, " "); got != "gpt-4o-mini gpt-4o-mini gpt-4o-mini" {
+		t.Errorf("models = %q, want -summary-model for the title, glossary, and topics", got)
+	}
+}
+
+func TestCreateGlossaryFollowsNotesFormat(t *testing.T) {
+	tests := []struct {
+		post       string
+		reply      string
+		wantPrompt string
+		wantFile   string
+		want       string
+	}{
+		{"create_glossary", "```org\n#+title: Glossary\n* Glossary\n- SVT :: A fast heart rhythm.\n```", "Emacs Org", "talk_glossary.org", "#+title: Glossary\n* Glossary\n- SVT :: A fast heart rhythm.\n"},
+		{"create_markdown_notes,create_glossary", "```markdown\n# Glossary\n\n- **SVT**: A fast heart rhythm.\n```", "Markdown", "talk_glossary.md", "# Glossary\n\n- **SVT**: A fast heart rhythm.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.post, func(t *testing.T) {
+			var prompt string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Messages []struct{ Content string }
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+				prompt = body.Messages[len(body.Messages)-1].Content
+				reply, _ := json.Marshal(tt.reply)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": ` + string(reply) + `}, "finish_reason": "stop"}]}`))
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			config := validConfig()
+			config.PostProcessCmd = tt.post
+			config.OpenAIAPIKey = "test-key"
+			config.BaseURL = server.URL
+			config.RetryLog = "quiet"
+			steps := postStepConfigs(config)
+			glossary, err := createGlossary(steps[len(steps)-1], "SVT came up twice.", filepath.Join(dir, "talk.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(prompt, tt.wantPrompt) {
+				t.Errorf("prompt does not ask for %s:\n%s", tt.wantPrompt, prompt)
+			}
+			if glossary != tt.want {
+				t.Errorf("createGlossary() = %q, want %q", glossary, tt.want)
+			}
+			written, err := os.ReadFile(filepath.Join(dir, tt.wantFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(written) != tt.want {
+				t.Errorf("%s = %q, want %q", tt.wantFile, written, tt.want)
+			}
+		})
+	}
+}
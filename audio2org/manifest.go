@@ -0,0 +1,176 @@
+package audio2org
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	manifestPending = "pending"
+	manifestDone    = "done"
+	manifestFailed  = "failed"
+)
+
+// errAlreadyDone is recorded for batch inputs the -manifest lists as done
+// with the same content.
+var errAlreadyDone = errors.New("already done according to -manifest")
+
+type manifestEntry struct {
+	Path      string    `json:"path"`
+	SHA256    string    `json:"sha256"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// batchManifest is the -manifest file: the status of each input of the
+// batch, keyed by path and content hash, so a restarted batch skips the
+// inputs that finished and redoes those that were edited since.
+type batchManifest struct {
+	mu      sync.Mutex
+	path    string
+	entries []manifestEntry
+	hashes  map[string]string
+}
+
+// loadManifest reads the -manifest file, or starts a new one, and marks
+// the inputs of this batch that are new or were edited since as pending.
+func loadManifest(path string, files []string) (*batchManifest, error) {
+	m := &batchManifest{path: path, hashes: map[string]string{}}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		log.Printf("Recording batch progress in %s\n", path)
+	case err != nil:
+		return nil, fmt.Errorf("reading -manifest: %w", err)
+	default:
+		if err := json.Unmarshal(data, &m.entries); err != nil {
+			return nil, fmt.Errorf("parsing -manifest %s: %w", path, err)
+		}
+	}
+
+	for _, file := range files {
+		hash, err := fileSHA256(file)
+		if err != nil {
+			return nil, err
+		}
+		m.hashes[file] = hash
+		if entry, ok := m.entry(file); !ok || entry.SHA256 != hash {
+			m.set(file, manifestPending, "")
+		}
+	}
+	return m, m.save()
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("hashing input for -manifest: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing input for -manifest: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// done reports whether the manifest lists file as done with its current
+// content.
+func (m *batchManifest) done(file string) bool {
+	if m == nil {
+		return false
+	}
+	entry, ok := m.entry(file)
+	return ok && entry.SHA256 == m.hashes[file] && entry.Status == manifestDone
+}
+
+// hash returns the content hash loadManifest recorded for file. A nil
+// manifest has none.
+func (m *batchManifest) hash(file string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	hash, ok := m.hashes[file]
+	return hash, ok
+}
+
+// doneWithHash returns an input other than file that the manifest lists
+// as done with content hash, such as the same recording under an earlier
+// name.
+func (m *batchManifest) doneWithHash(hash, file string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.entries {
+		if entry.Path != file && entry.SHA256 == hash && entry.Status == manifestDone {
+			return entry.Path, true
+		}
+	}
+	return "", false
+}
+
+func (m *batchManifest) entry(file string) (manifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.entries {
+		if entry.Path == file {
+			return entry, true
+		}
+	}
+	return manifestEntry{}, false
+}
+
+// record saves the outcome of processing file. A nil manifest records
+// nothing.
+func (m *batchManifest) record(file string, err error) error {
+	if m == nil {
+		return nil
+	}
+	switch {
+	case err == nil:
+		m.set(file, manifestDone, "")
+	case errors.Is(err, errNotStarted):
+		return nil
+	default:
+		m.set(file, manifestFailed, err.Error())
+	}
+	return m.save()
+}
+
+func (m *batchManifest) set(file, status, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := manifestEntry{Path: file, SHA256: m.hashes[file], Status: status, Error: message, UpdatedAt: time.Now().UTC().Truncate(time.Second)}
+	for i := range m.entries {
+		if m.entries[i].Path == file {
+			m.entries[i] = entry
+			return
+		}
+	}
+	m.entries = append(m.entries, entry)
+}
+
+func (m *batchManifest) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding -manifest: %w", err)
+	}
+	if err := writeFileAtomic(m.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("writing -manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package audio2org
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+	a, b, c := filepath.Join(dir, "a.mp3"), filepath.Join(dir, "b.mp3"), filepath.Join(dir, "c.mp3")
+	for _, file := range []string{a, b, c} {
+		if err := os.WriteFile(file, []byte("audio of "+filepath.Base(file)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := loadManifest(path, []string{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range []struct {
+		file string
+		err  error
+	}{{a, nil}, {b, errors.New("Whisper API: server_error: try again")}, {c, errNotStarted}} {
+		if err := m.record(record.file, record.err); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A restart skips the finished input until its content changes.
+	m, err = loadManifest(path, []string{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.done(a) || m.done(b) || m.done(c) {
+		t.Errorf("after restart, done = %v %v %v, want only a.mp3", m.done(a), m.done(b), m.done(c))
+	}
+	for _, entry := range m.entries {
+		if entry.Path == b && entry.Status != manifestFailed {
+			t.Errorf("b.mp3 status = %q, want %q kept", entry.Status, manifestFailed)
+		}
+		if entry.Path == c && entry.Status != manifestPending {
+			t.Errorf("c.mp3 status = %q, want %q", entry.Status, manifestPending)
+		}
+	}
+
+	if err := os.WriteFile(a, []byte("edited audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = loadManifest(path, []string{a}); err != nil {
+		t.Fatal(err)
+	}
+	if m.done(a) {
+		t.Error("an edited input is still done")
+	}
+	if len(m.entries) != 3 {
+		t.Errorf("manifest has %d entries, want the 3 inputs kept", len(m.entries))
+	}
+}
@@ -0,0 +1,103 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const markdownDateLayout = "2006-01-02"
+
+func createMarkdownNotes(config Config, transcriptionText, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_markdown_notes command...")
+
+	transcriptionText, err := condenseTranscript(config, transcriptionText)
+	if err != nil {
+		return "", err
+	}
+
+	date := recordingDate(config)
+	prompt := createMarkdownPrompt(transcriptionText, date, config.DetailLevel)
+	markdown, err := generateNotes(config, "", prompt, ".md", chatResponsePath(config, baseFilePath, ""), func(text string) (string, error) {
+		return createMarkdownPrompt(text, date, config.DetailLevel), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	markdown = setMarkdownDate(markdown, date)
+	if config.WrapWidth > 0 {
+		frontmatter, body := splitFrontmatter(markdown)
+		markdown = frontmatter + wrapText(body, config.WrapWidth)
+	}
+
+	if err := writeToFile(config, generateMarkdownFilePath(baseFilePath), markdown); err != nil {
+		return "", err
+	}
+	return markdown, nil
+}
+
+func generateMarkdownFilePath(baseFilePath string) string {
+	return generateDerivedFilePath(baseFilePath, "_notes.md")
+}
+
+// splitFrontmatter returns the leading "---" delimited YAML frontmatter,
+// including its closing line, and the rest of the document.
+func splitFrontmatter(markdown string) (string, string) {
+	if !strings.HasPrefix(markdown, "---\n") {
+		return "", markdown
+	}
+	end := strings.Index(markdown[4:], "\n---")
+	if end < 0 {
+		return "", markdown
+	}
+	end += 4 + len("\n---")
+	if newline := strings.IndexByte(markdown[end:], '\n'); newline >= 0 {
+		end += newline + 1
+	} else {
+		end = len(markdown)
+	}
+	return markdown[:end], markdown[end:]
+}
+
+// setMarkdownDate replaces the date: in the frontmatter with the recording
+// date, since models tend to make one up, and adds frontmatter when the
+// response has none.
+func setMarkdownDate(markdown string, date time.Time) string {
+	dateLine := "date: " + date.Format(markdownDateLayout)
+
+	frontmatter, body := splitFrontmatter(markdown)
+	if frontmatter == "" {
+		return "---\n" + dateLine + "\n---\n\n" + strings.TrimLeft(markdown, "\n")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(frontmatter, "\n"), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "date:") {
+			lines[i] = dateLine
+			return strings.Join(lines, "\n") + "\n" + body
+		}
+	}
+	closing := len(lines) - 1
+	lines = append(lines[:closing], dateLine, lines[closing])
+	return strings.Join(lines, "\n") + "\n" + body
+}
+
+func createMarkdownPrompt(transcriptionText string, date time.Time, level string) string {
+	detail := notesDetail(level)
+	return fmt.Sprintf(`I need you to summarize the following content and convert it into a Markdown document. Please do not include any extra commentary or explanations.
+
+%s
+
+The response should only contain the Markdown output, without wrapping it in a code block.
+
+Use the following structure:
+
+1. The file should start with YAML frontmatter between --- lines, with title:, author:, and date: keys, and the date: as %s
+2. Include a "## Summary" section that gives a brief overview of the key points, %s
+3. Include a "## Notes" section, with ### subsections that organize the content logically. %s
+
+Here is the content to summarize:
+
+%s`, detail.guidance, date.Format(markdownDateLayout), detail.summary, detail.note, transcriptionText)
+}
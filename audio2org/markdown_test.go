@@ -0,0 +1,85 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetMarkdownDate(t *testing.T) {
+	date := time.Date(2024, 4, 12, 9, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "replaces the model's date",
+			markdown: "---\ntitle: Standup\ndate: 2023-01-01\n---\n\n## Summary\n",
+			want:     "---\ntitle: Standup\ndate: 2024-04-12\n---\n\n## Summary\n",
+		},
+		{
+			name:     "adds a missing date",
+			markdown: "---\ntitle: Standup\nauthor: Team\n---\n## Summary\n",
+			want:     "---\ntitle: Standup\nauthor: Team\ndate: 2024-04-12\n---\n## Summary\n",
+		},
+		{
+			name:     "adds frontmatter",
+			markdown: "\n## Summary\nShort.",
+			want:     "---\ndate: 2024-04-12\n---\n\n## Summary\nShort.",
+		},
+		{
+			name:     "leaves a later rule alone",
+			markdown: "## Summary\n\n---\n\ndate: of the release",
+			want:     "---\ndate: 2024-04-12\n---\n\n## Summary\n\n---\n\ndate: of the release",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setMarkdownDate(tt.markdown, date); got != tt.want {
+				t.Errorf("setMarkdownDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateMarkdownNotes(t *testing.T) {
+	dir := t.TempDir()
+	summarizer := filepath.Join(dir, "summarizer.sh")
+	script := "#!/bin/sh\ncat > " + filepath.Join(dir, "prompt.txt") + "\nprintf -- '---\\ntitle: Standup\\ndate: 2000-01-01\\n---\\n\\n## Summary\\n\\nShipped.\\n'\n"
+	if err := os.WriteFile(summarizer, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config := Config{SummarizerCmd: summarizer, RecordingDate: "2024-04-12"}
+	markdown, err := createMarkdownNotes(config, "We shipped the release.", filepath.Join(dir, "standup.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "---\ntitle: Standup\ndate: 2024-04-12\n---\n\n## Summary\n\nShipped.\n"
+	if markdown != want {
+		t.Errorf("createMarkdownNotes() = %q, want %q", markdown, want)
+	}
+	written, err := os.ReadFile(filepath.Join(dir, "standup_notes.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != want {
+		t.Errorf("standup_notes.md = %q, want %q", written, want)
+	}
+
+	prompt, err := os.ReadFile(filepath.Join(dir, "prompt.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{"Markdown", "the date: as 2024-04-12", "We shipped the release."} {
+		if !strings.Contains(string(prompt), part) {
+			t.Errorf("prompt does not contain %q:\n%s", part, prompt)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// checkMergeOutputs rejects -merge-outputs runs whose first -post step
+// does not write notes to merge.
+func checkMergeOutputs(config Config, files []string) error {
+	if config.MergeOutputs == "" {
+		return nil
+	}
+	steps := postSteps(config)
+	switch {
+	case len(files) == 0:
+		return errors.New("-merge-outputs combines the notes of -file inputs and requires -file")
+	case len(steps) == 0 || (steps[0] != "create_emacs_org_notes" && steps[0] != "create_markdown_notes"):
+		return errors.New("-merge-outputs requires the first -post command to be create_emacs_org_notes or create_markdown_notes")
+	case config.SummaryLanguages != "":
+		return errors.New("-merge-outputs cannot be combined with -summary-languages, which writes one file per language")
+	case config.NoOutput || config.Stdout:
+		return errors.New("-merge-outputs cannot be combined with -no-output or -stdout")
+	case config.DryRun:
+		return errors.New("-merge-outputs cannot be combined with -dry-run, which writes no notes to merge")
+	}
+	return nil
+}
+
+// writeMergedOutputs writes the -merge-outputs file: the notes of every
+// input that finished, in the order the inputs were given.
+func writeMergedOutputs(config Config, files []string) error {
+	entries := orderedOrgIndexEntries(files)
+	title := strings.TrimSuffix(filepath.Base(config.MergeOutputs), filepath.Ext(config.MergeOutputs))
+	var content string
+	if postSteps(config)[0] == "create_markdown_notes" {
+		content = formatMergedMarkdown(entries, title, len(files))
+	} else {
+		content = formatMergedOrg(entries, title, time.Now(), len(files))
+	}
+	return writeToFile(config, config.MergeOutputs, content)
+}
+
+// formatMergedOrg puts each input's notes under a top-level heading naming
+// the input, as an orgEntry one level down with its headings below that.
+func formatMergedOrg(entries []orgIndexEntry, title string, now time.Time, inputs int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#+title: %s\n", title)
+	fmt.Fprintf(&b, "#+date: [%s]\n", now.Format(orgTimestampLayout))
+	if len(entries) < inputs {
+		fmt.Fprintf(&b, "\n%d of the %d inputs did not finish and are not included.\n", inputs-len(entries), inputs)
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "\n* %s\n%s", filepath.Base(entry.Source), orgEntry(shiftOrgHeadings(entry.Notes, 1), 1))
+	}
+	return b.String()
+}
+
+// formatMergedMarkdown puts each input's notes, without their frontmatter,
+// under a second-level heading naming the input, with their own headings
+// demoted one level.
+func formatMergedMarkdown(entries []orgIndexEntry, title string, inputs int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	if len(entries) < inputs {
+		fmt.Fprintf(&b, "\n%d of the %d inputs did not finish and are not included.\n", inputs-len(entries), inputs)
+	}
+	for _, entry := range entries {
+		_, body := splitFrontmatter(entry.Notes)
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", filepath.Base(entry.Source), strings.TrimSpace(shiftMarkdownHeadings(body, 1)))
+	}
+	return b.String()
+}
+
+// shiftMarkdownHeadings adds offset levels to every ATX heading outside
+// fenced code blocks.
+func shiftMarkdownHeadings(markdown string, offset int) string {
+	hashes := strings.Repeat("#", offset)
+	inFence := false
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			inFence = !inFence
+		case !inFence && strings.HasPrefix(line, "#") && strings.HasPrefix(strings.TrimLeft(line, "#"), " "):
+			lines[i] = hashes + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMergedOutputs(t *testing.T) {
+	t.Cleanup(func() { orgIndexEntries = nil })
+	dir := t.TempDir()
+	files := []string{"rec/monday.mp3", "rec/tuesday.mp3", "rec/wednesday.mp3"}
+	// Recorded in the order the inputs finished, not the order given.
+	orgIndexEntries = []orgIndexEntry{
+		{Source: "rec/tuesday.mp3", Notes: "#+title: Retro\n#+date: <2024-04-16 Tue>\n* Summary\nWent well."},
+		{Source: "rec/monday.mp3", Notes: "#+title: Planning\n* Summary\nPlanned."},
+	}
+
+	config := Config{MergeOutputs: filepath.Join(dir, "week.org"), PostProcessCmd: "create_emacs_org_notes"}
+	if err := writeMergedOutputs(config, files); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(config.MergeOutputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	want := "\n1 of the 3 inputs did not finish and are not included.\n" +
+		"\n* monday.mp3\n** Planning\n\n*** Summary\nPlanned.\n" +
+		"\n* tuesday.mp3\n** Retro\n<2024-04-16 Tue>\n\n*** Summary\nWent well.\n"
+	if !strings.HasPrefix(content, "#+title: week\n#+date: [") || !strings.HasSuffix(content, want) {
+		t.Errorf("merged org =\n%s\nwant it to end with\n%s", content, want)
+	}
+}
+
+func TestFormatMergedMarkdown(t *testing.T) {
+	entries := []orgIndexEntry{
+		{Source: "rec/monday.mp3", Notes: "---\ntitle: Planning\ndate: 2024-04-15\n---\n\n## Summary\nPlanned.\n\n```\n# not a heading\n```\n"},
+		{Source: "rec/tuesday.mp3", Notes: "## Summary\nWent well.\n"},
+	}
+	want := "# week\n" +
+		"\n## monday.mp3\n\n### Summary\nPlanned.\n\n```\n# not a heading\n```\n" +
+		"\n## tuesday.mp3\n\n### Summary\nWent well.\n"
+	if got := formatMergedMarkdown(entries, "week", 2); got != want {
+		t.Errorf("formatMergedMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCheckMergeOutputs(t *testing.T) {
+	files := []string{"a.mp3", "b.mp3"}
+	tests := []struct {
+		name    string
+		config  Config
+		files   []string
+		wantErr bool
+	}{
+		{"off", Config{}, nil, false},
+		{"org notes", Config{MergeOutputs: "all.org", PostProcessCmd: "create_emacs_org_notes,create_glossary"}, files, false},
+		{"markdown notes", Config{MergeOutputs: "all.md", PostProcessCmd: "create_markdown_notes"}, files, false},
+		{"no -file", Config{MergeOutputs: "all.org", PostProcessCmd: "create_emacs_org_notes"}, nil, true},
+		{"no -post", Config{MergeOutputs: "all.org"}, files, true},
+		{"first step is not notes", Config{MergeOutputs: "all.org", PostProcessCmd: "create_glossary,create_emacs_org_notes"}, files, true},
+		{"stdout", Config{MergeOutputs: "all.org", PostProcessCmd: "create_emacs_org_notes", Stdout: true}, files, true},
+		{"dry run", Config{MergeOutputs: "all.org", PostProcessCmd: "create_emacs_org_notes", DryRun: true}, files, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkMergeOutputs(tt.config, tt.files); (err != nil) != tt.wantErr {
+				t.Errorf("checkMergeOutputs() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatMergedOrgDate(t *testing.T) {
+	now := time.Date(2024, 4, 17, 18, 0, 0, 0, time.UTC)
+	if got := formatMergedOrg(nil, "week", now, 0); got != "#+title: week\n#+date: [2024-04-17 Wed 18:00]\n" {
+		t.Errorf("formatMergedOrg() of no entries = %q", got)
+	}
+}
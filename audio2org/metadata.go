@@ -0,0 +1,44 @@
+package audio2org
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const metadataFence = "---"
+
+// metadataHeader is the -prepend-metadata block written above a text
+// transcript: the same source, model, language, and created_at fields as
+// the -format json Result, between --- lines and followed by a blank line.
+func metadataHeader(config Config, transcription TranscriptionResponse, createdAt time.Time) string {
+	var b strings.Builder
+	b.WriteString(metadataFence + "\n")
+	fmt.Fprintf(&b, "source: %s\n", inputSource(config))
+	fmt.Fprintf(&b, "model: %s\n", transcriptionModel(config))
+	language := transcription.Language
+	if language == "" {
+		language = config.Language
+	}
+	if language != "" {
+		fmt.Fprintf(&b, "language: %s\n", language)
+	}
+	fmt.Fprintf(&b, "created_at: %s\n", createdAt.UTC().Format(time.RFC3339))
+	b.WriteString(metadataFence + "\n\n")
+	return b.String()
+}
+
+// stripMetadataHeader removes a leading -prepend-metadata block, so a
+// transcript read back with -transcription or after -edit is post-processed
+// without it.
+func stripMetadataHeader(text string) string {
+	if !strings.HasPrefix(text, metadataFence+"\n") {
+		return text
+	}
+	end := strings.Index(text[len(metadataFence)+1:], "\n"+metadataFence+"\n")
+	if end < 0 {
+		return text
+	}
+	body := text[len(metadataFence)+1+end+len(metadataFence)+2:]
+	return strings.TrimPrefix(body, "\n")
+}
@@ -0,0 +1,56 @@
+package audio2org
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetadataHeader(t *testing.T) {
+	createdAt := time.Date(2024, 5, 6, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		config        Config
+		transcription TranscriptionResponse
+		want          string
+	}{
+		{
+			name:          "detected language",
+			config:        Config{AudioFilePath: "talks/keynote.mp3", Backend: "openai", TranscribeModel: "whisper-1"},
+			transcription: TranscriptionResponse{Language: "english"},
+			want:          "---\nsource: talks/keynote.mp3\nmodel: whisper-1\nlanguage: english\ncreated_at: 2024-05-06T14:30:00Z\n---\n\n",
+		},
+		{
+			name:   "language flag",
+			config: Config{AudioFilePath: "a.mp3", Backend: "local", WhisperModel: "base.en", Language: "en"},
+			want:   "---\nsource: a.mp3\nmodel: whisper.cpp:base.en\nlanguage: en\ncreated_at: 2024-05-06T14:30:00Z\n---\n\n",
+		},
+		{
+			name:   "unknown language",
+			config: Config{AudioFilePath: "a.mp3", Backend: "openai", TranscribeModel: "whisper-1"},
+			want:   "---\nsource: a.mp3\nmodel: whisper-1\ncreated_at: 2024-05-06T14:30:00Z\n---\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := metadataHeader(tt.config, tt.transcription, createdAt)
+			if header != tt.want {
+				t.Errorf("metadataHeader() = %q, want %q", header, tt.want)
+			}
+			if got := stripMetadataHeader(header + "Hello there.\n"); got != "Hello there.\n" {
+				t.Errorf("stripMetadataHeader() = %q, want the body", got)
+			}
+		})
+	}
+}
+
+func TestStripMetadataHeaderKeepsOtherText(t *testing.T) {
+	for _, text := range []string{
+		"Hello there.\n",
+		"---\nan unclosed fence\n",
+		"Intro\n---\nsource: a.mp3\n---\n",
+	} {
+		if got := stripMetadataHeader(text); got != text {
+			t.Errorf("stripMetadataHeader(%q) = %q, want it unchanged", text, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+const multilangChunkSeconds = 30.0
+
+// transcribeMultilang transcribes fixed-length chunks separately so Whisper
+// detects the language of each one instead of forcing the whole recording
+// into the language of its opening seconds. A [lang] tag starts a new line
+// wherever the detected language changes. If a chunk fails after others
+// finished, the error is a *partialTranscriptionError with the text so far.
+func transcribeMultilang(config Config, audioFilePath string) (string, error) {
+	if err := requireFFmpeg("-multilang"); err != nil {
+		return "", err
+	}
+	if err := requireFFprobe("-multilang"); err != nil {
+		return "", err
+	}
+
+	duration, err := probeDuration(audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	regions := fixedRegions(duration.Seconds(), multilangChunkSeconds)
+
+	var b strings.Builder
+	language := ""
+	for i, region := range regions {
+		log.Printf("Transcribing chunk %d/%d (%s-%s)...\n", i+1, len(regions), formatTimestamp(region.Start), formatTimestamp(region.End))
+
+		// Unlike -vad, the previous chunk is not passed as the prompt: Whisper
+		// follows the prompt's language, which would defeat the detection.
+		transcription, err := transcribeRegion(config, audioFilePath, "multilang", region, map[string]string{
+			"response_format": "verbose_json",
+		})
+		if err != nil {
+			err = fmt.Errorf("transcribing chunk %d: %w", i+1, err)
+			if b.Len() == 0 {
+				return "", err
+			}
+			partial := TranscriptionResponse{Text: b.String(), Duration: region.Start}
+			return "", &partialTranscriptionError{transcription: partial, done: i, total: len(regions), units: "chunks", err: err}
+		}
+		text := strings.TrimSpace(transcription.Text)
+		if text == "" {
+			continue
+		}
+
+		if transcription.Language != language {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "[%s] ", transcription.Language)
+			language = transcription.Language
+		} else {
+			b.WriteString(" ")
+		}
+		b.WriteString(text)
+	}
+
+	return b.String(), nil
+}
+
+func fixedRegions(total, length float64) []audioRegion {
+	var regions []audioRegion
+	for start := 0.0; start < total; start += length {
+		regions = append(regions, audioRegion{Start: start, End: min(total, start+length)})
+	}
+	return regions
+}
@@ -0,0 +1,90 @@
+package audio2org
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFixedRegions(t *testing.T) {
+	tests := []struct {
+		name  string
+		total float64
+		want  []audioRegion
+	}{
+		{"exact multiple", 90, []audioRegion{{0, 30}, {30, 60}, {60, 90}}},
+		{"short final region", 70, []audioRegion{{0, 30}, {30, 60}, {60, 70}}},
+		{"shorter than one region", 10, []audioRegion{{0, 10}}},
+		{"zero total", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fixedRegions(tt.total, 30); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fixedRegions(%v, 30) = %v, want %v", tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscribeMultilangTagsLanguages(t *testing.T) {
+	type chunk struct{ text, language string }
+	tests := []struct {
+		name   string
+		chunks []chunk
+		want   string
+	}{
+		{
+			name:   "one language",
+			chunks: []chunk{{"One.", "english"}, {"Two.", "english"}, {"Three.", "english"}},
+			want:   "[english] One. Two. Three.",
+		},
+		{
+			name:   "language changes",
+			chunks: []chunk{{"Hello.", "english"}, {"Hallo.", "german"}, {"Hello again.", "english"}},
+			want:   "[english] Hello.\n[german] Hallo.\n[english] Hello again.",
+		},
+		{
+			name:   "silent chunk between the languages",
+			chunks: []chunk{{"Hello.", "english"}, {"", "german"}, {"Hallo.", "german"}},
+			want:   "[english] Hello.\n[german] Hallo.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeChunkTools(t)
+			t.Setenv("FAKE_DURATION", "90")
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				chunk := tt.chunks[requests]
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"text": %q, "language": %q}`, chunk.text, chunk.language)
+			}))
+			defer server.Close()
+
+			config := validConfig()
+			config.OpenAIAPIKey = "key"
+			config.BaseURL = server.URL
+			config.NoCache = true
+			config.RetryLog = "quiet"
+			path := filepath.Join(t.TempDir(), "talk.mp3")
+			writeSparseAudio(t, path, 1024)
+
+			got, err := transcribeMultilang(config, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requests != len(tt.chunks) {
+				t.Errorf("sent %d requests, want one per %d chunks", requests, len(tt.chunks))
+			}
+			if got != tt.want {
+				t.Errorf("transcribeMultilang() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
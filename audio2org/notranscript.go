@@ -0,0 +1,35 @@
+package audio2org
+
+import "errors"
+
+// transcriptOnDisk reports whether the run leaves a transcript file behind:
+// one from -file that was written, or the -transcription input.
+func transcriptOnDisk(config Config) bool {
+	if config.AudioFilePath == "" {
+		return config.TranscriptionFilePath != ""
+	}
+	return !config.NoOutput && !config.NoTranscriptFile
+}
+
+// checkNoTranscriptFile rejects the options that would put the
+// transcript on disk anyway, or that need the transcript file.
+func checkNoTranscriptFile(config Config) error {
+	if !config.NoTranscriptFile {
+		return nil
+	}
+	switch {
+	case len(audioInputs(config)) == 0:
+		return errors.New("-no-transcript-file requires -file; a -transcription input is already on disk")
+	case len(postSteps(config)) == 0 && !config.InlineSummary:
+		return errors.New("-no-transcript-file requires -post or -inline-summary, or the run would write nothing")
+	case hasPostStep(config, "create_org_transcript"):
+		return errors.New("-no-transcript-file cannot be combined with create_org_transcript, which writes the full transcript")
+	case config.WordTimestamps:
+		return errors.New("-no-transcript-file cannot be combined with -word-timestamps, which writes every word of the transcript")
+	case config.Resume != "":
+		return errors.New("-no-transcript-file cannot be combined with -resume, which saves the transcript in the state file")
+	case config.Edit:
+		return errors.New("-no-transcript-file cannot be combined with -edit, which opens the transcript file")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckNoTranscriptFile(t *testing.T) {
+	base := Config{NoTranscriptFile: true, AudioFilePath: "talk.mp3", PostProcessCmd: "create_emacs_org_notes"}
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"notes only", func(c *Config) {}, ""},
+		{"inline summary only", func(c *Config) { c.PostProcessCmd, c.InlineSummary = "", true }, ""},
+		{"existing transcript", func(c *Config) { c.AudioFilePath, c.TranscriptionFilePath = "", "talk.txt" }, "requires -file"},
+		{"nothing to write", func(c *Config) { c.PostProcessCmd = "" }, "requires -post or -inline-summary"},
+		{"org transcript", func(c *Config) { c.PostProcessCmd = "create_glossary,create_org_transcript" }, "create_org_transcript"},
+		{"word timestamps", func(c *Config) { c.WordTimestamps = true }, "-word-timestamps"},
+		{"resume", func(c *Config) { c.Resume = "state.json" }, "-resume"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := base
+			tt.modify(&config)
+			err := checkNoTranscriptFile(config)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkNoTranscriptFile() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNoTranscriptFileOutputs(t *testing.T) {
+	config := Config{NoTranscriptFile: true, AudioFilePath: "talk.mp3", PostProcessCmd: "create_emacs_org_notes"}
+	targets := planOutputs(config, "output/talk.txt")
+	if len(targets) != 1 || targets[0].Path != "output/talk_emacs_org_notes.org" {
+		t.Errorf("planOutputs() = %v, want only the org notes", targets)
+	}
+	if transcriptOnDisk(config) {
+		t.Error("transcriptOnDisk() = true with -no-transcript-file")
+	}
+}
@@ -0,0 +1,337 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const orgTimestampLayout = "2006-01-02 Mon 15:04"
+
+var orgDateLayouts = map[string]string{
+	"active":   "<2006-01-02 Mon>",
+	"inactive": "[2006-01-02 Mon]",
+	"iso":      "2006-01-02",
+}
+
+const recordingDateLayout = "2006-01-02"
+
+// recordingDate is the day the recording was made, for the #+date: line:
+// the -recording-date, or else the audio file's modification time, or
+// today for a -transcription input.
+func recordingDate(config Config) time.Time {
+	if date, err := time.ParseInLocation(recordingDateLayout, config.RecordingDate, time.Local); err == nil {
+		return date
+	}
+	if config.AudioFilePath != "" {
+		if info, err := os.Stat(config.AudioFilePath); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Now()
+}
+
+// recordingClock is the -clock entry: from the audio file's modification
+// time, moved to the -recording-date when one is given, for the length of
+// the recording.
+func recordingClock(config Config) (string, error) {
+	if err := requireFFprobe("-clock"); err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(config.AudioFilePath)
+	if err != nil {
+		return "", fmt.Errorf("reading audio file info: %w", err)
+	}
+
+	start := info.ModTime()
+	if date, err := time.ParseInLocation(recordingDateLayout, config.RecordingDate, time.Local); err == nil {
+		start = time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), start.Second(), 0, time.Local)
+	}
+	duration, err := probeDuration(config.AudioFilePath)
+	if err != nil {
+		return "", err
+	}
+	return formatClockEntry(start, start.Add(duration)), nil
+}
+
+func formatClockEntry(start, end time.Time) string {
+	minutes := int(end.Sub(start).Round(time.Minute).Minutes())
+	return fmt.Sprintf("CLOCK: [%s]--[%s] => %2d:%02d",
+		start.Format(orgTimestampLayout),
+		end.Format(orgTimestampLayout),
+		minutes/60, minutes%60)
+}
+
+func insertLogbook(orgContent, clockEntry string) string {
+	drawer := ":LOGBOOK:\n" + clockEntry + "\n:END:"
+
+	lines := strings.Split(orgContent, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "*") && strings.HasPrefix(strings.TrimLeft(line, "*"), " ") {
+			rest := append([]string{drawer}, lines[i+1:]...)
+			return strings.Join(append(lines[:i+1], rest...), "\n")
+		}
+	}
+
+	return strings.TrimRight(orgContent, "\n") + "\n\n* Recording\n" + drawer + "\n"
+}
+
+// setOrgDate replaces the #+date: line the model wrote, or adds one after
+// the #+title: line, so the date format does not depend on the model.
+func setOrgDate(orgContent, style string, date time.Time) string {
+	dateLine := "#+date: " + date.Format(orgDateLayouts[style])
+
+	lines := strings.Split(orgContent, "\n")
+	titleIndex := -1
+	for i, line := range lines {
+		lower := strings.ToLower(strings.TrimSpace(line))
+		if strings.HasPrefix(lower, "#+date:") {
+			lines[i] = dateLine
+			return strings.Join(lines, "\n")
+		}
+		if titleIndex < 0 && strings.HasPrefix(lower, "#+title:") {
+			titleIndex = i
+		}
+	}
+
+	rest := append([]string{dateLine}, lines[titleIndex+1:]...)
+	return strings.Join(append(lines[:titleIndex+1], rest...), "\n")
+}
+
+const abstractInstruction = "Directly after the #+title: line, add a #+subtitle: line containing an abstract of the whole content in two to three complete sentences, on that one line."
+
+const todoInstruction = `Add an "Action Items" section listing every task, follow-up, or commitment mentioned, each as a "** TODO" heading naming who does what. When the content gives a due date for it, add a line directly under the heading with SCHEDULED: and that date as an org timestamp, e.g. SCHEDULED: <2024-03-08 Fri>, taking relative dates such as "next Friday" from the #+date:. Leave the Action Items section out if there are none.`
+
+const autoTagsInstruction = "Directly after the #+title: line, add a #+filetags: line with up to three short lowercase tags for the main topics, in org's :tag1:tag2: form, using only letters, digits, and underscores."
+
+// orgTagPattern is what org accepts in a tag.
+var orgTagPattern = regexp.MustCompile(`^[\p{L}\p{N}_@#%]+$`)
+
+// orgTags splits -org-tags into its fixed tags and whether it includes
+// auto, which asks the model for topic tags as well.
+func orgTags(value string) (tags []string, auto bool) {
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.Trim(strings.TrimSpace(tag), ":")
+		switch {
+		case tag == "":
+		case tag == "auto":
+			auto = true
+		default:
+			tags = append(tags, tag)
+		}
+	}
+	return tags, auto
+}
+
+func checkOrgTags(config Config) error {
+	if config.OrgTags == "" {
+		return nil
+	}
+	tags, auto := orgTags(config.OrgTags)
+	if len(tags) == 0 && !auto {
+		return fmt.Errorf("-org-tags %q lists no tags", config.OrgTags)
+	}
+	for _, tag := range tags {
+		if !orgTagPattern.MatchString(tag) {
+			return fmt.Errorf("-org-tags: %q is not a valid org tag; use letters, digits, _, @, #, and %%", tag)
+		}
+	}
+	return nil
+}
+
+// setOrgFiletags writes one #+filetags: line after the #+title: with the
+// -org-tags followed by any topic tags the model chose, without
+// duplicates, replacing the model's own #+filetags: lines. Model tags that
+// org would not read as tags are dropped.
+func setOrgFiletags(orgContent string, fixed []string) string {
+	tags := append([]string(nil), fixed...)
+	var lines []string
+	for _, line := range strings.Split(orgContent, "\n") {
+		keyword, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && strings.EqualFold(keyword, "#+filetags") {
+			for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ':' || r == ' ' }) {
+				if orgTagPattern.MatchString(tag) && !slices.Contains(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(tags) == 0 {
+		return strings.Join(lines, "\n")
+	}
+
+	filetags := "#+filetags: :" + strings.Join(tags, ":") + ":"
+	for i, line := range lines {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "#+title:") {
+			return strings.Join(slices.Insert(lines, i+1, filetags), "\n")
+		}
+	}
+	return strings.Join(append([]string{filetags}, lines...), "\n")
+}
+
+var sentenceEndPattern = regexp.MustCompile(`[.!?]+(\s|$)`)
+
+// validateAbstract checks that the notes start with the #+subtitle:
+// abstract that -abstract asks for.
+func validateAbstract(orgContent string) error {
+	for _, line := range strings.Split(orgContent, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "*") {
+			break
+		}
+		if !strings.HasPrefix(strings.ToLower(trimmed), "#+subtitle:") {
+			continue
+		}
+
+		abstract := strings.TrimSpace(trimmed[len("#+subtitle:"):])
+		sentences := len(sentenceEndPattern.FindAllString(abstract, -1))
+		if sentences < 2 || sentences > 3 {
+			return fmt.Errorf("the #+subtitle: abstract has %d sentences, expected 2-3", sentences)
+		}
+		return nil
+	}
+	return fmt.Errorf("the notes have no #+subtitle: abstract before the first heading")
+}
+
+const orgCorrection = "That was not a valid Emacs Org file. Return only the Org file itself, starting with the #+title: line, with no code fences and no commentary before or after it."
+
+// stripCodeFence removes a Markdown code fence, such as ```org, around the
+// whole response, which models sometimes add despite the instructions.
+func stripCodeFence(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "```") {
+		return content
+	}
+	first, rest, _ := strings.Cut(trimmed, "\n")
+	if strings.Contains(first[3:], "`") || !strings.HasSuffix(rest, "```") {
+		return content
+	}
+	return strings.TrimSpace(strings.TrimSuffix(rest, "```")) + "\n"
+}
+
+// validateOrgNotes checks that the response is the org file alone: it
+// starts with the #+title: line and has at least one heading.
+func validateOrgNotes(orgContent string) error {
+	trimmed := strings.TrimSpace(orgContent)
+	if !strings.HasPrefix(strings.ToLower(trimmed), "#+title:") {
+		first, _, _ := strings.Cut(trimmed, "\n")
+		return fmt.Errorf("the notes do not start with a #+title: line but with %q", truncateText(first, 60))
+	}
+	for _, line := range strings.Split(trimmed, "\n") {
+		if strings.HasPrefix(line, "*") && strings.HasPrefix(strings.TrimLeft(line, "*"), " ") {
+			return nil
+		}
+	}
+	return fmt.Errorf("the notes have no org headings")
+}
+
+// shiftOrgHeadings demotes every org heading by offset levels so the notes
+// can be nested under an existing parent heading. Lines inside blocks are
+// left alone.
+func shiftOrgHeadings(orgContent string, offset int) string {
+	if offset <= 0 {
+		return orgContent
+	}
+
+	stars := strings.Repeat("*", offset)
+	inBlock := false
+	lines := strings.Split(orgContent, "\n")
+	for i, line := range lines {
+		lower := strings.ToLower(strings.TrimSpace(line))
+		switch {
+		case inBlock:
+			inBlock = !strings.HasPrefix(lower, "#+end_")
+		case strings.HasPrefix(lower, "#+begin_"):
+			inBlock = true
+		case strings.HasPrefix(line, "*") && strings.HasPrefix(strings.TrimLeft(line, "*"), " "):
+			lines[i] = stars + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// transcriptWrapWidth fills the -append-transcript section when no
+// -wrap is given, since a Whisper transcript is one long line.
+const transcriptWrapWidth = 80
+
+// orgEscape is the zero-width space Org recommends for text that would
+// otherwise be read as markup.
+const orgEscape = "\u200b"
+
+// appendTranscriptSection adds the transcript to the end of the notes under
+// a "Full Transcript" heading one level below offset, with each paragraph
+// filled to width. Filled lines that would read as a heading, keyword,
+// block, table, or drawer are escaped so the transcript stays plain text.
+func appendTranscriptSection(orgContent, transcript string, offset, width int) string {
+	if width <= 0 {
+		width = transcriptWrapWidth
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(transcript), "\n\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		for _, line := range fillWords(words, "", "", width) {
+			if isStructuralLine(line, line) || strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+				line = orgEscape + line
+			}
+			lines = append(lines, line)
+		}
+	}
+	heading := strings.Repeat("*", offset+1) + " Full Transcript"
+	return strings.TrimRight(orgContent, "\n") + "\n\n" + heading + "\n" + strings.Join(lines, "\n") + "\n"
+}
+
+const orgLintScript = `(progn
+  (require 'org)
+  (require 'org-lint)
+  (find-file (getenv "AUDIO2ORG_LINT_FILE"))
+  (org-mode)
+  (dolist (report (org-lint))
+    (let ((v (cadr report)))
+      (princ (format "line %s (%s): %s\n" (aref v 0) (aref v 1) (aref v 2))))))`
+
+func lintOrgFile(config Config, orgFilePath string) {
+	if config.OutputURI != "" {
+		log.Println("Skipping -emacs-lint: the org file was uploaded to object storage")
+		return
+	}
+	if config.NoOutput {
+		log.Println("Skipping -emacs-lint: no org file is written with -no-output")
+		return
+	}
+	if _, err := exec.LookPath("emacs"); err != nil {
+		log.Println("Skipping -emacs-lint: emacs was not found on PATH")
+		return
+	}
+
+	log.Printf("Running org-lint on %s...\n", orgFilePath)
+	cmd := exec.Command("emacs", "--batch", "-Q", "--eval", orgLintScript)
+	cmd.Env = append(os.Environ(), "AUDIO2ORG_LINT_FILE="+orgFilePath)
+	output, err := cmd.Output()
+	if err != nil {
+		log.Printf("Error running org-lint: %v\n", err)
+		return
+	}
+
+	issues := strings.TrimSpace(string(output))
+	if issues == "" {
+		log.Println("org-lint found no issues")
+		return
+	}
+	for _, issue := range strings.Split(issues, "\n") {
+		log.Printf("org-lint: %s\n", issue)
+	}
+}
@@ -0,0 +1,312 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShiftOrgHeadings(t *testing.T) {
+	input := "#+title: Notes\n* Topic\nSome *bold* text\n** Detail\n#+begin_src org\n* not a heading\n#+end_src\n*bold* start\n"
+	want := "#+title: Notes\n*** Topic\nSome *bold* text\n**** Detail\n#+begin_src org\n* not a heading\n#+end_src\n*bold* start\n"
+
+	if got := shiftOrgHeadings(input, 2); got != want {
+		t.Errorf("shiftOrgHeadings() =\n%s\nwant:\n%s", got, want)
+	}
+	if got := shiftOrgHeadings(input, 0); got != input {
+		t.Errorf("shiftOrgHeadings() with offset 0 changed the content:\n%s", got)
+	}
+}
+
+func TestAppendTranscriptSection(t *testing.T) {
+	notes := "#+title: Sync\n\n* Decisions\nShip it.\n"
+	transcript := "We ship Friday. * marks the spot.\n#+begin_src is not a block\n\nSecond paragraph | pipe :PROPERTIES: here"
+
+	got := appendTranscriptSection(notes, transcript, 0, 16)
+	want := "#+title: Sync\n\n* Decisions\nShip it.\n\n* Full Transcript\n" +
+		"We ship Friday.\n\u200b* marks the\nspot.\n\u200b#+begin_src is\nnot a block\n\nSecond paragraph\n\u200b| pipe\n\u200b:PROPERTIES:\nhere\n"
+	if got != want {
+		t.Errorf("appendTranscriptSection() =\n%q\nwant\n%q", got, want)
+	}
+
+	if got := appendTranscriptSection(notes, "Hello.", 1, 0); !strings.HasSuffix(got, "\n\n** Full Transcript\nHello.\n") {
+		t.Errorf("with -heading-offset 1 = %q, want a level-2 heading", got)
+	}
+}
+
+func TestSetOrgDate(t *testing.T) {
+	date := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		content string
+		style   string
+		want    string
+	}{
+		{"replaces active", "#+title: Notes\n#+date: <1999-10-04 Mon>\n* Summary", "inactive", "#+title: Notes\n#+date: [2024-01-01 Mon]\n* Summary"},
+		{"replaces uppercase", "#+TITLE: Notes\n#+DATE: 2023-05-05\n", "active", "#+TITLE: Notes\n#+date: <2024-01-01 Mon>\n"},
+		{"adds after title", "#+title: Notes\n#+author: Me\n", "iso", "#+title: Notes\n#+date: 2024-01-01\n#+author: Me\n"},
+		{"adds at top", "* Summary\n", "iso", "#+date: 2024-01-01\n* Summary\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setOrgDate(tt.content, tt.style, date); got != tt.want {
+				t.Errorf("setOrgDate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatClockEntry(t *testing.T) {
+	start := time.Date(2024, 3, 5, 9, 58, 0, 0, time.UTC)
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{42 * time.Minute, "CLOCK: [2024-03-05 Tue 09:58]--[2024-03-05 Tue 10:40] =>  0:42"},
+		{2*time.Hour + 5*time.Minute + 40*time.Second, "CLOCK: [2024-03-05 Tue 09:58]--[2024-03-05 Tue 12:03] =>  2:06"},
+		{20 * time.Second, "CLOCK: [2024-03-05 Tue 09:58]--[2024-03-05 Tue 09:58] =>  0:00"},
+	}
+
+	for _, tt := range tests {
+		if got := formatClockEntry(start, start.Add(tt.duration)); got != tt.want {
+			t.Errorf("formatClockEntry(%v) = %q, want %q", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestInsertLogbook(t *testing.T) {
+	const clock = "CLOCK: [2024-03-05 Tue 09:58]--[2024-03-05 Tue 10:40] =>  0:42"
+	drawer := ":LOGBOOK:\n" + clock + "\n:END:"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"under first heading", "#+title: Sync\n* Summary\nText\n* Decisions\n", "#+title: Sync\n* Summary\n" + drawer + "\nText\n* Decisions\n"},
+		{"nested first heading", "#+title: Sync\n** Agenda\n", "#+title: Sync\n** Agenda\n" + drawer + "\n"},
+		{"bold text is not a heading", "*Note* first\n* Summary", "*Note* first\n* Summary\n" + drawer},
+		{"heading on last line", "* Summary", "* Summary\n" + drawer},
+		{"no heading", "#+title: Sync\nJust text\n\n", "#+title: Sync\nJust text\n\n* Recording\n" + drawer + "\n"},
+		{"empty", "", "\n\n* Recording\n" + drawer + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := insertLogbook(tt.content, clock); got != tt.want {
+				t.Errorf("insertLogbook() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAbstract(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"two sentences", "#+title: Notes\n#+subtitle: The team reviewed the launch. They agreed to ship Friday.\n* Summary\n", false},
+		{"three sentences", "#+subtitle: One. Two! Three?\n* Summary\n", false},
+		{"one sentence", "#+subtitle: The team reviewed the launch.\n* Summary\n", true},
+		{"too long", "#+subtitle: One. Two. Three. Four.\n", true},
+		{"missing", "#+title: Notes\n* Summary\n#+subtitle: Later. Too late.\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAbstract(tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("validateAbstract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"```org\n#+title: Sync\n* Notes\n```\n", "#+title: Sync\n* Notes\n"},
+		{"\n```\n#+title: Sync\n```", "#+title: Sync\n"},
+		{"#+title: Sync\n* Notes\n", "#+title: Sync\n* Notes\n"},
+		{"```org\n#+title: Sync\n", "```org\n#+title: Sync\n"},
+		{"```inline``` and more\n```", "```inline``` and more\n```"},
+	}
+	for _, tt := range tests {
+		if got := stripCodeFence(tt.content); got != tt.want {
+			t.Errorf("stripCodeFence(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestValidateOrgNotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", "#+title: Sync\n#+date: <2024-03-05 Tue>\n\n* Summary\n", ""},
+		{"upper-case keyword", "\n#+TITLE: Sync\n** Notes\n", ""},
+		{"preamble", "Here are your notes:\n\n#+title: Sync\n* Summary\n", `"Here are your notes:"`},
+		{"no headings", "#+title: Sync\nJust text.\n", "no org headings"},
+		{"bold is not a heading", "#+title: Sync\n*Bold* text\n", "no org headings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOrgNotes(tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateOrgNotes() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateOrgNotes() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteEmacsOrgNotesRetriesMalformed(t *testing.T) {
+	responses := []string{"Sure! Here are the notes.\n\n* Notes\n", "```org\n#+title: Sync\n\n* Notes\nShip it.\n```"}
+	var requests [][]map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []map[string]string `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body.Messages)
+		content, _ := json.Marshal(responses[min(len(requests), len(responses))-1])
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices": [{"message": {"role": "assistant", "content": %s}, "finish_reason": "stop"}]}`, content)
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL + "/v1", RetryLog: "quiet", OrgDateStyle: "active", RecordingDate: "2024-03-05"}
+	path := filepath.Join(t.TempDir(), "sync_emacs_org_notes.org")
+	got, err := writeEmacsOrgNotes(config, "We ship Friday.", path, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#+title: Sync\n#+date: <2024-03-05 Tue>\n\n* Notes\nShip it.\n"; got != want {
+		t.Errorf("notes = %q, want %q", got, want)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want a retry after the malformed response", len(requests))
+	}
+	retry := requests[1]
+	if n := len(retry); n < 2 || retry[n-2]["role"] != "assistant" || retry[n-1]["content"] != orgCorrection {
+		t.Errorf("retry messages = %v, want the bad response followed by the correction", retry)
+	}
+}
+
+func TestSetOrgFiletags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		value   string
+		want    string
+	}{
+		{"fixed tags", "#+title: Sync\n#+date: <2024-03-05 Tue>\n* Notes", "meeting, apollo", "#+title: Sync\n#+filetags: :meeting:apollo:\n#+date: <2024-03-05 Tue>\n* Notes"},
+		{"model tags merged", "#+title: Sync\n#+FILETAGS: :budget:meeting:\n* Notes", "meeting,auto", "#+title: Sync\n#+filetags: :meeting:budget:\n* Notes"},
+		{"invalid model tags dropped", "#+title: Sync\n#+filetags: :q3-plan:ok:\n* Notes", "auto", "#+title: Sync\n#+filetags: :ok:\n* Notes"},
+		{"auto without model tags", "#+title: Sync\n* Notes", "auto", "#+title: Sync\n* Notes"},
+		{"no title", "* Notes", "meeting", "#+filetags: :meeting:\n* Notes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixed, _ := orgTags(tt.value)
+			if got := setOrgFiletags(tt.content, fixed); got != tt.want {
+				t.Errorf("setOrgFiletags() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOrgTags(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr string
+	}{
+		{"", ""},
+		{"meeting,apollo", ""},
+		{":meeting:", ""},
+		{"auto", ""},
+		{"@work,#1,100%", ""},
+		{"q3-plan", "not a valid org tag"},
+		{"two words", "not a valid org tag"},
+		{" , ", "lists no tags"},
+	}
+	for _, tt := range tests {
+		err := checkOrgTags(Config{OrgTags: tt.value})
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("checkOrgTags(%q) = %v, want nil", tt.value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("checkOrgTags(%q) = %v, want an error containing %q", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRecordingDate(t *testing.T) {
+	audioPath := filepath.Join(t.TempDir(), "standup.m4a")
+	if err := os.WriteFile(audioPath, []byte("audio"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	recorded := time.Date(2024, 4, 12, 9, 30, 0, 0, time.Local)
+	if err := os.Chtimes(audioPath, recorded, recorded); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := recordingDate(Config{AudioFilePath: audioPath}); !got.Equal(recorded) {
+		t.Errorf("recordingDate() = %v, want the file's modification time %v", got, recorded)
+	}
+	got := recordingDate(Config{AudioFilePath: audioPath, RecordingDate: "2024-03-01"})
+	if orgPromptDate(got) != "<2024-03-01 Fri>" {
+		t.Errorf("recordingDate() with -recording-date = %v, want 2024-03-01", got)
+	}
+
+	prompt := defaultOrgSystemPrompt(recordingDate(Config{AudioFilePath: audioPath}), "normal")
+	if !strings.Contains(prompt, "the #+date: header as <2024-04-12 Fri>") {
+		t.Errorf("defaultOrgSystemPrompt() does not ask for the recording date:\n%s", prompt)
+	}
+}
+
+func TestRecordingClock(t *testing.T) {
+	fakeChunkTools(t)
+	audioPath := filepath.Join(t.TempDir(), "standup.m4a")
+	if err := os.WriteFile(audioPath, []byte("audio"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	recorded := time.Date(2024, 4, 12, 9, 30, 0, 0, time.Local)
+	if err := os.Chtimes(audioPath, recorded, recorded); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		recordingDate string
+		want          string
+	}{
+		{"", "CLOCK: [2024-04-12 Fri 09:30]--[2024-04-12 Fri 09:31] =>  0:01"},
+		{"2024-03-01", "CLOCK: [2024-03-01 Fri 09:30]--[2024-03-01 Fri 09:31] =>  0:01"},
+	}
+
+	for _, tt := range tests {
+		got, err := recordingClock(Config{AudioFilePath: audioPath, RecordingDate: tt.recordingDate})
+		if err != nil || got != tt.want {
+			t.Errorf("recordingClock() with -recording-date %q = %q, %v, want %q", tt.recordingDate, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	orgTitlePattern = regexp.MustCompile(`(?mi)^#\+title:[ \t]*(.+)$`)
+	orgDatePattern  = regexp.MustCompile(`(?mi)^#\+date:[ \t]*(.+)$`)
+)
+
+type orgIndexEntry struct {
+	Source   string  `json:"source"`
+	Title    string  `json:"title"`
+	Date     string  `json:"date"`
+	Duration float64 `json:"duration,omitempty"`
+	Path     string  `json:"path"`
+	// Notes is the first -post output, kept for -merge-outputs.
+	Notes string `json:"notes,omitempty"`
+}
+
+var (
+	orgIndexMu      sync.Mutex
+	orgIndexEntries []orgIndexEntry
+)
+
+// checkOrgIndex rejects -org-index runs without local output files to link
+// to.
+func checkOrgIndex(config Config, files []string) error {
+	switch {
+	case config.OrgIndex == "":
+		return nil
+	case len(files) == 0:
+		return errors.New("-org-index lists the notes of -file inputs and requires -file")
+	case slices.Contains(files, stdinPath):
+		return errors.New("-org-index links to the recordings and cannot be combined with -file -")
+	case slices.ContainsFunc(files, isAudioURL):
+		return errors.New("-org-index links to the recordings and cannot be combined with a -file URL")
+	case config.NoOutput || config.OutputURI != "":
+		return errors.New("-org-index links to local output files and cannot be combined with -no-output or -output-uri")
+	case config.DryRun:
+		return errors.New("-org-index cannot be combined with -dry-run, which writes no notes to list")
+	}
+	if info, err := os.Stat(filepath.Dir(config.OrgIndex)); err != nil || !info.IsDir() {
+		return fmt.Errorf("-org-index %s: the directory %s does not exist", config.OrgIndex, filepath.Dir(config.OrgIndex))
+	}
+	return nil
+}
+
+// recordOrgIndexEntry adds a finished input to the -org-index: its notes,
+// or its transcript without -post. The title and date come from the
+// #+title: and #+date: lines of org output, and otherwise from the file
+// name and the recording's modification time. With -merge-outputs the
+// notes themselves are kept too.
+func recordOrgIndexEntry(config Config, transcription TranscriptionResponse, transcriptPath, postOutput string) {
+	if config.OrgIndex == "" && config.MergeOutputs == "" {
+		return
+	}
+
+	entry := orgIndexEntry{
+		Source:   config.AudioFilePath,
+		Title:    strings.TrimSuffix(filepath.Base(config.AudioFilePath), filepath.Ext(config.AudioFilePath)),
+		Duration: transcription.Duration,
+		Path:     primaryOutput(config, transcriptPath),
+	}
+	if config.MergeOutputs != "" {
+		entry.Notes = postOutput
+	}
+	if match := orgTitlePattern.FindStringSubmatch(postOutput); match != nil {
+		entry.Title = strings.TrimSpace(match[1])
+	}
+	if match := orgDatePattern.FindStringSubmatch(postOutput); match != nil {
+		entry.Date = strings.TrimSpace(match[1])
+	} else {
+		entry.Date = recordingDate(config).Format(orgDateLayouts[config.OrgDateStyle])
+	}
+	if entry.Duration == 0 && requireFFprobe("-org-index") == nil {
+		if duration, err := probeDuration(config.AudioFilePath); err == nil {
+			entry.Duration = duration.Seconds()
+		}
+	}
+
+	orgIndexMu.Lock()
+	orgIndexEntries = append(orgIndexEntries, entry)
+	orgIndexMu.Unlock()
+}
+
+// writeOrgIndex writes the -org-index file for the inputs that finished,
+// in the order they were given.
+func writeOrgIndex(config Config, files []string) error {
+	return writeToFile(config, config.OrgIndex, formatOrgIndex(orderedOrgIndexEntries(files), config.OrgIndex, time.Now(), len(files)))
+}
+
+// orderedOrgIndexEntries returns the recorded entries in the order of
+// files, whatever order the inputs finished in.
+func orderedOrgIndexEntries(files []string) []orgIndexEntry {
+	orgIndexMu.Lock()
+	entries := append([]orgIndexEntry(nil), orgIndexEntries...)
+	orgIndexMu.Unlock()
+
+	ordered := make([]orgIndexEntry, 0, len(entries))
+	for _, file := range files {
+		for _, entry := range entries {
+			if entry.Source == file {
+				ordered = append(ordered, entry)
+			}
+		}
+	}
+	return ordered
+}
+
+// formatOrgIndex renders the entries as an org table, with links relative
+// to the index file so the index and the notes can be moved together.
+func formatOrgIndex(entries []orgIndexEntry, indexPath string, now time.Time, inputs int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#+title: Index of %d %s\n", len(entries), plural(len(entries), "recording", "recordings"))
+	fmt.Fprintf(&b, "#+date: [%s]\n\n", now.Format(orgTimestampLayout))
+	if len(entries) < inputs {
+		fmt.Fprintf(&b, "%d of the %d inputs did not finish and are not listed.\n\n", inputs-len(entries), inputs)
+	}
+
+	b.WriteString("| Title | Date | Duration | Notes |\n")
+	b.WriteString("|-------+------+----------+-------|\n")
+	for _, entry := range entries {
+		duration := ""
+		if entry.Duration > 0 {
+			duration = formatTimestamp(entry.Duration)
+		}
+		link := relativeLink(entry.Path, indexPath)
+		fmt.Fprintf(&b, "| %s | %s | %s | [[file:%s][%s]] |\n",
+			orgTableCell(entry.Title), orgTableCell(entry.Date), duration, link, orgTableCell(filepath.Base(entry.Path)))
+	}
+	return b.String()
+}
+
+// orgTableCell keeps text on one line and escapes the bars that would
+// otherwise split it into more columns.
+func orgTableCell(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", `\vert{}`)
+}
@@ -0,0 +1,63 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordOrgIndexEntry(t *testing.T) {
+	t.Cleanup(func() { orgIndexEntries = nil })
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "standup-0412.mp3")
+	if err := os.WriteFile(audioPath, []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 4, 12, 9, 30, 0, 0, time.Local)
+	if err := os.Chtimes(audioPath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	transcriptPath := filepath.Join(dir, "standup-0412.txt")
+
+	orgIndexEntries = nil
+	base := Config{AudioFilePath: audioPath, OrgIndex: "index.org", OrgDateStyle: "inactive"}
+	notes := base
+	notes.PostProcessCmd = "create_emacs_org_notes"
+	recordOrgIndexEntry(notes, TranscriptionResponse{Duration: 754}, transcriptPath, "#+title: Sprint Standup\n#+date: <2024-04-12 Fri>\n\n* Notes\n")
+	recordOrgIndexEntry(base, TranscriptionResponse{}, transcriptPath, "")
+
+	if len(orgIndexEntries) != 2 {
+		t.Fatalf("recorded %d entries, want 2", len(orgIndexEntries))
+	}
+	want := orgIndexEntry{Source: audioPath, Title: "Sprint Standup", Date: "<2024-04-12 Fri>", Duration: 754, Path: filepath.Join(dir, "standup-0412_emacs_org_notes.org")}
+	if got := orgIndexEntries[0]; got != want {
+		t.Errorf("entry with notes = %+v, want %+v", got, want)
+	}
+	if got := orgIndexEntries[1]; got.Title != "standup-0412" || got.Date != "[2024-04-12 Fri]" || got.Path != transcriptPath {
+		t.Errorf("entry without notes = %+v, want the file name, modification date, and transcript", got)
+	}
+}
+
+func TestFormatOrgIndex(t *testing.T) {
+	entries := []orgIndexEntry{
+		{Title: "Design review | API", Date: "<2024-04-12 Fri>", Duration: 3725, Path: "output/review_emacs_org_notes.org"},
+		{Title: "Retro", Date: "[2024-04-13 Sat]", Path: "output/retro.txt"},
+	}
+	now := time.Date(2024, 4, 14, 18, 5, 0, 0, time.UTC)
+
+	got := formatOrgIndex(entries, "index.org", now, 3)
+	want := `#+title: Index of 2 recordings
+#+date: [2024-04-14 Sun 18:05]
+
+1 of the 3 inputs did not finish and are not listed.
+
+| Title | Date | Duration | Notes |
+|-------+------+----------+-------|
+| Design review \vert{} API | <2024-04-12 Fri> | 01:02:05 | [[file:output/review_emacs_org_notes.org][review_emacs_org_notes.org]] |
+| Retro | [2024-04-13 Sat] |  | [[file:output/retro.txt][retro.txt]] |
+`
+	if got != want {
+		t.Errorf("formatOrgIndex() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// orgPathData holds the values an -org-path-template can use.
+type orgPathData struct {
+	Date     string // the recording date, e.g. 2024-03-05
+	Year     string
+	Month    string
+	Day      string
+	BaseName string // the transcript's file name without its extension
+	Dir      string // the transcript's directory
+	Language string // the -summary-languages code, empty without it
+}
+
+func newOrgPathData(config Config, baseFilePath, language string) orgPathData {
+	date := recordingDate(config)
+	return orgPathData{
+		Date:     date.Format(recordingDateLayout),
+		Year:     date.Format("2006"),
+		Month:    date.Format("01"),
+		Day:      date.Format("02"),
+		BaseName: strings.TrimSuffix(filepath.Base(baseFilePath), filepath.Ext(baseFilePath)),
+		Dir:      filepath.Dir(baseFilePath),
+		Language: language,
+	}
+}
+
+func renderOrgPath(pathTemplate string, data orgPathData) (string, error) {
+	tmpl, err := template.New("org-path").Parse(pathTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parsing -org-path-template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering -org-path-template: %w", err)
+	}
+	path := b.String()
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expanding ~ in -org-path-template: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	return filepath.Clean(path), nil
+}
+
+func checkOrgPathTemplate(config Config) error {
+	if config.OrgPathTemplate == "" {
+		return nil
+	}
+	if !hasPostStep(config, "create_emacs_org_notes") {
+		return errors.New("-org-path-template requires -post create_emacs_org_notes")
+	}
+	if config.OutputURI != "" {
+		return errors.New("-org-path-template names local files and cannot be combined with -output-uri")
+	}
+	sample := orgPathData{Date: "2024-03-05", Year: "2024", Month: "03", Day: "05", BaseName: "talk", Dir: "output"}
+	path, err := renderOrgPath(config.OrgPathTemplate, sample)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(config.OrgPathTemplate, "/") || path == "." {
+		return fmt.Errorf("-org-path-template %q names a directory; end it with a file name such as {{.BaseName}}.org", config.OrgPathTemplate)
+	}
+	if len(summaryLanguages(config)) > 0 {
+		sample.Language = "en"
+		other := sample
+		other.Language = "de"
+		if otherPath, _ := renderOrgPath(config.OrgPathTemplate, other); otherPath == path {
+			return errors.New("-org-path-template must use {{.Language}} with -summary-languages, so each language gets its own file")
+		}
+	}
+	return nil
+}
+
+// templatedOrgPath renders the -org-path-template for the notes of
+// baseFilePath. ok is false without a template.
+func templatedOrgPath(config Config, baseFilePath, language string) (string, bool) {
+	if config.OrgPathTemplate == "" {
+		return "", false
+	}
+	path, err := renderOrgPath(config.OrgPathTemplate, newOrgPathData(config, baseFilePath, language))
+	if err != nil {
+		// checkOrgPathTemplate has already rendered the template once.
+		return "", false
+	}
+	return path, true
+}
+
+// createOrgPathDir creates the directories an -org-path-template path
+// needs, since it may point anywhere.
+func createOrgPathDir(config Config, outputFilePath string) error {
+	if config.OrgPathTemplate == "" || config.NoOutput || config.Stdout {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(outputFilePath), 0o755); err != nil {
+		return fmt.Errorf("creating the -org-path-template directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateOrgFilePathTemplate(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name     string
+		template string
+		language string
+		want     string
+	}{
+		{"no template", "", "", "output/talk_20240305_101500_emacs_org_notes.org"},
+		{"no template with a language", "", "de", "output/talk_20240305_101500_emacs_org_notes_de.org"},
+		{"dated tree", "~/org/{{.Year}}/{{.Month}}/{{.BaseName}}.org", "", filepath.Join(home, "org/2024/03/talk_20240305_101500.org")},
+		{"next to the transcript", "{{.Dir}}/{{.Date}}-notes.org", "", "output/2024-03-05-notes.org"},
+		{"per language", "notes/{{.Language}}/{{.Day}}.org", "de", "notes/de/05.org"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{OrgPathTemplate: tt.template, RecordingDate: "2024-03-05"}
+			var got string
+			if tt.language == "" {
+				got = generateOrgFilePath(config, "output/talk_20240305_101500.txt")
+			} else {
+				got = generateOrgLanguageFilePath(config, "output/talk_20240305_101500.txt", tt.language)
+			}
+			if got != tt.want {
+				t.Errorf("org path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOrgPathTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"valid", Config{OrgPathTemplate: "~/org/{{.Year}}/{{.BaseName}}.org", PostProcessCmd: "create_emacs_org_notes"}, ""},
+		{"other post command", Config{OrgPathTemplate: "{{.BaseName}}.org", PostProcessCmd: "create_glossary"}, "requires -post create_emacs_org_notes"},
+		{"unknown field", Config{OrgPathTemplate: "{{.Title}}.org", PostProcessCmd: "create_emacs_org_notes"}, "rendering -org-path-template"},
+		{"syntax", Config{OrgPathTemplate: "{{.BaseName.org", PostProcessCmd: "create_emacs_org_notes"}, "parsing -org-path-template"},
+		{"directory", Config{OrgPathTemplate: "~/org/{{.Year}}/", PostProcessCmd: "create_emacs_org_notes"}, "names a directory"},
+		{"languages without .Language", Config{OrgPathTemplate: "{{.BaseName}}.org", PostProcessCmd: "create_emacs_org_notes", SummaryLanguages: "en,de"}, "must use {{.Language}}"},
+		{"object storage", Config{OrgPathTemplate: "{{.BaseName}}.org", PostProcessCmd: "create_emacs_org_notes", OutputURI: "s3://notes"}, "cannot be combined with -output-uri"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOrgPathTemplate(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkOrgPathTemplate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkOrgPathTemplate() = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateOrgPathDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2024", "03", "talk.org")
+	if err := createOrgPathDir(Config{OrgPathTemplate: "x"}, path); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		t.Errorf("createOrgPathDir() did not create %s: %v", filepath.Dir(path), err)
+	}
+}
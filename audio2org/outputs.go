@@ -0,0 +1,239 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type outputTarget struct {
+	Feature string
+	Path    string
+}
+
+// planOutputs lists every path the run will write, given the transcript
+// path that processTranscription settled on. Derived outputs are always
+// named from that path, so -output and -title-from-content take precedence
+// over the post-processing suffixes.
+func planOutputs(config Config, transcriptPath string) []outputTarget {
+	var targets []outputTarget
+
+	if config.AudioFilePath != "" && !config.NoTranscriptFile {
+		targets = append(targets, outputTarget{"transcript", transcriptPath})
+		if config.WordTimestamps {
+			targets = append(targets, outputTarget{"word timestamps", generateWordsFilePath(transcriptPath)})
+		}
+	} else if config.RedactPII {
+		targets = append(targets, outputTarget{"redacted transcript", redactedTranscriptPath(transcriptPath)})
+	}
+
+	for _, step := range postSteps(config) {
+		switch step {
+		case "create_emacs_org_notes":
+			languages := summaryLanguages(config)
+			if len(languages) == 0 {
+				targets = append(targets, outputTarget{"org notes", generateOrgFilePath(config, transcriptPath)})
+			}
+			for _, language := range languages {
+				targets = append(targets, outputTarget{language + " org notes", generateOrgLanguageFilePath(config, transcriptPath, language)})
+			}
+		case "create_markdown_notes":
+			targets = append(targets, outputTarget{"markdown notes", generateMarkdownFilePath(transcriptPath)})
+		case "create_glossary":
+			targets = append(targets, outputTarget{"glossary", generateGlossaryFilePath(transcriptPath, writesMarkdownNotes(config))})
+		case "create_json_summary":
+			targets = append(targets, outputTarget{"JSON summary", generateDerivedFilePath(transcriptPath, "_summary.json")})
+		case "create_topic_org":
+			targets = append(targets, outputTarget{"topic org", generateDerivedFilePath(transcriptPath, "_topics.org")})
+		case "create_chapters":
+			targets = append(targets,
+				outputTarget{"VTT chapters", generateDerivedFilePath(transcriptPath, "_chapters.vtt")},
+				outputTarget{"text chapters", generateDerivedFilePath(transcriptPath, "_chapters.txt")})
+		case "create_org_transcript":
+			targets = append(targets, outputTarget{"org transcript", generateDerivedFilePath(transcriptPath, "_transcript.org")})
+		case "create_flashcards":
+			targets = append(targets, outputTarget{"flashcards", generateDerivedFilePath(transcriptPath, "_cards.tsv")})
+		}
+	}
+
+	if config.InlineSummary {
+		targets = append(targets, outputTarget{"inline summary", generateDerivedFilePath(transcriptPath, "_inline.org")})
+	}
+
+	if config.KeepRawResponse {
+		for _, path := range chatResponsePaths(config, transcriptPath) {
+			targets = append(targets, outputTarget{"raw chat response", path})
+		}
+	}
+
+	if config.DebugBundleDir != "" {
+		targets = append(targets, outputTarget{"debug bundle", config.DebugBundleDir})
+	}
+
+	return targets
+}
+
+// chatResponsePaths lists the path of each chat response
+// -keep-raw-response saves.
+func chatResponsePaths(config Config, transcriptPath string) []string {
+	var paths []string
+	for _, step := range postStepConfigs(config) {
+		switch step.PostProcessCmd {
+		case "create_org_transcript":
+		case "create_markdown_notes":
+			if step.SummarizerCmd == "" {
+				paths = append(paths, chatResponsePath(step, transcriptPath, ""))
+			}
+		case "create_emacs_org_notes":
+			if step.SummarizerCmd != "" {
+				break
+			}
+			languages := summaryLanguages(step)
+			if len(languages) == 0 {
+				paths = append(paths, chatResponsePath(step, transcriptPath, ""))
+			}
+			for _, language := range languages {
+				paths = append(paths, chatResponsePath(step, transcriptPath, "_"+language))
+			}
+		default:
+			paths = append(paths, chatResponsePath(step, transcriptPath, ""))
+		}
+	}
+	if config.InlineSummary {
+		paths = append(paths, chatResponsePath(config, transcriptPath, "_inline"))
+	}
+	return paths
+}
+
+// primaryOutput is the file -open shows: the first post-processing output,
+// or the transcript when there is none.
+func primaryOutput(config Config, transcriptPath string) string {
+	for _, target := range planOutputs(config, transcriptPath) {
+		if target.Feature != "transcript" && target.Feature != "debug bundle" {
+			return target.Path
+		}
+	}
+	return transcriptPath
+}
+
+func checkOutputCollisions(config Config, transcriptPath string) error {
+	seen := map[string]string{}
+	if config.AudioFilePath != "" {
+		seen[filepath.Clean(config.AudioFilePath)] = "-file input"
+	}
+	if config.TranscriptionFilePath != "" {
+		seen[filepath.Clean(config.TranscriptionFilePath)] = "-transcription input"
+	}
+
+	for _, target := range planOutputs(config, transcriptPath) {
+		path := filepath.Clean(target.Path)
+		if other, ok := seen[path]; ok {
+			return fmt.Errorf("%s and %s both resolve to %s; choose a different -output or input name", other, target.Feature, path)
+		}
+		seen[path] = target.Feature
+	}
+	return nil
+}
+
+// audioOutputName is the default transcript name for an audio input: its
+// base name with the -format extension, so meeting-2024.mp3 is transcribed
+// to meeting-2024.txt, and every output is named from that.
+func audioOutputName(config Config, audioFilePath string) string {
+	base := filepath.Base(audioFilePath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + transcriptExtension(config.Format)
+}
+
+// versionOutputPath applies -versioning to the transcript path that all of
+// the run's outputs are named from. Without -versioning, new transcripts get
+// a timestamp and reprocessed outputs overwrite the previous ones.
+func versionOutputPath(config Config, transcriptPath string) string {
+	switch outputVersioning(config) {
+	case "overwrite":
+		return transcriptPath
+	case "timestamp":
+		return generateTimestampedFilePath(filepath.Dir(transcriptPath), filepath.Base(transcriptPath))
+	case "increment":
+		return nextFreeVersion(config, transcriptPath)
+	}
+
+	if config.AudioFilePath != "" {
+		return generateTimestampedFilePath(filepath.Dir(transcriptPath), filepath.Base(transcriptPath))
+	}
+	return transcriptPath
+}
+
+// nextFreeVersion returns transcriptPath, or the first of its _v2, _v3, ...
+// variants, for which none of the run's outputs exist yet.
+func nextFreeVersion(config Config, transcriptPath string) string {
+	ext := filepath.Ext(transcriptPath)
+	stem := strings.TrimSuffix(transcriptPath, ext)
+
+	for n := 1; ; n++ {
+		candidate := transcriptPath
+		if n > 1 {
+			candidate = fmt.Sprintf("%s_v%d%s", stem, n, ext)
+		}
+		if !outputsExist(config, candidate) {
+			return candidate
+		}
+	}
+}
+
+func outputsExist(config Config, transcriptPath string) bool {
+	for _, target := range planOutputs(config, transcriptPath) {
+		// The -append file is shared by every run, so it never names this
+		// one's outputs.
+		if target.Feature == "debug bundle" || (config.Append != "" && target.Path == config.Append) {
+			continue
+		}
+		if _, err := os.Stat(target.Path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// outputVersioning is the -versioning of the run, with -overwrite and
+// -unique standing for overwrite and increment.
+func outputVersioning(config Config) string {
+	switch {
+	case config.Overwrite:
+		return "overwrite"
+	case config.Unique:
+		return "increment"
+	}
+	return config.Versioning
+}
+
+func checkOverwrite(config Config) error {
+	switch {
+	case config.Overwrite && config.Unique:
+		return errors.New("-overwrite and -unique cannot be combined")
+	case (config.Overwrite || config.Unique) && config.Versioning != "":
+		return errors.New("-overwrite and -unique cannot be combined with -versioning, which they set")
+	case config.Unique && config.OutputURI != "":
+		return errors.New("-unique cannot check for existing objects with -output-uri")
+	}
+	return nil
+}
+
+// checkExistingOutputs refuses to replace a file an earlier run wrote,
+// unless -overwrite, -versioning overwrite, or -resume, which picks up
+// where that run stopped, says to. Object storage is not checked.
+func checkExistingOutputs(config Config, transcriptPath string) error {
+	if outputVersioning(config) == "overwrite" || config.Resume != "" || config.NoOutput || config.OutputURI != "" {
+		return nil
+	}
+	for _, target := range planOutputs(config, transcriptPath) {
+		// The -append file is added to, not replaced.
+		if target.Feature == "debug bundle" || (config.Append != "" && target.Path == config.Append) {
+			continue
+		}
+		if _, err := os.Stat(target.Path); err == nil {
+			return fmt.Errorf("the %s %s already exists; pass -overwrite to replace it, or -unique to write the outputs under the next free _v2, _v3, ... name", target.Feature, target.Path)
+		}
+	}
+	return nil
+}
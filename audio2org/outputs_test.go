@@ -0,0 +1,256 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckOutputCollisions(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         Config
+		transcriptPath string
+		wantErr        string
+	}{
+		{
+			name:           "audio only",
+			config:         Config{AudioFilePath: "talk.mp3"},
+			transcriptPath: "output/transcription_20240101_120000.txt",
+		},
+		{
+			name:           "audio with org notes",
+			config:         Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_emacs_org_notes"},
+			transcriptPath: "output/transcription_20240101_120000.txt",
+		},
+		{
+			name:           "audio with glossary",
+			config:         Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_glossary"},
+			transcriptPath: "output/transcription_20240101_120000.txt",
+		},
+		{
+			name:           "existing transcript with org notes",
+			config:         Config{TranscriptionFilePath: "notes/talk.txt", PostProcessCmd: "create_emacs_org_notes"},
+			transcriptPath: "notes/talk.txt",
+		},
+		{
+			name:           "org notes in several languages",
+			config:         Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_emacs_org_notes", SummaryLanguages: "en, es"},
+			transcriptPath: "output/transcription_20240101_120000.txt",
+		},
+		{
+			name:           "repeated summary language",
+			config:         Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_emacs_org_notes", SummaryLanguages: "en,es,en"},
+			transcriptPath: "output/talk.txt",
+			wantErr:        "en org notes and en org notes",
+		},
+		{
+			name:           "output name matching the audio input",
+			config:         Config{AudioFilePath: "output/talk.txt"},
+			transcriptPath: "output/talk.txt",
+			wantErr:        "-file input and transcript",
+		},
+		{
+			name: "titled notes overwriting the input transcript",
+			config: Config{
+				TranscriptionFilePath: "notes/standup_emacs_org_notes.org",
+				PostProcessCmd:        "create_emacs_org_notes",
+				TitleFromContent:      true,
+			},
+			transcriptPath: "notes/standup.org",
+			wantErr:        "-transcription input and org notes",
+		},
+		{
+			name: "debug bundle pointed at the notes file",
+			config: Config{
+				AudioFilePath:  "talk.mp3",
+				PostProcessCmd: "create_emacs_org_notes",
+				DebugBundleDir: "output/talk_emacs_org_notes.org",
+			},
+			transcriptPath: "output/talk.txt",
+			wantErr:        "org notes and debug bundle",
+		},
+		{
+			name: "debug bundle pointed at the transcript",
+			config: Config{
+				AudioFilePath:  "talk.mp3",
+				DebugBundleDir: "./output/talk.txt",
+			},
+			transcriptPath: "output/talk.txt",
+			wantErr:        "transcript and debug bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOutputCollisions(tt.config, tt.transcriptPath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextFreeVersion(t *testing.T) {
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "talk.txt")
+	config := Config{TranscriptionFilePath: transcript, PostProcessCmd: "create_emacs_org_notes"}
+
+	if got := nextFreeVersion(config, transcript); got != transcript {
+		t.Errorf("with no outputs, nextFreeVersion() = %s, want %s", got, transcript)
+	}
+
+	for _, name := range []string{"talk_emacs_org_notes.org", "talk_v2_emacs_org_notes.org"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := nextFreeVersion(config, transcript), filepath.Join(dir, "talk_v3.txt"); got != want {
+		t.Errorf("nextFreeVersion() = %s, want %s", got, want)
+	}
+}
+
+func TestAudioOutputName(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		want   string
+	}{
+		{"recordings/meeting-2024.mp3", "text", "meeting-2024.txt"},
+		{"talk.final.m4a", "srt", "talk.final.srt"},
+		{"notes", "json", "notes.json"},
+	}
+
+	for _, tt := range tests {
+		if got := audioOutputName(Config{Format: tt.format}, tt.file); got != tt.want {
+			t.Errorf("audioOutputName(%q, %s) = %q, want %q", tt.file, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestPrimaryOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"transcript only", Config{AudioFilePath: "talk.mp3", DebugBundleDir: "debug"}, "output/talk.txt"},
+		{"org notes", Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_emacs_org_notes"}, "output/talk_emacs_org_notes.org"},
+		{"first language", Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_emacs_org_notes", SummaryLanguages: "es,en"}, "output/talk_emacs_org_notes_es.org"},
+		{"markdown notes", Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_markdown_notes"}, "output/talk_notes.md"},
+		{"first of several commands", Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_glossary, create_emacs_org_notes"}, "output/talk_glossary.org"},
+		{"glossary with markdown notes", Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_glossary,create_markdown_notes"}, "output/talk_glossary.md"},
+		{"existing transcript", Config{TranscriptionFilePath: "output/talk.txt"}, "output/talk.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := primaryOutput(tt.config, "output/talk.txt"); got != tt.want {
+				t.Errorf("primaryOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawChatResponseOutputs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{"off", Config{PostProcessCmd: "create_glossary"}, nil},
+		{"glossary", Config{PostProcessCmd: "create_glossary", KeepRawResponse: true}, []string{"output/talk_chat_response.json"}},
+		{"org transcript makes no chat request", Config{PostProcessCmd: "create_org_transcript", KeepRawResponse: true}, nil},
+		{
+			name:   "notes in two languages with inline summary",
+			config: Config{PostProcessCmd: "create_emacs_org_notes", SummaryLanguages: "en,de", InlineSummary: true, KeepRawResponse: true},
+			want:   []string{"output/talk_en_chat_response.json", "output/talk_de_chat_response.json", "output/talk_inline_chat_response.json"},
+		},
+		{"notes from -summarizer-cmd", Config{PostProcessCmd: "create_emacs_org_notes", SummarizerCmd: "ollama run llama3", KeepRawResponse: true}, nil},
+		{"markdown notes from -summarizer-cmd", Config{PostProcessCmd: "create_markdown_notes", SummarizerCmd: "ollama run llama3", KeepRawResponse: true}, nil},
+		{
+			name:   "several commands",
+			config: Config{PostProcessCmd: "create_glossary,create_org_transcript,create_flashcards", KeepRawResponse: true},
+			want:   []string{"output/talk_glossary_chat_response.json", "output/talk_flashcards_chat_response.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, target := range planOutputs(tt.config, "output/talk.txt") {
+				if target.Feature == "raw chat response" {
+					got = append(got, target.Path)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("raw chat response outputs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckExistingOutputs(t *testing.T) {
+	dir := t.TempDir()
+	transcriptPath := filepath.Join(dir, "talk.txt")
+	if err := os.WriteFile(transcriptPath, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "talk_glossary.org"), []byte("* Glossary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"existing output", Config{TranscriptionFilePath: transcriptPath, PostProcessCmd: "create_glossary"}, "talk_glossary.org already exists"},
+		{"new output", Config{TranscriptionFilePath: transcriptPath, PostProcessCmd: "create_topic_org"}, ""},
+		{"existing transcript", Config{AudioFilePath: "talk.mp3"}, "transcript " + transcriptPath + " already exists"},
+		{"-overwrite", Config{AudioFilePath: "talk.mp3", Overwrite: true}, ""},
+		{"-versioning overwrite", Config{AudioFilePath: "talk.mp3", Versioning: "overwrite"}, ""},
+		{"-resume", Config{AudioFilePath: "talk.mp3", Resume: "state.json"}, ""},
+		{"-no-output", Config{AudioFilePath: "talk.mp3", NoOutput: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExistingOutputs(tt.config, transcriptPath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkExistingOutputs() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkExistingOutputs() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUniqueVersioning(t *testing.T) {
+	dir := t.TempDir()
+	transcriptPath := filepath.Join(dir, "talk.txt")
+	if err := os.WriteFile(transcriptPath, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{AudioFilePath: "talk.mp3", Unique: true}
+	if got, want := versionOutputPath(config, transcriptPath), filepath.Join(dir, "talk_v2.txt"); got != want {
+		t.Errorf("versionOutputPath() with -unique = %s, want %s", got, want)
+	}
+	if err := checkOverwrite(Config{Unique: true, Overwrite: true}); err == nil {
+		t.Error("checkOverwrite() accepted -overwrite with -unique")
+	}
+	if err := checkOverwrite(Config{Overwrite: true, Versioning: "timestamp"}); err == nil {
+		t.Error("checkOverwrite() accepted -overwrite with -versioning")
+	}
+}
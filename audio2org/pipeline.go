@@ -0,0 +1,77 @@
+package audio2org
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// postSteps lists the -post commands in the order they run. -post takes a
+// comma-separated list, and each step reads the transcript: the prompts are
+// written for a transcript, so no step is given another step's notes.
+func postSteps(config Config) []string {
+	var steps []string
+	for _, step := range strings.Split(config.PostProcessCmd, ",") {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+func hasPostStep(config Config, command string) bool {
+	return slices.Contains(postSteps(config), command)
+}
+
+// writesMarkdownNotes reports whether the notes of the run are Markdown,
+// which the other text outputs follow: the first notes command in -post is
+// create_markdown_notes.
+func writesMarkdownNotes(config Config) bool {
+	for _, step := range postSteps(config) {
+		switch step {
+		case "create_emacs_org_notes":
+			return false
+		case "create_markdown_notes":
+			return true
+		}
+	}
+	return false
+}
+
+// postStepConfigs returns a copy of config for each -post step, with -post
+// narrowed to that step, so the code for a single step runs unchanged.
+func postStepConfigs(config Config) []Config {
+	steps := postSteps(config)
+	configs := make([]Config, len(steps))
+	for i, step := range steps {
+		configs[i] = config
+		configs[i].PostProcessCmd = step
+		configs[i].postPipeline = len(steps) > 1
+		configs[i].markdownNotes = writesMarkdownNotes(config)
+	}
+	return configs
+}
+
+// checkPostSteps rejects unknown and repeated -post commands before
+// anything is uploaded, so a typo in the last step does not surface only
+// after the first steps have spent their chat requests. name is the flag or
+// variable the list came from.
+func checkPostSteps(name, post string) error {
+	if strings.TrimSpace(post) == "" {
+		return nil
+	}
+	var seen []string
+	for _, step := range strings.Split(post, ",") {
+		step = strings.TrimSpace(step)
+		switch {
+		case step == "":
+			return fmt.Errorf("%s %q has an empty command; separate the commands with single commas", name, post)
+		case !slices.Contains(postCommands, step):
+			return fmt.Errorf("unknown %s command %q: expected one of %s", name, step, strings.Join(postCommands, ", "))
+		case slices.Contains(seen, step):
+			return fmt.Errorf("%s lists %s twice", name, step)
+		}
+		seen = append(seen, step)
+	}
+	return nil
+}
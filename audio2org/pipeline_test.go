@@ -0,0 +1,53 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostSteps(t *testing.T) {
+	tests := []struct {
+		post string
+		want []string
+	}{
+		{"", nil},
+		{"create_glossary", []string{"create_glossary"}},
+		{"create_emacs_org_notes, create_flashcards", []string{"create_emacs_org_notes", "create_flashcards"}},
+	}
+	for _, tt := range tests {
+		got := postSteps(Config{PostProcessCmd: tt.post})
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("postSteps(%q) = %q, want %q", tt.post, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPostSteps(t *testing.T) {
+	tests := []struct {
+		post    string
+		wantErr string
+	}{
+		{"", ""},
+		{"create_emacs_org_notes,create_flashcards", ""},
+		{"create_glosary", `unknown -post command "create_glosary"`},
+		{"create_glossary,,create_topic_org", "has an empty command"},
+		{"create_glossary,create_topic_org,create_glossary", "-post lists create_glossary twice"},
+	}
+	for _, tt := range tests {
+		err := checkPostSteps("-post", tt.post)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("checkPostSteps(%q) = %v, want %q", tt.post, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNeedsAPIKeyForSteps(t *testing.T) {
+	config := Config{Backend: "local", PostProcessCmd: "create_org_transcript"}
+	if needsAPIKey(config) {
+		t.Error("needsAPIKey() = true for create_org_transcript alone")
+	}
+	config.PostProcessCmd = "create_org_transcript,create_glossary"
+	if !needsAPIKey(config) {
+		t.Error("needsAPIKey() = false with create_glossary in the list")
+	}
+}
@@ -0,0 +1,28 @@
+package audio2org
+
+import "sync"
+
+// runPool calls work for each index from 0 to count-1 on up to n goroutines
+// and returns the errors in index order.
+func runPool(n, count int, work func(i int) error) []error {
+	errs := make([]error, count)
+	jobs := make(chan int, n)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(n, count); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = work(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,39 @@
+package audio2org
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunPool(t *testing.T) {
+	var running, peak atomic.Int32
+	errs := runPool(3, 10, func(i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if i%4 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if len(errs) != 10 {
+		t.Fatalf("runPool() returned %d results, want 10", len(errs))
+	}
+	for i, err := range errs {
+		if (err != nil) != (i%4 == 0) {
+			t.Errorf("result %d = %v", i, err)
+		}
+	}
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Errorf("peak concurrency = %d, want 2 or 3", p)
+	}
+}
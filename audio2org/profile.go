@@ -0,0 +1,51 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling starts the CPU profile and returns a function that stops
+// it and writes the heap profile. Errors at stop time are only logged, since
+// the run itself is already over.
+func startProfiling(config Config) (func(), error) {
+	var cpuFile *os.File
+	if config.CPUProfile != "" {
+		f, err := os.Create(config.CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("starting CPU profile: %w", err)
+		}
+		cpuFile = f
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+			log.Printf("CPU profile written to %s\n", config.CPUProfile)
+		}
+
+		if config.MemProfile != "" {
+			f, err := os.Create(config.MemProfile)
+			if err != nil {
+				log.Printf("Error creating memory profile: %v\n", err)
+				return
+			}
+			defer f.Close()
+
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				log.Printf("Error writing memory profile: %v\n", err)
+				return
+			}
+			log.Printf("Memory profile written to %s\n", config.MemProfile)
+		}
+	}, nil
+}
@@ -0,0 +1,36 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartProfilingWritesBothProfiles(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{CPUProfile: filepath.Join(dir, "cpu.pprof"), MemProfile: filepath.Join(dir, "mem.pprof")}
+
+	stop, err := startProfiling(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	for _, path := range []string{config.CPUProfile, config.MemProfile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("%s is empty", path)
+		}
+	}
+}
+
+func TestStartProfilingRejectsAnUnwritableCPUProfile(t *testing.T) {
+	config := Config{CPUProfile: filepath.Join(t.TempDir(), "missing", "cpu.pprof")}
+	if _, err := startProfiling(config); err == nil || !strings.Contains(err.Error(), "creating CPU profile") {
+		t.Errorf("startProfiling() error = %v, want the CPU profile to fail", err)
+	}
+}
@@ -0,0 +1,104 @@
+package audio2org
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const spinnerInterval = 100 * time.Millisecond
+
+// When stderr is not a terminal, a line is logged this often instead of
+// drawing a spinner.
+var progressLogInterval = 30 * time.Second
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// startProgress shows that a request to label is still running until the
+// returned function is called: a spinner with the elapsed time when stderr
+// is a terminal, or a periodic log line when it is redirected.
+func startProgress(label string) func() {
+	if isTerminal(os.Stderr) && !lineOrientedLogs {
+		return startSpinner(os.Stderr, label)
+	}
+	return startProgressLog(label, progressLogInterval)
+}
+
+func startProgressLog(label string, interval time.Duration) func() {
+	start := time.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				log.Printf("Still waiting for %s (%s elapsed)...\n", label, time.Since(start).Round(time.Second))
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// startSpinner redraws a spinner line on w. While it runs, log output is
+// routed through a writer that clears the line first, so retry messages are
+// not appended to the spinner.
+func startSpinner(w io.Writer, label string) func() {
+	var mu sync.Mutex
+	previous := log.Writer()
+	log.SetOutput(lineClearingWriter{term: w, w: previous, mu: &mu})
+
+	start := time.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			mu.Lock()
+			fmt.Fprintf(w, "\r\033[K%s Waiting for %s... %s", spinnerFrames[frame%len(spinnerFrames)], label, time.Since(start).Round(time.Second))
+			mu.Unlock()
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		mu.Lock()
+		fmt.Fprint(w, "\r\033[K")
+		mu.Unlock()
+		log.SetOutput(previous)
+	}
+}
+
+// lineClearingWriter clears the spinner line on term before passing each
+// log line on to w.
+type lineClearingWriter struct {
+	term io.Writer
+	w    io.Writer
+	mu   *sync.Mutex
+}
+
+func (c lineClearingWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := io.WriteString(c.term, "\r\033[K"); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
@@ -0,0 +1,63 @@
+package audio2org
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLog sends the standard logger to buf, without timestamps, for the
+// rest of the test.
+func captureLog(t *testing.T, buf *bytes.Buffer) {
+	flags := log.Flags()
+	log.SetOutput(buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	})
+}
+
+func TestStartProgressLog(t *testing.T) {
+	var logs bytes.Buffer
+	captureLog(t, &logs)
+
+	stop := startProgressLog("Whisper API", 10*time.Millisecond)
+	time.Sleep(35 * time.Millisecond)
+	stop()
+	lines := strings.Count(logs.String(), "Still waiting for Whisper API")
+	if lines == 0 {
+		t.Fatalf("no progress lines logged, got %q", logs.String())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if after := strings.Count(logs.String(), "Still waiting"); after != lines {
+		t.Errorf("%d progress lines logged after stop", after-lines)
+	}
+}
+
+func TestStartSpinner(t *testing.T) {
+	var out bytes.Buffer
+	captureLog(t, &out)
+
+	stop := startSpinner(&out, "OpenAI API")
+	log.Print("Request failed, retrying")
+	stop()
+
+	got := out.String()
+	if !strings.Contains(got, "\r\033[K| Waiting for OpenAI API... 0s") {
+		t.Errorf("spinner line missing from %q", got)
+	}
+	if !strings.Contains(got, "\r\033[KRequest failed, retrying\n") {
+		t.Errorf("log line was not written on a cleared line: %q", got)
+	}
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("spinner line not cleared on stop: %q", got)
+	}
+	if log.Writer() != &out {
+		t.Error("log output not restored after stop")
+	}
+}
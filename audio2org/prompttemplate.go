@@ -0,0 +1,122 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// promptTemplateData holds the values a -prompt-template can use.
+type promptTemplateData struct {
+	Transcription string
+	Date          string // the recording date as an active org timestamp, e.g. <2024-01-01 Mon>
+}
+
+const promptTemplateSample = "\x00transcription\x00"
+
+// loadPromptTemplate parses the -prompt-template file and renders it once
+// with placeholder values, so unknown fields and a template that leaves out
+// the transcription are caught before anything is sent to the API.
+func loadPromptTemplate(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading -prompt-template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing -prompt-template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, promptTemplateData{Transcription: promptTemplateSample, Date: orgPromptDate(time.Now())}); err != nil {
+		return nil, fmt.Errorf("checking -prompt-template: %w", err)
+	}
+	if !strings.Contains(b.String(), promptTemplateSample) {
+		return nil, errors.New("-prompt-template never includes the transcript; add {{.Transcription}} where it should go")
+	}
+	return tmpl, nil
+}
+
+// orgNotesPrompt is the create_emacs_org_notes user message for the
+// transcript: the -prompt-template if one was given, or the transcript
+// alone, with the instructions in the system prompt.
+func orgNotesPrompt(config Config, transcriptionText string) (string, error) {
+	if config.promptTemplate == nil {
+		return transcriptionText, nil
+	}
+
+	var b strings.Builder
+	if err := config.promptTemplate.Execute(&b, promptTemplateData{Transcription: transcriptionText, Date: orgPromptDate(recordingDate(config))}); err != nil {
+		return "", fmt.Errorf("rendering -prompt-template: %w", err)
+	}
+	return b.String(), nil
+}
+
+func orgPromptDate(date time.Time) string {
+	return date.Format("<2006-01-02 Mon>")
+}
+
+// orgNotesSystemPrompt is the create_emacs_org_notes system prompt: the
+// -system-prompt, none with a -prompt-template, which carries its own
+// instructions, or the built-in one.
+func orgNotesSystemPrompt(config Config) string {
+	switch {
+	case config.SystemPrompt != "":
+		return config.SystemPrompt
+	case config.promptTemplate != nil:
+		return ""
+	}
+	return defaultOrgSystemPrompt(recordingDate(config), config.DetailLevel)
+}
+
+// loadSystemPrompt returns the -system-prompt, or the contents of
+// -system-prompt-file.
+func loadSystemPrompt(config Config) (string, error) {
+	if config.SystemPromptFile == "" {
+		return config.SystemPrompt, nil
+	}
+	if config.SystemPrompt != "" {
+		return "", errors.New("specify only one of -system-prompt or -system-prompt-file")
+	}
+	data, err := os.ReadFile(config.SystemPromptFile)
+	if err != nil {
+		return "", fmt.Errorf("reading -system-prompt-file: %w", err)
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return "", errors.New("-system-prompt-file is empty")
+	}
+	return prompt, nil
+}
+
+// loadPromptContext returns the -context, or the contents of -context-file.
+func loadPromptContext(config Config) (string, error) {
+	if config.ContextFile == "" {
+		return strings.TrimSpace(config.Context), nil
+	}
+	if config.Context != "" {
+		return "", errors.New("specify only one of -context or -context-file")
+	}
+	data, err := os.ReadFile(config.ContextFile)
+	if err != nil {
+		return "", fmt.Errorf("reading -context-file: %w", err)
+	}
+	context := strings.TrimSpace(string(data))
+	if context == "" {
+		return "", errors.New("-context-file is empty")
+	}
+	return context, nil
+}
+
+// contextMessage is the -context as a system message of its own, sent
+// after the instructions so it neither replaces nor is lost in them.
+func contextMessage(config Config) map[string]string {
+	return map[string]string{
+		"role":    "system",
+		"content": "Background on the recording, to use where it helps make sense of the transcript:\n\n" + config.Context,
+	}
+}
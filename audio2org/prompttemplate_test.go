@@ -0,0 +1,176 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPromptTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{"transcription and date", "Notes for {{.Date}} with an Action Items section:\n\n{{.Transcription}}", ""},
+		{"syntax error", "Summarize {{.Transcription", "parsing -prompt-template"},
+		{"unknown field", "{{.Transcript}}", "checking -prompt-template"},
+		{"no transcription", "Summarize the recording as org.", "never includes the transcript"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prompt.tmpl")
+			if err := os.WriteFile(path, []byte(tt.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			tmpl, err := loadPromptTemplate(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadPromptTemplate() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prompt, err := orgNotesPrompt(Config{promptTemplate: tmpl}, "we shipped the release")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(prompt, "\n\nwe shipped the release") || !strings.Contains(prompt, orgPromptDate(time.Now())) {
+				t.Errorf("orgNotesPrompt() = %q", prompt)
+			}
+		})
+	}
+}
+
+func TestOrgNotesSystemPrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("Summarize:\n\n{{.Transcription}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := loadPromptTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := Config{RecordingDate: "2024-03-05"}
+	if got := orgNotesSystemPrompt(config); !strings.Contains(got, "<2024-03-05 Tue>") {
+		t.Errorf("default system prompt = %q, want the recording date in it", got)
+	}
+	if got, _ := orgNotesPrompt(config, "we shipped it"); got != "we shipped it" {
+		t.Errorf("user message = %q, want the transcript alone", got)
+	}
+	if got := orgNotesSystemPrompt(Config{promptTemplate: tmpl}); got != "" {
+		t.Errorf("system prompt with -prompt-template = %q, want none", got)
+	}
+	if got := orgNotesSystemPrompt(Config{promptTemplate: tmpl, SystemPrompt: "Write org."}); got != "Write org." {
+		t.Errorf("system prompt = %q, want the -system-prompt", got)
+	}
+}
+
+func TestLoadSystemPrompt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "system.txt")
+	if err := os.WriteFile(path, []byte("Write org notes.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte("\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr string
+	}{
+		{"flag", Config{SystemPrompt: "Be brief."}, "Be brief.", ""},
+		{"file", Config{SystemPromptFile: path}, "Write org notes.", ""},
+		{"both", Config{SystemPrompt: "Be brief.", SystemPromptFile: path}, "", "only one of"},
+		{"missing file", Config{SystemPromptFile: filepath.Join(dir, "missing.txt")}, "", "reading -system-prompt-file"},
+		{"empty file", Config{SystemPromptFile: empty}, "", "is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadSystemPrompt(tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadSystemPrompt() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("loadSystemPrompt() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPromptContext(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "context.txt")
+	if err := os.WriteFile(path, []byte("Project Apollo; say \"lander\", not \"module\".\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr string
+	}{
+		{"none", Config{}, "", ""},
+		{"flag", Config{Context: " Project Apollo. "}, "Project Apollo.", ""},
+		{"file", Config{ContextFile: path}, `Project Apollo; say "lander", not "module".`, ""},
+		{"both", Config{Context: "Apollo", ContextFile: path}, "", "only one of"},
+		{"missing file", Config{ContextFile: filepath.Join(dir, "missing.txt")}, "", "reading -context-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadPromptContext(tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadPromptContext() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("loadPromptContext() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateNotesContext(t *testing.T) {
+	var messages []struct{ Role, Content string }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct{ Role, Content string } `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		messages = body.Messages
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "* Notes"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL + "/v1", RetryLog: "quiet", Context: "Project Apollo."}
+	if _, err := generateNotes(config, "Write org notes.", "transcript", ".org", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("sent %d messages, want the system prompt, the context, and the transcript", len(messages))
+	}
+	if messages[0].Content != "Write org notes." || messages[1].Role != "system" || !strings.HasSuffix(messages[1].Content, "Project Apollo.") || messages[2].Content != "transcript" {
+		t.Errorf("messages = %+v", messages)
+	}
+}
@@ -0,0 +1,32 @@
+package audio2org
+
+// providerQuirks is how a -provider's chat endpoint differs from OpenAI's.
+type providerQuirks struct {
+	// omitFields are chat body fields the provider rejects, which are
+	// dropped before the request is sent.
+	omitFields []string
+	// contentType is the Content-Type of chat requests.
+	contentType string
+}
+
+// providers are the -provider presets. compatible is for servers that
+// implement the OpenAI API only in part, such as local model servers,
+// which tend to reject fields they do not know and to want the charset
+// spelled out.
+var providers = map[string]providerQuirks{
+	"openai":     {contentType: "application/json"},
+	"compatible": {omitFields: []string{"seed", "n", "stream_options"}, contentType: "application/json; charset=utf-8"},
+}
+
+// shapeChatRequest drops the fields the -provider rejects from reqBody and
+// returns the Content-Type to send it with.
+func shapeChatRequest(config Config, reqBody map[string]interface{}) string {
+	quirks, ok := providers[config.Provider]
+	if !ok {
+		quirks = providers["openai"]
+	}
+	for _, field := range quirks.omitFields {
+		delete(reqBody, field)
+	}
+	return quirks.contentType
+}
@@ -0,0 +1,60 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendChatRequestShapesForProvider(t *testing.T) {
+	tests := []struct {
+		provider    string
+		wantSeed    bool
+		contentType string
+	}{
+		{"openai", true, "application/json"},
+		{"compatible", false, "application/json; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			var body map[string]interface{}
+			var contentType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				json.NewDecoder(r.Body).Decode(&body)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "notes"}, "finish_reason": "stop"}]}`))
+			}))
+			defer server.Close()
+
+			config := Config{OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", Provider: tt.provider, Deterministic: true, RetryLog: "quiet"}
+			reqBody := map[string]interface{}{"model": "gpt-4o", "n": 1}
+			if _, err := sendChatRequest(config, reqBody, ""); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := body["seed"]; ok != tt.wantSeed {
+				t.Errorf("seed sent = %v, want %v", ok, tt.wantSeed)
+			}
+			if _, ok := body["n"]; ok != tt.wantSeed {
+				t.Errorf("n sent = %v, want %v", ok, tt.wantSeed)
+			}
+			if body["temperature"] != float64(0) {
+				t.Errorf("temperature = %v, want 0", body["temperature"])
+			}
+			if contentType != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestValidateProvider(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	config := validConfig()
+	config.Provider = "ollama"
+	if err := validateConfig(config); err == nil || err.Error() != `unknown -provider "ollama": expected openai or compatible` {
+		t.Errorf("validateConfig() = %v", err)
+	}
+}
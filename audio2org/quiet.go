@@ -0,0 +1,63 @@
+package audio2org
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const quietChildEnv = "AUDIO2ORG_QUIET_CHILD"
+
+// runQuietly re-runs the tool as a child process with its log output
+// captured, and only replays that output if the child fails. It returns the
+// child's exit code. Running a child rather than buffering in-process means
+// panics in the child are captured too. A SIGTERM is passed on to the child
+// so it can shut down gracefully.
+func runQuietly(grace time.Duration) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 1, fmt.Errorf("locating executable for -quiet-success: %w", err)
+	}
+
+	var logs bytes.Buffer
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), quietChildEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = &logs
+
+	if err := cmd.Start(); err != nil {
+		return 1, fmt.Errorf("running quietly: %w", err)
+	}
+	ctx, _, stopShutdown := handleShutdown(grace)
+	stopWatching := watchChild(ctx, cmd.Process, grace)
+	err = cmd.Wait()
+	stopWatching()
+	stopShutdown()
+	if err == nil {
+		return 0, nil
+	}
+
+	os.Stderr.Write(logs.Bytes())
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return childExitCode(exitErr), nil
+	}
+	return 1, fmt.Errorf("running quietly: %w", err)
+}
+
+// childExitCode returns the exit code of a child that failed, or, as shells
+// report it, 128 plus the signal number when a signal killed it.
+func childExitCode(exitErr *exec.ExitError) int {
+	if code := exitErr.ExitCode(); code >= 0 {
+		return code
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitFailure
+}
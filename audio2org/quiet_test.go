@@ -0,0 +1,72 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// quietTestExit tells the copy of the test binary runQuietly starts how to
+// end: with that exit code, or killed when it is "kill".
+const quietTestExit = "AUDIO2ORG_QUIET_TEST_EXIT"
+
+func TestRunQuietly(t *testing.T) {
+	if os.Getenv(quietChildEnv) != "" {
+		os.Stderr.WriteString("Reading audio file: talk.mp3\n")
+		exit := os.Getenv(quietTestExit)
+		if exit == "kill" {
+			process, _ := os.FindProcess(os.Getpid())
+			process.Kill()
+			time.Sleep(time.Minute)
+		}
+		code, _ := strconv.Atoi(exit)
+		os.Exit(code)
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("a killed process has no signal number on Windows")
+	}
+
+	tests := []struct {
+		name     string
+		exit     string
+		want     int
+		wantLogs bool
+	}{
+		{"success", "0", 0, false},
+		{"failure", "4", 4, true},
+		{"killed by a signal", "kill", 128 + 9, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(quietTestExit, tt.exit)
+			defer func(args []string) { os.Args = args }(os.Args)
+			os.Args = []string{os.Args[0], "-test.run=^TestRunQuietly$"}
+			logs, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func(stderr *os.File) { os.Stderr = stderr }(os.Stderr)
+			os.Stderr = logs
+
+			code, err := runQuietly(time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.want {
+				t.Errorf("runQuietly() = %d, want %d", code, tt.want)
+			}
+			data, err := os.ReadFile(logs.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replayed := strings.Contains(string(data), "Reading audio file: talk.mp3"); replayed != tt.wantLogs {
+				t.Errorf("replayed logs = %q, want them replayed: %v", data, tt.wantLogs)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package audio2org
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// apiLimiter spaces out the API requests of the run for -rpm; nil without
+// it. Like keepTempFiles, it is set once in run.
+var apiLimiter *rateLimiter
+
+// rateLimiter is a token bucket holding one token, refilled every
+// interval, so requests are spread evenly rather than sent in bursts that
+// trip the per-minute limit early in the minute.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rpm float64) *rateLimiter {
+	if rpm <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Minute) / rpm)}
+}
+
+// wait blocks until the next request may be sent, or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	if delay >= time.Second {
+		log.Printf("Waiting %s before the next API request (-rpm)\n", delay.Round(100*time.Millisecond))
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limitAPIRequests makes every attempt, retries included, of the client's
+// requests to the -base-url host wait for apiLimiter. Webhooks and S3
+// uploads are not limited.
+func limitAPIRequests(client *resty.Client, config Config) {
+	base, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return
+	}
+	client.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+		if apiLimiter == nil {
+			return nil
+		}
+		if u, err := url.Parse(r.URL); err != nil || u.Host != base.Host {
+			return nil
+		}
+		return apiLimiter.wait(r.Context())
+	})
+}
@@ -0,0 +1,37 @@
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacing(t *testing.T) {
+	if newRateLimiter(0) != nil {
+		t.Error("newRateLimiter(0) is not nil; -rpm 0 should not limit")
+	}
+	limiter := newRateLimiter(1200)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request goes out at once and the other three 50ms apart.
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond || elapsed > time.Second {
+		t.Errorf("4 requests at 1200 rpm took %s, want about 150ms", elapsed)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	limiter := newRateLimiter(1)
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait() = %v, want context.DeadlineExceeded", err)
+	}
+}
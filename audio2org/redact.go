@@ -0,0 +1,251 @@
+package audio2org
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{1,4}\)[ .-]?)?\d{2,4}(?:[ .-]\d{2,4}){1,4}`)
+)
+
+// piiRule is one pattern -redact-pii and -redact replace with its
+// placeholder. valid, when set, filters the matches, so the card and phone
+// patterns can stay loose without taking every long number.
+type piiRule struct {
+	name, plural string
+	placeholder  string
+	pattern      *regexp.Regexp
+	valid        func(string) bool
+}
+
+// defaultPIIRules run in this order, so card numbers are replaced before
+// the phone pattern can take part of one.
+var defaultPIIRules = []piiRule{
+	{"email address", "email addresses", "[EMAIL]", emailPattern, nil},
+	{"card number", "card numbers", "[CARD]", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), passesLuhn},
+	{"API key", "API keys", "[API_KEY]", regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,})\b`), nil},
+	{"phone number", "phone numbers", "[PHONE]", phonePattern, looksLikePhone},
+}
+
+// Text is sent to the chat redaction pass in pieces of about this many
+// characters, so each reply fits well within the output token limit.
+const redactChunkChars = 8000
+
+// loadPIIRules returns the default rules followed by those of the
+// -redact-patterns file, one regular expression per line, which are
+// replaced with [REDACTED]. Blank lines and lines starting with # are
+// skipped.
+func loadPIIRules(path string) ([]piiRule, error) {
+	rules := append([]piiRule(nil), defaultPIIRules...)
+	if path == "" {
+		return rules, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading -redact-patterns: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("parsing -redact-patterns %s line %d: %w", path, n, err)
+		}
+		rules = append(rules, piiRule{"custom pattern match", "custom pattern matches", "[REDACTED]", pattern, nil})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading -redact-patterns: %w", err)
+	}
+	return rules, nil
+}
+
+// redactTranscription replaces email addresses, card numbers, API keys,
+// phone numbers, and -redact-patterns matches in the transcript and its
+// segments with placeholders, and with -redact-pii-chat also has the model
+// replace names, before anything else sees the text.
+func redactTranscription(config Config, transcription TranscriptionResponse) (TranscriptionResponse, error) {
+	counts := map[string]int{}
+	transcription.Text = redactPII(config.piiRules, transcription.Text, counts)
+	for i := range transcription.Segments {
+		transcription.Segments[i].Text = redactPII(config.piiRules, transcription.Segments[i].Text, nil)
+	}
+	transcription.Subtitles = redactSubtitles(config.piiRules, transcription.Subtitles)
+	logRedactions(config.piiRules, counts, "the transcript")
+
+	if config.RedactPIIChat {
+		text, err := redactNames(config, transcription.Text)
+		if err != nil {
+			return transcription, err
+		}
+		transcription.Text = text
+	}
+	return transcription, nil
+}
+
+// redactForChat applies -redact to the text and segments about to be sent
+// to the chat API. The transcript itself is left as it is, so the local
+// transcript file keeps the original wording.
+func redactForChat(config Config, text string, transcription TranscriptionResponse) (string, TranscriptionResponse) {
+	if !config.Redact {
+		return text, transcription
+	}
+	counts := map[string]int{}
+	text = redactPII(config.piiRules, text, counts)
+	segments := make([]TranscriptionSegment, len(transcription.Segments))
+	for i, segment := range transcription.Segments {
+		segment.Text = redactPII(config.piiRules, segment.Text, nil)
+		segments[i] = segment
+	}
+	transcription.Segments = segments
+	logRedactions(config.piiRules, counts, "the text sent to the chat API")
+	return text, transcription
+}
+
+// redactPII replaces every match of rules in text with the rule's
+// placeholder, and counts the replacements by rule name in counts unless it
+// is nil. A run of digits is only taken for a phone number if it has 7 to
+// 15 digits and either 10 or more of them or phone punctuation, so years
+// and amounts are left alone.
+func redactPII(rules []piiRule, text string, counts map[string]int) string {
+	for _, rule := range rules {
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			if counts != nil {
+				counts[rule.name]++
+			}
+			return rule.placeholder
+		})
+	}
+	return text
+}
+
+// logRedactions logs how many matches of each rule were replaced in what.
+func logRedactions(rules []piiRule, counts map[string]int, what string) {
+	var found []string
+	for _, rule := range rules {
+		// The custom patterns share a name and are counted together.
+		if n := counts[rule.name]; n > 0 {
+			found = append(found, fmt.Sprintf("%d %s", n, plural(n, rule.name, rule.plural)))
+			counts[rule.name] = 0
+		}
+	}
+	if len(found) == 0 {
+		log.Printf("Nothing to redact from %s\n", what)
+	} else {
+		log.Printf("Redacted %s from %s\n", strings.Join(found, ", "), what)
+	}
+}
+
+func looksLikePhone(match string) bool {
+	digits := 0
+	for _, r := range match {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits < 7 || digits > 15 {
+		return false
+	}
+	return digits >= 10 || strings.ContainsAny(match, "+(-.")
+}
+
+// passesLuhn reports whether the digits of match pass the Luhn checksum
+// that card numbers carry.
+func passesLuhn(match string) bool {
+	sum, double := 0, false
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func checkRedact(config Config) error {
+	if config.RedactPatterns != "" && !config.Redact && !config.RedactPII {
+		return errors.New("-redact-patterns requires -redact or -redact-pii")
+	}
+	return nil
+}
+
+// redactNames asks the model to replace people's names with [NAME], a
+// piece of the transcript at a time.
+func redactNames(config Config, text string) (string, error) {
+	log.Println("Redacting names with the chat API...")
+
+	var pieces []string
+	for _, piece := range splitBetweenSentences(text, redactChunkChars) {
+		message := map[string]string{
+			"role":    "user",
+			"content": createRedactPrompt(piece),
+		}
+		reqBody := map[string]interface{}{
+			"model":       config.SummaryModel,
+			"messages":    []map[string]string{message},
+			"max_tokens":  4000,
+			"temperature": 0,
+		}
+
+		redacted, err := sendChatRequest(config, reqBody, "")
+		if err != nil {
+			return "", fmt.Errorf("redacting names: %w", err)
+		}
+		redacted = strings.TrimSpace(redacted)
+		// Placeholders are shorter than most names, but not by this much.
+		if len(redacted) < len(piece)/2 {
+			return "", fmt.Errorf("redacting names: the reply is %d characters for %d characters of transcript, so text was likely dropped", len(redacted), len(piece))
+		}
+		pieces = append(pieces, redacted)
+	}
+	return strings.Join(pieces, " "), nil
+}
+
+// redactedTranscriptPath is where the redacted copy of a -transcription
+// input is written; transcripts from -file are redacted in place.
+func redactedTranscriptPath(transcriptPath string) string {
+	return generateDerivedFilePath(transcriptPath, "_redacted"+filepath.Ext(transcriptPath))
+}
+
+func createRedactPrompt(text string) string {
+	return fmt.Sprintf(`Replace every name of a person in the following transcript excerpt with [NAME], including first names, surnames, nicknames, and usernames. Also replace street addresses with [ADDRESS]. Leave everything else exactly as it is, including names of companies, products, places, and placeholders such as [EMAIL], [PHONE], and [REDACTED]. Respond with the excerpt only.
+
+%s`, text)
+}
+
+// redactSubtitles redacts the cue text of a subtitle file from Whisper,
+// leaving the cue numbers and timings, which the phone pattern could
+// otherwise take for numbers.
+func redactSubtitles(rules []piiRule, subtitles string) string {
+	lines := strings.Split(subtitles, "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, "-->") && strings.Trim(line, "0123456789\r") != "" {
+			lines[i] = redactPII(rules, line, nil)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
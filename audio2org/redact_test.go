@@ -0,0 +1,97 @@
+package audio2org
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactPII(t *testing.T) {
+	tests := []struct {
+		text       string
+		want       string
+		wantCounts map[string]int
+	}{
+		{"Mail jane.doe+notes@example.co.uk today.", "Mail [EMAIL] today.", map[string]int{"email address": 1}},
+		{"Call +1 (555) 123-4567 or 555.987.6543.", "Call [PHONE] or [PHONE].", map[string]int{"phone number": 2}},
+		{"My number is 555 123 4567.", "My number is [PHONE].", map[string]int{"phone number": 1}},
+		{"Reach the office on +44 20 7946 0958.", "Reach the office on [PHONE].", map[string]int{"phone number": 1}},
+		{"The card is 4111 1111 1111 1111, expiring soon.", "The card is [CARD], expiring soon.", map[string]int{"card number": 1}},
+		{"Order 1234 5678 9012 3456 shipped.", "Order 1234 5678 9012 3456 shipped.", map[string]int{}},
+		{"The key sk-abcdefghijklmnopqrstuvwx leaked.", "The key [API_KEY] leaked.", map[string]int{"API key": 1}},
+		{"Between 1999 and 2000 we grew 25 percent.", "Between 1999 and 2000 we grew 25 percent.", map[string]int{}},
+		{"The seasons 2019 2020 were slow.", "The seasons 2019 2020 were slow.", map[string]int{}},
+		{"Ping 192.168.1.10 at 10:30.", "Ping 192.168.1.10 at 10:30.", map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		counts := map[string]int{}
+		if got := redactPII(defaultPIIRules, tt.text, counts); got != tt.want {
+			t.Errorf("redactPII(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if !maps.Equal(counts, tt.wantCounts) {
+			t.Errorf("redactPII(%q) counted %v, want %v", tt.text, counts, tt.wantCounts)
+		}
+	}
+}
+
+func TestLoadPIIRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	if err := os.WriteFile(path, []byte("# project names\n\nProject [A-Z][a-z]+\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadPIIRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := redactPII(rules, "Project Falcon ships to a@b.io.", nil); got != "[REDACTED] ships to [EMAIL]." {
+		t.Errorf("redactPII() = %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte("ok\n(unclosed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPIIRules(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("loadPIIRules() of a bad pattern = %v, want the line number", err)
+	}
+}
+
+func TestRedactForChat(t *testing.T) {
+	var buf bytes.Buffer
+	captureLog(t, &buf)
+
+	config := Config{Redact: true, piiRules: defaultPIIRules}
+	transcription := TranscriptionResponse{
+		Text:     "Mail me at a@b.io or b@c.io.",
+		Segments: []TranscriptionSegment{{Text: "Mail me at a@b.io"}},
+	}
+	text, redacted := redactForChat(config, transcription.Text, transcription)
+	if text != "Mail me at [EMAIL] or [EMAIL]." || redacted.Segments[0].Text != "Mail me at [EMAIL]" {
+		t.Errorf("redactForChat() = %q, %q", text, redacted.Segments[0].Text)
+	}
+	if transcription.Segments[0].Text != "Mail me at a@b.io" {
+		t.Errorf("the transcript's own segments were changed to %q", transcription.Segments[0].Text)
+	}
+	if !strings.Contains(buf.String(), "Redacted 2 email addresses from the text sent to the chat API") {
+		t.Errorf("log = %q", buf.String())
+	}
+}
+
+func TestSplitBetweenSentences(t *testing.T) {
+	text := strings.Repeat("This sentence is thirty chars. ", 10)
+	pieces := splitBetweenSentences(text, 100)
+	if len(pieces) != 4 {
+		t.Fatalf("splitBetweenSentences() returned %d pieces, want 4: %q", len(pieces), pieces)
+	}
+	for _, piece := range pieces {
+		if len(piece) > 100 || !strings.HasSuffix(piece, ".") {
+			t.Errorf("piece %q is over the limit or ends mid-sentence", piece)
+		}
+	}
+	if got := strings.Join(pieces, " "); got != strings.TrimSpace(text) {
+		t.Errorf("pieces do not add back up to the text: %q", got)
+	}
+}
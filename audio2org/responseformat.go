@@ -0,0 +1,82 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var whisperResponseFormats = []string{"json", "verbose_json", "text", "srt", "vtt"}
+
+func checkWhisperResponseFormat(config Config) error {
+	format := config.WhisperResponseFormat
+	switch {
+	case format == "":
+		return nil
+	case !slices.Contains(whisperResponseFormats, format):
+		return fmt.Errorf("unknown -whisper-response-format %q: expected %s", format, strings.Join(whisperResponseFormats, ", "))
+	case config.Backend == "local":
+		return errors.New("-whisper-response-format sets the Whisper API response format and cannot be combined with -backend local")
+	case format != "json" && format != "text" && config.TranscribeModel != "whisper-1":
+		return fmt.Errorf("-whisper-response-format %s is only available with -transcribe-model whisper-1", format)
+	case isSubtitleFormat(format) && config.Format != format:
+		return fmt.Errorf("-whisper-response-format %s writes the transcript as %s subtitles and cannot be combined with -format %s", format, format, config.Format)
+	case isSubtitleFormat(format) && config.RedactPIIChat:
+		return fmt.Errorf("-redact-pii-chat only redacts the plain text, so it cannot be combined with -whisper-response-format %s", format)
+	case isSubtitleFormat(format) && (config.AudioFilePath == "" || config.VAD || config.Multilang):
+		return fmt.Errorf("-whisper-response-format %s requires -file and cannot be combined with -vad or -multilang", format)
+	case format != "verbose_json" && needsSegments(config):
+		return fmt.Errorf("-whisper-response-format %s returns no segment timing, which %s need; use verbose_json or leave it unset",
+			format, strings.Join(segmentFeatures(config), ", "))
+	}
+	return nil
+}
+
+// isPlainResponseFormat reports whether Whisper answers the
+// -whisper-response-format with text rather than JSON.
+func isPlainResponseFormat(responseFormat string) bool {
+	return responseFormat == "text" || responseFormat == "srt" || responseFormat == "vtt"
+}
+
+// parseTranscriptionBody reads a Whisper response in the response_format
+// that was asked for. The text formats come back as the body itself; for
+// srt and vtt the subtitle file is kept as is and the text is its cues.
+func parseTranscriptionBody(responseFormat string, body []byte) (TranscriptionResponse, error) {
+	switch responseFormat {
+	case "text":
+		return TranscriptionResponse{Text: strings.TrimSpace(string(body))}, nil
+	case "srt", "vtt":
+		return TranscriptionResponse{Text: subtitleText(string(body)), Subtitles: string(body)}, nil
+	}
+
+	var transcription TranscriptionResponse
+	if err := json.Unmarshal(body, &transcription); err != nil {
+		return transcription, fmt.Errorf("unmarshalling JSON response: %w", err)
+	}
+	return transcription, nil
+}
+
+// subtitleText joins the cue text of an SRT or VTT file into one line,
+// leaving out the header, cue numbers, timings, and NOTE blocks.
+func subtitleText(subtitles string) string {
+	var words []string
+	for _, block := range strings.Split(strings.ReplaceAll(subtitles, "\r\n", "\n"), "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if strings.HasPrefix(lines[0], "WEBVTT") || strings.HasPrefix(lines[0], "NOTE") {
+			continue
+		}
+		timing := false
+		for _, line := range lines {
+			if !timing {
+				timing = strings.Contains(line, "-->")
+				continue
+			}
+			if line = strings.TrimSpace(line); line != "" {
+				words = append(words, line)
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,109 @@
+package audio2org
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const whisperSRT = `1
+00:00:00,000 --> 00:00:02,000
+Hello there,
+
+2
+00:00:02,000 --> 00:00:04,500
+call 555-123-4567.
+`
+
+const whisperVTT = `WEBVTT
+
+00:00:00.000 --> 00:00:02.000
+Hello there,
+
+NOTE made by Whisper
+
+00:00:02.000 --> 00:00:04.500
+general Kenobi.
+`
+
+func TestSendTranscriptionResponseFormat(t *testing.T) {
+	tests := []struct {
+		format        string
+		body          string
+		wantText      string
+		wantSubtitles bool
+	}{
+		{"text", "Hello there.\n", "Hello there.", false},
+		{"srt", whisperSRT, "Hello there, call 555-123-4567.", true},
+		{"vtt", whisperVTT, "Hello there, general Kenobi.", true},
+		{"verbose_json", `{"text": "Hello there.", "language": "english"}`, "Hello there.", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var responseFormat string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				responseFormat = r.FormValue("response_format")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL + "/v1", TranscribeModel: "whisper-1", WhisperResponseFormat: tt.format, RetryLog: "quiet"}
+			got, err := sendTranscription(config, "talk.mp3", strings.NewReader("audio"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if responseFormat != tt.format {
+				t.Errorf("response_format = %q, want %q", responseFormat, tt.format)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", got.Text, tt.wantText)
+			}
+			if (got.Subtitles == tt.body) != tt.wantSubtitles {
+				t.Errorf("subtitles = %q, want the body kept: %v", got.Subtitles, tt.wantSubtitles)
+			}
+		})
+	}
+}
+
+func TestCheckWhisperResponseFormat(t *testing.T) {
+	base := Config{AudioFilePath: "talk.mp3", Backend: "openai", TranscribeModel: "whisper-1", Format: "text"}
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"unset", func(c *Config) {}, ""},
+		{"text", func(c *Config) { c.WhisperResponseFormat = "text" }, ""},
+		{"srt with -format srt", func(c *Config) { c.WhisperResponseFormat, c.Format = "srt", "srt" }, ""},
+		{"unknown", func(c *Config) { c.WhisperResponseFormat = "docx" }, "unknown -whisper-response-format"},
+		{"srt with -format text", func(c *Config) { c.WhisperResponseFormat = "srt" }, "cannot be combined with -format text"},
+		{"gpt-4o model", func(c *Config) { c.WhisperResponseFormat, c.TranscribeModel = "verbose_json", "gpt-4o-transcribe" }, "only available with -transcribe-model whisper-1"},
+		{"segments", func(c *Config) { c.WhisperResponseFormat, c.Timestamps = "text", true }, "returns no segment timing, which -timestamps need"},
+		{"vad", func(c *Config) { c.WhisperResponseFormat, c.Format, c.VAD = "vtt", "vtt", true }, "cannot be combined with -vad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := base
+			tt.modify(&config)
+			err := checkWhisperResponseFormat(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkWhisperResponseFormat() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkWhisperResponseFormat() = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedactSubtitles(t *testing.T) {
+	got := redactSubtitles(defaultPIIRules, whisperSRT)
+	want := strings.Replace(whisperSRT, "555-123-4567", "[PHONE]", 1)
+	if got != want {
+		t.Errorf("redactSubtitles() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Result is the transcript file written with -format json, for other
+// programs to read instead of the log.
+type Result struct {
+	Text            string    `json:"text"`
+	Source          string    `json:"source"`
+	Model           string    `json:"model"`
+	Language        string    `json:"language,omitempty"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	PostProcessCmd  string    `json:"post_process_cmd,omitempty"`
+	NotesPath       string    `json:"notes_path,omitempty"`
+	// StageSeconds is the time spent in each stage so far; see -timings.
+	StageSeconds map[string]float64 `json:"stage_seconds,omitempty"`
+}
+
+// formatResult renders the JSON transcript. notesPath is the main
+// post-processing output, empty until post-processing has run. Without a
+// duration from Whisper, it is taken from ffprobe when that is installed.
+func formatResult(config Config, transcription TranscriptionResponse, notesPath string) (string, error) {
+	result := Result{
+		Text:            transcription.Text,
+		Source:          inputSource(config),
+		Model:           transcriptionModel(config),
+		Language:        transcription.Language,
+		DurationSeconds: transcription.Duration,
+		CreatedAt:       time.Now().UTC().Truncate(time.Second),
+		NotesPath:       notesPath,
+		StageSeconds:    inputStageSeconds(config),
+	}
+	if notesPath != "" {
+		result.PostProcessCmd = config.PostProcessCmd
+	}
+	if result.DurationSeconds == 0 && config.AudioFilePath != "" && requireFFprobe("-format json") == nil {
+		if duration, err := probeDuration(config.AudioFilePath); err == nil {
+			result.DurationSeconds = duration.Seconds()
+		}
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding JSON transcript: %w", err)
+	}
+	return string(data) + "\n", nil
+}
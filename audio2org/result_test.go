@@ -0,0 +1,59 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatResult(t *testing.T) {
+	config := Config{AudioFilePath: "talks/keynote.mp3", TranscribeModel: "whisper-1", PostProcessCmd: "create_emacs_org_notes"}
+	config.stages = map[string]stageStats{"transcribe": {Count: 1, Total: 4321 * time.Millisecond}}
+	transcription := TranscriptionResponse{Text: "Hello and welcome.", Language: "english", Duration: 61.5}
+
+	content, err := formatResult(config, transcription, "output/keynote_emacs_org_notes.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result Result
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		t.Fatalf("formatResult() is not valid JSON: %v\n%s", err, content)
+	}
+
+	want := Result{
+		Text:            "Hello and welcome.",
+		Source:          "talks/keynote.mp3",
+		Model:           "whisper-1",
+		Language:        "english",
+		DurationSeconds: 61.5,
+		CreatedAt:       result.CreatedAt,
+		PostProcessCmd:  "create_emacs_org_notes",
+		NotesPath:       "output/keynote_emacs_org_notes.org",
+		StageSeconds:    map[string]float64{"transcribe": 4.321},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("formatResult() = %+v, want %+v", result, want)
+	}
+	if result.CreatedAt.IsZero() {
+		t.Error("formatResult() has no created_at")
+	}
+}
+
+func TestFormatResultBeforePostProcessing(t *testing.T) {
+	config := Config{AudioFilePath: "keynote.mp3", TranscribeModel: "whisper-1", PostProcessCmd: "create_glossary"}
+
+	content, err := formatResult(config, TranscriptionResponse{Text: "Hi.", Duration: 3}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"post_process_cmd", "notes_path"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("formatResult() has %s before post-processing ran:\n%s", key, content)
+		}
+	}
+}
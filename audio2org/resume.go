@@ -0,0 +1,182 @@
+package audio2org
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// resumeState is the -resume progress file. It records each finished chunk,
+// the finished transcription, and the output of each finished
+// post-processing step, so a restarted run skips them.
+type resumeState struct {
+	Source         string                           `json:"source"`
+	Chunks         map[string]TranscriptionResponse `json:"chunks,omitempty"`
+	Transcription  *TranscriptionResponse           `json:"transcription,omitempty"`
+	TranscriptPath string                           `json:"transcript_path,omitempty"`
+	Outputs        map[string]string                `json:"outputs,omitempty"`
+}
+
+var (
+	resumeMu   sync.Mutex
+	resumePath string
+	resume     *resumeState
+)
+
+// loadResumeState reads the -resume file, or starts a new one if it does
+// not exist yet. A file written for a different input is rejected.
+func loadResumeState(path, source string) error {
+	state := &resumeState{Source: source}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		log.Printf("Recording progress in %s\n", path)
+	case err != nil:
+		return fmt.Errorf("reading -resume state: %w", err)
+	default:
+		if err := json.Unmarshal(data, state); err != nil {
+			return fmt.Errorf("parsing -resume state %s: %w", path, err)
+		}
+		if state.Source != source {
+			return fmt.Errorf("-resume state %s is for %s, not %s", path, state.Source, source)
+		}
+		log.Printf("Resuming from %s: %d chunks done\n", path, len(state.Chunks))
+	}
+
+	resumeMu.Lock()
+	resumePath, resume = path, state
+	resumeMu.Unlock()
+	return nil
+}
+
+// saveResumeState rewrites the state file through a temp file and rename,
+// so an interruption never leaves it half written. Callers hold resumeMu.
+func saveResumeState() error {
+	data, err := json.MarshalIndent(resume, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding -resume state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(resumePath), filepath.Base(resumePath)+".*")
+	if err != nil {
+		return fmt.Errorf("writing -resume state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing -resume state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing -resume state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), resumePath); err != nil {
+		return fmt.Errorf("writing -resume state: %w", err)
+	}
+	return nil
+}
+
+func resumeChunkKey(kind string, region audioRegion) string {
+	return fmt.Sprintf("%s %.3f-%.3f", kind, region.Start, region.End)
+}
+
+func resumedChunk(key string) (TranscriptionResponse, bool) {
+	resumeMu.Lock()
+	defer resumeMu.Unlock()
+	if resume == nil {
+		return TranscriptionResponse{}, false
+	}
+	transcription, ok := resume.Chunks[key]
+	return transcription, ok
+}
+
+func recordChunk(key string, transcription TranscriptionResponse) error {
+	resumeMu.Lock()
+	defer resumeMu.Unlock()
+	if resume == nil {
+		return nil
+	}
+	if resume.Chunks == nil {
+		resume.Chunks = map[string]TranscriptionResponse{}
+	}
+	resume.Chunks[key] = transcription
+	return saveResumeState()
+}
+
+func resumedTranscription() (TranscriptionResponse, string, bool) {
+	resumeMu.Lock()
+	defer resumeMu.Unlock()
+	if resume == nil || resume.Transcription == nil {
+		return TranscriptionResponse{}, "", false
+	}
+	return *resume.Transcription, resume.TranscriptPath, true
+}
+
+// recordTranscription stores the finished transcription. The chunks are no
+// longer needed once it is saved, so they are dropped.
+func recordTranscription(transcription TranscriptionResponse, transcriptPath string) error {
+	resumeMu.Lock()
+	defer resumeMu.Unlock()
+	if resume == nil {
+		return nil
+	}
+	resume.Transcription = &transcription
+	resume.TranscriptPath = transcriptPath
+	resume.Chunks = nil
+	return saveResumeState()
+}
+
+func resumedOutput(step string) (string, bool) {
+	resumeMu.Lock()
+	defer resumeMu.Unlock()
+	if resume == nil {
+		return "", false
+	}
+	output, ok := resume.Outputs[step]
+	return output, ok
+}
+
+func recordOutput(step, output string) error {
+	resumeMu.Lock()
+	defer resumeMu.Unlock()
+	if resume == nil {
+		return nil
+	}
+	if resume.Outputs == nil {
+		resume.Outputs = map[string]string{}
+	}
+	resume.Outputs[step] = output
+	return saveResumeState()
+}
+
+// transcribeRegion transcribes one region of a longer recording, or returns
+// the result recorded in the -resume state by an earlier run.
+func transcribeRegion(config Config, audioFilePath, kind string, region audioRegion, form map[string]string) (TranscriptionResponse, error) {
+	key := resumeChunkKey(kind, region)
+	if transcription, ok := resumedChunk(key); ok {
+		log.Println("Already transcribed, skipping")
+		return transcription, nil
+	}
+
+	audioBytes, err := readRegion(audioFilePath, region)
+	if err != nil {
+		return TranscriptionResponse{}, err
+	}
+	if int64(len(audioBytes)) > maxChunkBytes(config) {
+		return TranscriptionResponse{}, fmt.Errorf("the extracted audio is %.1f MB, over the -max-chunk-mb limit of %d MB",
+			float64(len(audioBytes))/(1024*1024), config.MaxChunkMB)
+	}
+
+	transcription, err := transcribeCached(config, audioFilePath, bytes.NewReader(audioBytes), form)
+	if err != nil {
+		return TranscriptionResponse{}, err
+	}
+	if err := recordChunk(key, transcription); err != nil {
+		return TranscriptionResponse{}, err
+	}
+	return transcription, nil
+}
@@ -0,0 +1,61 @@
+package audio2org
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResumeState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	t.Cleanup(func() { resume, resumePath = nil, "" })
+
+	if err := loadResumeState(path, "talk.mp3"); err != nil {
+		t.Fatal(err)
+	}
+	key := resumeChunkKey("chunk", audioRegion{0, 205})
+	if err := recordChunk(key, TranscriptionResponse{Text: "first chunk"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordOutput("create_glossary", "* Glossary"); err != nil {
+		t.Fatal(err)
+	}
+
+	resume = nil
+	if err := loadResumeState(path, "talk.mp3"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := resumedChunk(key); !ok || got.Text != "first chunk" {
+		t.Errorf("resumedChunk() = %q, %v, want the recorded chunk", got.Text, ok)
+	}
+	if _, ok := resumedChunk(resumeChunkKey("chunk", audioRegion{205, 415})); ok {
+		t.Error("resumedChunk() found a chunk that was never recorded")
+	}
+	if got, ok := resumedOutput("create_glossary"); !ok || got != "* Glossary" {
+		t.Errorf("resumedOutput() = %q, %v, want the recorded output", got, ok)
+	}
+
+	if err := recordTranscription(TranscriptionResponse{Text: "first chunk"}, "output/transcription.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resumedChunk(key); ok {
+		t.Error("chunks should be dropped once the transcription is recorded")
+	}
+	if _, path, ok := resumedTranscription(); !ok || path != "output/transcription.txt" {
+		t.Errorf("resumedTranscription() path = %q, %v", path, ok)
+	}
+
+	err := loadResumeState(path, "other.mp3")
+	if err == nil || !strings.Contains(err.Error(), "is for talk.mp3") {
+		t.Errorf("loadResumeState() with another input = %v, want a mismatch error", err)
+	}
+}
+
+func TestResumeStateDisabled(t *testing.T) {
+	if err := recordChunk("chunk 0.000-1.000", TranscriptionResponse{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resumedChunk("chunk 0.000-1.000"); ok {
+		t.Error("nothing should be recorded without -resume")
+	}
+}
@@ -0,0 +1,157 @@
+package audio2org
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const maxRetryWait = 2 * time.Minute
+
+var retryableStatuses = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// configureRetries retries requests that fail with a transport error or a
+// retryable status, or with a body that is not JSON where JSON was expected
+// (see expectJSON). Other errors, such as 400 or 401, fail on the first
+// attempt. Multipart readers are rewound so a retried upload re-sends the
+// whole file. With -retry-budget, a request is not retried once that long
+// has passed since its first attempt, and no wait runs past the budget.
+func configureRetries(client *resty.Client, config Config) {
+	if config.MaxRetries <= 0 {
+		return
+	}
+
+	client.SetRetryCount(config.MaxRetries).
+		SetRetryWaitTime(0).
+		SetRetryMaxWaitTime(maxRetryWait).
+		SetRetryResetReaders(true).
+		SetLogger(retryLogger{})
+
+	if config.RetryBudget > 0 {
+		client.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+			if r.Attempt == 1 {
+				r.SetContext(context.WithValue(r.Context(), retryStartKey{}, time.Now()))
+			}
+			return nil
+		})
+	}
+
+	client.AddRetryCondition(func(resp *resty.Response, err error) bool {
+		// A nil response means the request was never sent, e.g. an invalid
+		// body, which a retry cannot fix.
+		if resp == nil {
+			return false
+		}
+		if !(err != nil || retryableStatuses[resp.StatusCode()] || invalidJSONBody(resp)) {
+			return false
+		}
+		if remaining, ok := retryBudgetLeft(config, resp.Request); ok && remaining <= 0 {
+			if config.RetryLog != "quiet" {
+				log.Printf("Request failed (%s) and the -retry-budget of %s is used up; not retrying\n", describeFailure(resp, err), config.RetryBudget)
+			}
+			return false
+		}
+		return true
+	})
+
+	client.AddRetryHook(func(resp *resty.Response, err error) {
+		if config.RetryLog == "quiet" || resp.Request.Attempt > config.MaxRetries {
+			return
+		}
+		log.Printf("Request failed (%s), retrying (attempt %d of %d)...\n",
+			describeFailure(resp, err), resp.Request.Attempt+1, config.MaxRetries+1)
+	})
+
+	client.SetRetryAfter(func(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+		delay := retryDelay(config.RetryBaseDelay, resp.Request.Attempt, resp.Header().Get("Retry-After"), time.Now())
+		if remaining, ok := retryBudgetLeft(config, resp.Request); ok {
+			delay = min(delay, max(remaining, 0))
+		}
+		if config.RetryLog == "verbose" {
+			log.Printf("Waiting %s before retrying %s\n", delay.Round(time.Millisecond), resp.Request.URL)
+		}
+		return delay, nil
+	})
+}
+
+type retryStartKey struct{}
+
+// retryBudgetLeft returns how much of the -retry-budget is left for the
+// request, counted from the start of its first attempt. ok is false
+// without a budget.
+func retryBudgetLeft(config Config, request *resty.Request) (remaining time.Duration, ok bool) {
+	start, ok := request.Context().Value(retryStartKey{}).(time.Time)
+	if config.RetryBudget <= 0 || !ok {
+		return 0, false
+	}
+	return config.RetryBudget - time.Since(start), true
+}
+
+type jsonBodyKey struct{}
+
+// expectJSON marks a request whose successful responses must be JSON, so
+// that a body that does not parse, such as one cut off by a dropped
+// connection, is requested again like a 5xx.
+func expectJSON(request *resty.Request) *resty.Request {
+	return request.SetContext(context.WithValue(request.Context(), jsonBodyKey{}, true))
+}
+
+func invalidJSONBody(resp *resty.Response) bool {
+	expected, _ := resp.Request.Context().Value(jsonBodyKey{}).(bool)
+	return expected && resp.IsSuccess() && !json.Valid(resp.Body())
+}
+
+func describeFailure(resp *resty.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if invalidJSONBody(resp) {
+		return "invalid JSON in a " + resp.Status() + " response"
+	}
+	return resp.Status()
+}
+
+// retryDelay returns how long to wait after the given failed attempt
+// (counting from 1). A Retry-After header, in seconds or as an HTTP date,
+// takes precedence; otherwise the base delay doubles per attempt with up to
+// 50% jitter so parallel runs don't retry in lockstep.
+func retryDelay(base time.Duration, attempt int, retryAfter string, now time.Time) time.Duration {
+	if retryAfter = strings.TrimSpace(retryAfter); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, maxRetryWait)
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return min(max(date.Sub(now), 0), maxRetryWait)
+		}
+	}
+
+	delay := base << max(attempt-1, 0)
+	if delay <= 0 || delay > maxRetryWait {
+		delay = maxRetryWait
+	}
+	if jitter := int64(delay / 2); jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter))
+	}
+	return min(delay, maxRetryWait)
+}
+
+// retryLogger drops resty's own per-attempt warnings; retries are logged
+// according to -retry-log instead, and final errors are returned.
+type retryLogger struct{}
+
+func (retryLogger) Errorf(string, ...interface{}) {}
+func (retryLogger) Warnf(string, ...interface{})  {}
+func (retryLogger) Debugf(string, ...interface{}) {}
@@ -0,0 +1,226 @@
+package audio2org
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		low, high  time.Duration
+	}{
+		{"first attempt", 1, "", time.Second, 1500 * time.Millisecond},
+		{"third attempt doubles twice", 3, "", 4 * time.Second, 6 * time.Second},
+		{"retry-after seconds", 1, "7", 7 * time.Second, 7 * time.Second},
+		{"retry-after date", 1, now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, 30 * time.Second},
+		{"retry-after is capped", 1, "3600", maxRetryWait, maxRetryWait},
+		{"invalid retry-after falls back", 1, "soon", time.Second, 1500 * time.Millisecond},
+		{"backoff is capped", 40, "", maxRetryWait, maxRetryWait},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retryDelay(time.Second, tt.attempt, tt.retryAfter, now)
+			if got < tt.low || got > tt.high {
+				t.Errorf("retryDelay() = %s, want between %s and %s", got, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   int
+		wantRequests int
+	}{
+		{"retries a 503", []int{503, 200}, 200, 2},
+		{"retries a 429", []int{429, 429, 200}, 200, 3},
+		{"gives up after max retries", []int{500, 502, 503, 504}, 503, 3},
+		{"does not retry a 400", []int{400, 200}, 400, 1},
+		{"does not retry a 401", []int{401, 200}, 401, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				file, _, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("reading uploaded file: %v", err)
+				} else {
+					data, _ := io.ReadAll(file)
+					bodies = append(bodies, string(data))
+				}
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[len(bodies)-1])
+			}))
+			defer server.Close()
+
+			client, err := newHTTPClient(Config{MaxRetries: 2, RetryBaseDelay: time.Millisecond, RetryLog: "quiet"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.R().SetFileReader("file", "audio.mp3", bytes.NewReader([]byte("audio"))).Post(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode() != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode(), tt.wantStatus)
+			}
+			if len(bodies) != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", len(bodies), tt.wantRequests)
+			}
+			for i, body := range bodies {
+				if body != "audio" {
+					t.Errorf("request %d uploaded %q, want the whole file", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestClientTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := Config{
+		RetryBaseDelay:      time.Millisecond,
+		RetryLog:            "quiet",
+		Timeout:             time.Minute,
+		ConnectTimeout:      time.Second,
+		TLSHandshakeTimeout: time.Second,
+	}
+	client, err := newHTTPClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.R().Get(server.URL); err != nil {
+		t.Errorf("slow response within -timeout: %v", err)
+	}
+
+	config.ResponseHeaderTimeout = 20 * time.Millisecond
+	client, err = newHTTPClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.R().Get(server.URL); err == nil {
+		t.Error("slow response past -response-header-timeout succeeded, want a timeout error")
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryAfter   string
+		handlerDelay time.Duration
+		maxRequests  int
+	}{
+		{"stops once the budget is used", "0", 40 * time.Millisecond, 3},
+		{"wait is cut to the budget", "60", 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				time.Sleep(tt.handlerDelay)
+				w.Header().Set("Retry-After", tt.retryAfter)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			client, err := newHTTPClient(Config{MaxRetries: 10, RetryBaseDelay: time.Millisecond, RetryBudget: 100 * time.Millisecond, RetryLog: "quiet"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			resp, err := client.R().Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode() != http.StatusServiceUnavailable {
+				t.Errorf("status = %d, want 503", resp.StatusCode())
+			}
+			if requests < 2 || requests > tt.maxRequests {
+				t.Errorf("sent %d requests, want 2 to %d within the budget", requests, tt.maxRequests)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("took %s with a 100ms -retry-budget", elapsed)
+			}
+		})
+	}
+}
+
+func TestSendTranscriptionRetriesTruncatedJSON(t *testing.T) {
+	bodies := []string{`{"text": "Hello fr`, `{"text": "Hello from the retry."}`}
+	tests := []struct {
+		name       string
+		maxRetries int
+		wantText   string
+		wantErr    bool
+	}{
+		{"retried", 1, "Hello from the retry.", false},
+		{"no retries left", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, _, err := r.FormFile("file"); err != nil {
+					t.Errorf("attempt %d: reading uploaded file: %v", requests+1, err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(bodies[min(requests, len(bodies)-1)]))
+				requests++
+			}))
+			defer server.Close()
+
+			config := Config{
+				OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", TranscribeModel: "whisper-1",
+				Timeout: time.Minute, MaxRetries: tt.maxRetries, RetryBaseDelay: time.Millisecond, RetryLog: "quiet",
+			}
+			transcription, err := sendTranscription(config, "talk.mp3", bytes.NewReader([]byte("audio")), nil)
+			if (err != nil) != tt.wantErr || transcription.Text != tt.wantText {
+				t.Errorf("sendTranscription() = %q, %v, want %q, error %v", transcription.Text, err, tt.wantText, tt.wantErr)
+			}
+			if requests != tt.maxRetries+1 {
+				t.Errorf("sent %d requests, want %d", requests, tt.maxRetries+1)
+			}
+		})
+	}
+}
+
+func TestPlainResponseFormatIsNotRetried(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("Hello, not JSON."))
+	}))
+	defer server.Close()
+
+	config := Config{
+		OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", TranscribeModel: "whisper-1", WhisperResponseFormat: "text",
+		Timeout: time.Minute, MaxRetries: 2, RetryBaseDelay: time.Millisecond, RetryLog: "quiet",
+	}
+	transcription, err := sendTranscription(config, "talk.mp3", bytes.NewReader([]byte("audio")), nil)
+	if err != nil || transcription.Text != "Hello, not JSON." || requests != 1 {
+		t.Errorf("sendTranscription() = %q, %v after %d requests, want the text from one", transcription.Text, err, requests)
+	}
+}
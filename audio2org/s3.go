@@ -0,0 +1,289 @@
+package audio2org
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type s3Location struct {
+	Bucket string
+	Prefix string
+}
+
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func isCloudURI(uri string) bool {
+	return strings.HasPrefix(uri, "s3://")
+}
+
+func parseS3URI(uri string) (s3Location, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return s3Location{}, err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return s3Location{}, fmt.Errorf("expected s3://bucket/prefix, got %q", uri)
+	}
+	return s3Location{Bucket: u.Host, Prefix: strings.Trim(u.Path, "/")}, nil
+}
+
+func uploadToS3(config Config, filePath, content string) error {
+	location, err := parseS3URI(config.OutputURI)
+	if err != nil {
+		return fmt.Errorf("invalid -output-uri: %w", err)
+	}
+
+	creds, err := loadAWSCredentials()
+	if err != nil {
+		return fmt.Errorf("loading AWS credentials: %w", err)
+	}
+	region := awsRegion()
+
+	key := path.Join(location.Prefix, filepath.Base(filePath))
+	endpoint := s3ObjectURL(config.S3Endpoint, region, location.Bucket, key)
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	body := []byte(content)
+	headers := signS3Put(creds, region, u, body, time.Now().UTC())
+
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	resp, err := client.R().
+		SetContext(runContext(config)).
+		SetHeaders(headers).
+		SetHeader("Content-Type", contentType).
+		SetBody(body).
+		Put(endpoint)
+	if err != nil {
+		return fmt.Errorf("uploading to S3: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("response from S3: %s\n%s", resp.Status(), resp.String())
+	}
+
+	log.Printf("Content successfully uploaded to s3://%s/%s\n", location.Bucket, key)
+	return nil
+}
+
+func s3ObjectURL(endpoint, region, bucket, key string) string {
+	escapedKey := s3EscapePath(key)
+	if endpoint != "" {
+		return strings.TrimRight(endpoint, "/") + "/" + bucket + "/" + escapedKey
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, escapedKey)
+}
+
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		var b strings.Builder
+		for _, c := range []byte(segment) {
+			if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+				c == '-' || c == '_' || c == '.' || c == '~' {
+				b.WriteByte(c)
+			} else {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		}
+		segments[i] = b.String()
+	}
+	return strings.Join(segments, "/")
+}
+
+// signS3Put returns the headers for a SigV4-signed PUT of body to u.
+func signS3Put(creds awsCredentials, region string, u *url.URL, body []byte, now time.Time) map[string]string {
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	headers := map[string]string{
+		"host":                 u.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if creds.SessionToken != "" {
+		headers["x-amz-security-token"] = creds.SessionToken
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		"PUT",
+		u.EscapedPath(),
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", shortDate, region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	delete(headers, "host")
+	headers["Authorization"] = fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature)
+	return headers
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func loadAWSCredentials() (awsCredentials, error) {
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		return creds, nil
+	}
+
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return creds, err
+		}
+		credentialsFile = filepath.Join(home, ".aws", "credentials")
+	}
+
+	values, err := readAWSProfile(credentialsFile, awsProfile(), false)
+	if err != nil {
+		return creds, fmt.Errorf("AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY not set and %v", err)
+	}
+	creds = awsCredentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, fmt.Errorf("profile %q in %s has no access key", awsProfile(), credentialsFile)
+	}
+	return creds, nil
+}
+
+func awsProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+func awsRegion() string {
+	for _, key := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(key); region != "" {
+			return region
+		}
+	}
+
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configFile = filepath.Join(home, ".aws", "config")
+		}
+	}
+	if values, err := readAWSProfile(configFile, awsProfile(), true); err == nil && values["region"] != "" {
+		return values["region"]
+	}
+	return "us-east-1"
+}
+
+// readAWSProfile reads one profile section from an AWS INI file. In the
+// config file, non-default sections are named "profile <name>".
+func readAWSProfile(filePath, profile string, configFile bool) (map[string]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	section := profile
+	if configFile && profile != "default" {
+		section = "profile " + profile
+	}
+
+	values := map[string]string{}
+	inSection := false
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == section
+			found = found || inSection
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("profile %q not found in %s", profile, filePath)
+	}
+	return values, nil
+}
@@ -0,0 +1,25 @@
+package audio2org
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchemaForSummary(t *testing.T) {
+	schema := jsonSchemaFor(JSONSummary{})
+
+	if schema["type"] != "object" || schema["additionalProperties"] != false {
+		t.Fatalf("unexpected top-level schema: %v", schema)
+	}
+
+	wantRequired := []string{"title", "summary", "bullets", "action_items"}
+	if !reflect.DeepEqual(schema["required"], wantRequired) {
+		t.Fatalf("required = %v, want %v", schema["required"], wantRequired)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	bullets := properties["bullets"].(map[string]interface{})
+	if bullets["type"] != "array" || !reflect.DeepEqual(bullets["items"], map[string]interface{}{"type": "string"}) {
+		t.Fatalf("unexpected bullets schema: %v", bullets)
+	}
+}
@@ -0,0 +1,138 @@
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// errNotStarted is recorded for batch inputs that were still waiting when a
+// shutdown signal arrived.
+var errNotStarted = errors.New("not started because of a shutdown signal")
+
+// errGraceExpired cancels the work still running when -shutdown-grace runs
+// out.
+var errGraceExpired = errors.New("-shutdown-grace expired")
+
+// shutdownSignal is the cause of the context handleShutdown cancels.
+type shutdownSignal struct {
+	os.Signal
+}
+
+func (s shutdownSignal) Error() string {
+	return "received " + s.String()
+}
+
+// handleShutdown listens for SIGINT and SIGTERM. The first signal ends
+// the drain context, so batches stop starting new inputs while the ones in
+// flight finish. The abort context ends at a second signal, or once grace
+// has passed since the first; it cancels the API requests and commands
+// still running, so the run returns an error instead of writing partial
+// outputs, apart from the finished chunks of a split file (see
+// writePartialTranscription). After that the default handling is restored, and a further
+// signal stops the process at once.
+func handleShutdown(grace time.Duration) (drain, abort context.Context, stop func()) {
+	drain, cancelDrain := context.WithCancelCause(context.Background())
+	abort, cancelAbort := context.WithCancelCause(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			return
+		case sig := <-sigs:
+			log.Printf("Received %s: finishing the work in progress for up to %s (-shutdown-grace); send it again to cancel it\n", sig, grace)
+			cancelDrain(shutdownSignal{sig})
+		}
+
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return
+		case sig := <-sigs:
+			log.Printf("Received %s again: cancelling the work in progress\n", sig)
+			cancelAbort(shutdownSignal{sig})
+		case <-timer.C:
+			log.Printf("The work in progress did not finish within -shutdown-grace %s: cancelling it\n", grace)
+			cancelAbort(errGraceExpired)
+		}
+		signal.Stop(sigs)
+	}()
+
+	return drain, abort, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancelDrain(nil)
+		cancelAbort(nil)
+	}
+}
+
+// cancelled replaces the error of an aborted run, which only says a
+// request or command was cancelled, with the reason it was aborted.
+func cancelled(abort context.Context, err error) error {
+	if err == nil || abort.Err() == nil {
+		return err
+	}
+	return fmt.Errorf("cancelled: %w", context.Cause(abort))
+}
+
+// runContext returns the context that cancels the run's API requests and
+// commands, or the background context outside a run, as in tests.
+func runContext(config Config) context.Context {
+	if config.ctx == nil {
+		return context.Background()
+	}
+	return config.ctx
+}
+
+// forwardsToChildren reports whether the shutdown signal behind ctx should
+// be passed on to child processes. SIGTERM usually comes from a process
+// manager and only reaches this process; SIGINT from the terminal already
+// reached the whole process group, and sending it again would stop the
+// children at once.
+func forwardsToChildren(ctx context.Context) bool {
+	var sig shutdownSignal
+	return errors.As(context.Cause(ctx), &sig) && sig.Signal == syscall.SIGTERM
+}
+
+// watchChild passes a SIGTERM shutdown on to a child process and kills the
+// child if it is still running grace after the signal. Call the returned
+// function once the child has exited; it reports whether it was killed.
+func watchChild(ctx context.Context, process *os.Process, grace time.Duration) func() bool {
+	exited := make(chan struct{})
+	killed := make(chan bool, 1)
+	go func() {
+		select {
+		case <-exited:
+			killed <- false
+			return
+		case <-ctx.Done():
+		}
+		if forwardsToChildren(ctx) {
+			// Not supported on Windows, where the child is only killed.
+			process.Signal(syscall.SIGTERM)
+		}
+
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case <-exited:
+			killed <- false
+		case <-timer.C:
+			process.Kill()
+			killed <- true
+		}
+	}()
+	return func() bool {
+		close(exited)
+		return <-killed
+	}
+}
@@ -0,0 +1,100 @@
+package audio2org
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleShutdown(t *testing.T) {
+	drain, abort, stop := handleShutdown(time.Minute)
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		t.Skipf("cannot signal the test process: %v", err)
+	}
+
+	select {
+	case <-drain.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain context not done after SIGTERM")
+	}
+	if !forwardsToChildren(drain) {
+		t.Errorf("forwardsToChildren() = false after SIGTERM, want true")
+	}
+	if abort.Err() != nil {
+		t.Fatal("abort context done after the first SIGTERM")
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-abort.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("abort context not done after a second SIGTERM")
+	}
+}
+
+func TestHandleShutdownGraceExpiry(t *testing.T) {
+	_, abort, stop := handleShutdown(10 * time.Millisecond)
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(syscall.SIGINT); err != nil {
+		t.Skipf("cannot signal the test process: %v", err)
+	}
+
+	select {
+	case <-abort.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("abort context not done after -shutdown-grace")
+	}
+	if err := cancelled(abort, errors.New("context canceled")); !errors.Is(err, errGraceExpired) {
+		t.Errorf("cancelled() = %v, want it to wrap errGraceExpired", err)
+	}
+	if err := cancelled(abort, nil); err != nil {
+		t.Errorf("cancelled() = %v for a run that finished, want nil", err)
+	}
+}
+
+func TestForwardsToChildren(t *testing.T) {
+	tests := []struct {
+		name  string
+		cause error
+		want  bool
+	}{
+		{"SIGTERM", shutdownSignal{syscall.SIGTERM}, true},
+		{"SIGINT", shutdownSignal{os.Interrupt}, false},
+		{"run finished", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			cancel(tt.cause)
+			if got := forwardsToChildren(ctx); got != tt.want {
+				t.Errorf("forwardsToChildren() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBatchAfterShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(shutdownSignal{syscall.SIGTERM})
+
+	err := runBatch(ctx, Config{Format: "text"}, []string{"one.mp3", "two.mp3"})
+	if err == nil || err.Error() != "2 of 2 files were not started because of a shutdown signal" {
+		t.Errorf("runBatch() = %v, want both files not started", err)
+	}
+}
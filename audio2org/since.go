@@ -0,0 +1,57 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sinceLayouts are the date forms -since accepts, read in local time.
+var sinceLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", recordingDateLayout}
+
+// parseSince reads -since as a duration before now, such as 24h or 7d, or
+// as a date or time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range sinceLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid -since %q: expected a duration such as 24h or 7d, or a date such as 2024-03-05 or 2024-03-05T18:00", value)
+}
+
+// filterSince keeps the files modified after cutoff, logging how many were
+// left out. Audio piped in with -file - or downloaded from a URL is always
+// kept.
+func filterSince(files []string, cutoff time.Time) ([]string, error) {
+	var kept []string
+	for _, file := range files {
+		if file == stdinPath || isAudioURL(file) {
+			kept = append(kept, file)
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading audio file: %w", err)
+		}
+		if info.ModTime().After(cutoff) {
+			kept = append(kept, file)
+		}
+	}
+	if skipped := len(files) - len(kept); skipped > 0 {
+		log.Printf("Skipping %d of %d -file inputs not modified since %s (-since)\n", skipped, len(files), cutoff.Format("2006-01-02 15:04:05"))
+	}
+	return kept, nil
+}
@@ -0,0 +1,65 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"24h", time.Date(2024, 3, 9, 12, 0, 0, 0, time.Local), false},
+		{"90m", time.Date(2024, 3, 10, 10, 30, 0, 0, time.Local), false},
+		{"7d", time.Date(2024, 3, 3, 12, 0, 0, 0, time.Local), false},
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local), false},
+		{"2024-03-05T18:00", time.Date(2024, 3, 5, 18, 0, 0, 0, time.Local), false},
+		{"2024-03-05T18:00:00Z", time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC), false},
+		{"-24h", time.Time{}, true},
+		{"last week", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterSince(t *testing.T) {
+	dir := t.TempDir()
+	cutoff := time.Now().Add(-24 * time.Hour)
+	var files []string
+	for name, modified := range map[string]time.Time{
+		"old.mp3": cutoff.Add(-time.Hour),
+		"new.mp3": cutoff.Add(time.Hour),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	got, err := filterSince(append(files, stdinPath), cutoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "new.mp3"), stdinPath}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterSince() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+var audioPlayers = [][]string{
+	{"afplay"},
+	{"mpg123", "-q"},
+	{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+}
+
+func speakSummary(config Config, transcriptionText, baseFilePath string) {
+	line := createStatusLine(config, transcriptionText)
+	log.Printf("Speaking summary: %s\n", line)
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		log.Printf("Error creating TTS client: %v\n", err)
+		return
+	}
+	url := apiURL(config, "/audio/speech", "tts-1")
+	reqBody := map[string]interface{}{
+		"model": "tts-1",
+		"voice": "alloy",
+		"input": line,
+	}
+
+	resp, err := client.R().
+		SetContext(runContext(config)).
+		SetHeaders(apiHeaders(config, config.OpenAIAPIKey)).
+		SetHeader("Content-Type", "application/json").
+		SetBody(reqBody).
+		Post(url)
+	if err != nil {
+		log.Printf("Error sending request to TTS API: %v\n", err)
+		return
+	}
+	saveDebugExchange(config, "speech", url, reqBody, "", []byte(resp.Status()))
+	if resp.IsError() {
+		log.Printf("Error response from TTS API: %v\n", resp.String())
+		return
+	}
+
+	player := findAudioPlayer()
+	if player == nil {
+		outputFilePath := generateDerivedFilePath(baseFilePath, "_status.mp3")
+		if err := writeToFile(config, outputFilePath, string(resp.Body())); err != nil {
+			log.Printf("Error saving status audio: %v\n", err)
+		}
+		return
+	}
+
+	tmpPath, err := createTempFile("status", ".mp3")
+	if err != nil {
+		log.Printf("Error saving status audio: %v\n", err)
+		return
+	}
+	defer removeTempFile(tmpPath)
+	if err := os.WriteFile(tmpPath, resp.Body(), 0600); err != nil {
+		log.Printf("Error writing temp file: %v\n", err)
+		return
+	}
+
+	if err := exec.Command(player[0], append(player[1:], tmpPath)...).Run(); err != nil {
+		log.Printf("Error playing status audio: %v\n", err)
+	}
+}
+
+func createStatusLine(config Config, transcriptionText string) string {
+	var parts []string
+
+	if config.AudioFilePath != "" {
+		if duration, err := probeDuration(config.AudioFilePath); err == nil {
+			minutes := int(duration.Minutes() + 0.5)
+			parts = append(parts, fmt.Sprintf("Transcribed %d %s", minutes, plural(minutes, "minute", "minutes")))
+		} else {
+			parts = append(parts, "Transcribed")
+		}
+	} else {
+		parts = append(parts, "Read transcript")
+	}
+
+	words := countWords(transcriptionText)
+	parts = append(parts, fmt.Sprintf("%d %s", words, plural(words, "word", "words")))
+
+	if config.PostProcessCmd != "" {
+		parts = append(parts, "notes written")
+	}
+
+	return strings.Join(parts, ", ") + "."
+}
+
+func findAudioPlayer() []string {
+	for _, player := range audioPlayers {
+		if _, err := exec.LookPath(player[0]); err == nil {
+			return player
+		}
+	}
+	return nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// countWords counts whitespace-separated words, plus one per character of
+// scripts written without spaces, where a single field can hold a whole
+// sentence. Fields with no letters or digits, such as a lone dash, are not
+// counted.
+func countWords(text string) int {
+	count := 0
+	for _, field := range strings.Fields(text) {
+		inWord := false
+		for _, r := range field {
+			switch {
+			case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+				count++
+				inWord = false
+			case !inWord && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+				count++
+				inWord = true
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,23 @@
+package audio2org
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"one two  three\nfour", 4},
+		{"well - that's it", 3},
+		{"日本語です", 5},
+		{"Go言語 rocks", 4},
+		{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", 1},
+	}
+
+	for _, tt := range tests {
+		if got := countWords(tt.text); got != tt.want {
+			t.Errorf("countWords(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
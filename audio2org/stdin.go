@@ -0,0 +1,80 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// stdinPath is the -file value that reads the audio from stdin.
+const stdinPath = "-"
+
+// checkStdinInput rejects -stdin-format without -file -, a missing or
+// unknown format, and the features that need the audio to stay on disk
+// after the run.
+func checkStdinInput(config Config) error {
+	if config.AudioFilePath != stdinPath {
+		if config.StdinFormat != "" {
+			return errors.New("-stdin-format only applies to -file -")
+		}
+		return nil
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(config.StdinFormat), ".")
+	switch {
+	case format == "":
+		return fmt.Errorf("-file - needs -stdin-format to name the audio format, one of %s", stdinFormats())
+	case !slices.Contains(supportedExtensions, "."+format):
+		return fmt.Errorf("unknown -stdin-format %q: expected one of %s", config.StdinFormat, stdinFormats())
+	case hasPostStep(config, "create_org_transcript"):
+		return errors.New("create_org_transcript links to the audio file, which -file - does not have")
+	case config.Clock:
+		return errors.New("-clock uses the audio file's modification time, which -file - does not have")
+	case config.Resume != "":
+		return errors.New("-resume cannot tell whether stdin holds the same audio as before; save it to a file first")
+	}
+	return nil
+}
+
+// spoolStdin copies the audio piped to stdin into a temp file with the
+// -stdin-format extension, so the upload has a name Whisper can infer the
+// format from and ffmpeg-based features can read it like any other file.
+func spoolStdin(format string) (string, error) {
+	if isTerminal(os.Stdin) {
+		return "", errors.New("-file - reads audio from stdin, but stdin is a terminal; pipe the audio in")
+	}
+
+	path, err := createTempFile("stdin", "."+strings.TrimPrefix(strings.ToLower(format), "."))
+	if err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("opening temp file: %w", err)
+	}
+	n, err := io.Copy(f, os.Stdin)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading audio from stdin: %w", err)
+	}
+	if n == 0 {
+		return "", errors.New("no audio was read from stdin")
+	}
+
+	log.Printf("Read %.1f MB of audio from stdin\n", float64(n)/(1024*1024))
+	return path, nil
+}
+
+func stdinFormats() string {
+	formats := make([]string, len(supportedExtensions))
+	for i, ext := range supportedExtensions {
+		formats[i] = strings.TrimPrefix(ext, ".")
+	}
+	return strings.Join(formats, ", ")
+}
@@ -0,0 +1,38 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckStdinInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"regular file", Config{AudioFilePath: "talk.mp3"}, ""},
+		{"stdin with format", Config{AudioFilePath: "-", StdinFormat: "mp3"}, ""},
+		{"format with dot and capitals", Config{AudioFilePath: "-", StdinFormat: ".WAV"}, ""},
+		{"stdin without format", Config{AudioFilePath: "-"}, "needs -stdin-format"},
+		{"unknown format", Config{AudioFilePath: "-", StdinFormat: "aiff"}, "unknown -stdin-format"},
+		{"format without stdin", Config{AudioFilePath: "talk.mp3", StdinFormat: "mp3"}, "only applies to -file -"},
+		{"org transcript links", Config{AudioFilePath: "-", StdinFormat: "mp3", PostProcessCmd: "create_org_transcript"}, "links to the audio file"},
+		{"resume", Config{AudioFilePath: "-", StdinFormat: "mp3", Resume: "state.json"}, "-resume"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStdinInput(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkStdinInput() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkStdinInput() = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
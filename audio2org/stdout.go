@@ -0,0 +1,41 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// checkStdout rejects the flags that write files or print to stdout
+// themselves, which -stdout would conflict with.
+func checkStdout(config Config) error {
+	switch {
+	case !config.Stdout:
+		return nil
+	case config.NoOutput || config.OutputURI != "":
+		return errors.New("-stdout cannot be combined with -no-output or -output-uri")
+	case config.OrgIndex != "":
+		return errors.New("-stdout writes no notes files for -org-index to link to")
+	case config.Bench:
+		return errors.New("-stdout cannot be combined with -bench, which prints its report to stdout")
+	case config.Concurrency > 1:
+		return errors.New("-stdout prints the outputs one input at a time and cannot be combined with -concurrency")
+	}
+	return nil
+}
+
+// printStdoutOutput prints the notes of a -stdout run, or the transcript
+// without -post, to w as the file would have held them.
+func printStdoutOutput(w io.Writer, config Config, transcriptPath, transcriptionText string) error {
+	content, ok := config.stdoutOutputs[primaryOutput(config, transcriptPath)]
+	if !ok {
+		// A -transcription input without -post is not written again.
+		content = transcriptionText
+	}
+	clear(config.stdoutOutputs)
+
+	if _, err := fmt.Fprint(w, content); err != nil {
+		return fmt.Errorf("writing to stdout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckStdout(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"off", Config{NoOutput: true, Concurrency: 4}, ""},
+		{"single input", Config{Stdout: true, Concurrency: 1}, ""},
+		{"no output", Config{Stdout: true, NoOutput: true}, "-no-output"},
+		{"object storage", Config{Stdout: true, OutputURI: "s3://bucket/notes"}, "-output-uri"},
+		{"org index", Config{Stdout: true, OrgIndex: "index.org"}, "-org-index"},
+		{"bench", Config{Stdout: true, Bench: true}, "-bench"},
+		{"concurrent batch", Config{Stdout: true, Concurrency: 2}, "-concurrency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStdout(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkStdout() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkStdout() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintStdoutOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"transcript", Config{AudioFilePath: "talk.mp3", Stdout: true}, "Hello there.\n"},
+		{"notes", Config{AudioFilePath: "talk.mp3", PostProcessCmd: "create_glossary", Stdout: true}, "* Glossary\n"},
+		{"existing transcript", Config{TranscriptionFilePath: "output/talk.txt", Stdout: true}, "Hello there."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.stdoutOutputs = map[string]string{}
+			if tt.config.AudioFilePath != "" {
+				if err := writeToFile(tt.config, "output/talk.txt", "Hello there.\n"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.config.PostProcessCmd != "" {
+				if err := writeToFile(tt.config, "output/talk_glossary.org", "* Glossary\n"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var b strings.Builder
+			if err := printStdoutOutput(&b, tt.config, "output/talk.txt", "Hello there."); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("printStdoutOutput() = %q, want %q", b.String(), tt.want)
+			}
+			if len(tt.config.stdoutOutputs) != 0 {
+				t.Errorf("printStdoutOutput() left %d outputs behind", len(tt.config.stdoutOutputs))
+			}
+		})
+	}
+}
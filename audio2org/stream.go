@@ -0,0 +1,147 @@
+package audio2org
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// chatStreamChunk is one server-sent event of a streamed chat completion.
+type chatStreamChunk struct {
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
+		Delta struct {
+			Content string `json:"content"`
+			Refusal string `json:"refusal"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	PromptFilterResults json.RawMessage `json:"prompt_filter_results"`
+	// Usage is only set on the last event, which has no choices, and only
+	// when stream_options.include_usage is requested.
+	Usage *Usage `json:"usage"`
+}
+
+// readChatStream reads the body of a -stream chat completion, copying each
+// piece of content to echo as it arrives unless echo is nil, and returns
+// the deltas put together as the response a request without streaming
+// would have returned.
+func readChatStream(body io.Reader, echo io.Writer) (OpenAIResponse, error) {
+	var aiResponse OpenAIResponse
+	var content, refusal strings.Builder
+	finishReason, choices, done := "", false, false
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return aiResponse, fmt.Errorf("unmarshalling OpenAI stream event: %w", err)
+		}
+		if chunk.Usage != nil {
+			aiResponse.Usage = chunk.Usage
+		}
+		if chunk.SystemFingerprint != "" {
+			aiResponse.SystemFingerprint = chunk.SystemFingerprint
+		}
+		if len(chunk.PromptFilterResults) > 0 {
+			if err := json.Unmarshal(chunk.PromptFilterResults, &aiResponse.PromptFilterResults); err != nil {
+				return aiResponse, fmt.Errorf("unmarshalling OpenAI stream event: %w", err)
+			}
+		}
+		for _, choice := range chunk.Choices {
+			choices = true
+			content.WriteString(choice.Delta.Content)
+			refusal.WriteString(choice.Delta.Refusal)
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+			if echo != nil && choice.Delta.Content != "" {
+				io.WriteString(echo, choice.Delta.Content)
+			}
+		}
+	}
+	if echo != nil && content.Len() > 0 {
+		io.WriteString(echo, "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return aiResponse, fmt.Errorf("reading OpenAI stream: %w", err)
+	}
+	if !done {
+		return aiResponse, errors.New("the OpenAI stream ended before [DONE]; the connection may have dropped")
+	}
+
+	if choices {
+		aiResponse.Choices = make([]struct {
+			Message struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+				Refusal string `json:"refusal"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		}, 1)
+		aiResponse.Choices[0].Message.Role = "assistant"
+		aiResponse.Choices[0].Message.Content = content.String()
+		aiResponse.Choices[0].Message.Refusal = refusal.String()
+		aiResponse.Choices[0].FinishReason = finishReason
+	}
+	return aiResponse, nil
+}
+
+// streamedChatBody reads a -stream response and returns it as the JSON
+// body of a response without streaming, for -keep-raw-response,
+// -debug-bundle, and parsing. An error status is read as usual.
+func streamedChatBody(config Config, resp *resty.Response) ([]byte, error) {
+	raw := resp.RawBody()
+	defer raw.Close()
+
+	if resp.IsError() {
+		body, err := io.ReadAll(raw)
+		if err != nil {
+			return nil, fmt.Errorf("reading OpenAI API error: %w", err)
+		}
+		var errorResponse OpenAIErrorResponse
+		json.Unmarshal(body, &errorResponse)
+		return body, openAIError("OpenAI API", resp.StatusCode(), resp.Status(), &errorResponse, string(body))
+	}
+
+	var echo io.Writer
+	if config.StreamEcho {
+		echo = os.Stderr
+	}
+	aiResponse, err := readChatStream(raw, echo)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(aiResponse)
+	if err != nil {
+		return nil, fmt.Errorf("encoding the streamed OpenAI response: %w", err)
+	}
+	return body, nil
+}
+
+func checkStream(config Config) error {
+	switch {
+	case config.StreamEcho && !config.Stream:
+		return errors.New("-stream-echo requires -stream")
+	case config.StreamEcho && config.Concurrency > 1:
+		return errors.New("-stream-echo cannot be combined with -concurrency above 1, whose log lines would be interleaved")
+	}
+	return nil
+}
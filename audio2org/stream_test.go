@@ -0,0 +1,129 @@
+package audio2org
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadChatStream(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		want         string
+		finishReason string
+		wantErr      string
+	}{
+		{
+			name: "deltas",
+			body: "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"* Not\"}}]}\n\n" +
+				": keep-alive\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"es\"},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: [DONE]\n\n",
+			want:         "* Notes",
+			finishReason: "stop",
+		},
+		{
+			name: "no space after data",
+			body: "data:{\"choices\":[{\"delta\":{\"content\":\"x\"}}]}\ndata:[DONE]\n",
+			want: "x",
+		},
+		{
+			name:    "dropped connection",
+			body:    "data: {\"choices\":[{\"delta\":{\"content\":\"* Not\"}}]}\n\n",
+			wantErr: "before [DONE]",
+		},
+		{
+			name:    "bad event",
+			body:    "data: {\"choices\":\n\n",
+			wantErr: "unmarshalling OpenAI stream event",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var echo bytes.Buffer
+			aiResponse, err := readChatStream(strings.NewReader(tt.body), &echo)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(aiResponse.Choices) != 1 {
+				t.Fatalf("got %d choices, want 1", len(aiResponse.Choices))
+			}
+			choice := aiResponse.Choices[0]
+			if choice.Message.Content != tt.want || choice.FinishReason != tt.finishReason {
+				t.Errorf("content = %q, finish_reason = %q, want %q, %q", choice.Message.Content, choice.FinishReason, tt.want, tt.finishReason)
+			}
+			if echo.String() != tt.want+"\n" {
+				t.Errorf("echoed %q, want %q", echo.String(), tt.want+"\n")
+			}
+		})
+	}
+}
+
+func TestChatRequestStream(t *testing.T) {
+	var reqBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"* Notes\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", Stream: true, MaxTokens: 100}
+	content, err := sendChatRequest(config, map[string]interface{}{"model": "gpt-4o"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "* Notes" {
+		t.Errorf("content = %q, want %q", content, "* Notes")
+	}
+	if reqBody["stream"] != true {
+		t.Errorf("request body stream = %v, want true", reqBody["stream"])
+	}
+}
+
+func TestChatRequestStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "Rate limit reached", "type": "requests"}}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", Stream: true}
+	_, err := sendChatRequest(config, map[string]interface{}{"model": "gpt-4o"}, "")
+	if err == nil || !strings.Contains(err.Error(), "Rate limit reached") {
+		t.Errorf("err = %v, want the API error message", err)
+	}
+}
+
+func TestCheckStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"off", Config{}, false},
+		{"stream", Config{Stream: true, Concurrency: 4}, false},
+		{"echo", Config{Stream: true, StreamEcho: true, Concurrency: 1}, false},
+		{"echo without stream", Config{StreamEcho: true}, true},
+		{"echo with concurrency", Config{Stream: true, StreamEcho: true, Concurrency: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkStream(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("checkStream() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
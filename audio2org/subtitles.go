@@ -0,0 +1,63 @@
+package audio2org
+
+import (
+	"fmt"
+	"strings"
+)
+
+func isSubtitleFormat(format string) bool {
+	return format == "srt" || format == "vtt"
+}
+
+func transcriptExtension(format string) string {
+	if isSubtitleFormat(format) || format == "json" {
+		return "." + format
+	}
+	return ".txt"
+}
+
+// formatSubtitles writes one cue per transcription segment. Segments with
+// no text are dropped and the remaining cues are numbered from 1.
+func formatSubtitles(format string, segments []TranscriptionSegment) string {
+	var b strings.Builder
+	if format == "vtt" {
+		b.WriteString("WEBVTT\n")
+	}
+
+	cue := 0
+	for _, segment := range segments {
+		if strings.TrimSpace(segment.Text) == "" {
+			continue
+		}
+		text := speakerText(segment)
+		cue++
+
+		if format == "vtt" {
+			fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", cue, formatVTTTimestamp(segment.Start), formatVTTTimestamp(segment.End), text)
+		} else {
+			if cue > 1 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n", cue, formatSRTTimestamp(segment.Start), formatSRTTimestamp(segment.End), text)
+		}
+	}
+	return b.String()
+}
+
+// formatTimestampedText writes each segment on its own line, after its
+// start time as [HH:MM:SS], for -timestamps.
+func formatTimestampedText(segments []TranscriptionSegment) string {
+	var lines []string
+	for _, segment := range segments {
+		if strings.TrimSpace(segment.Text) != "" {
+			lines = append(lines, fmt.Sprintf("[%s] %s", formatTimestamp(segment.Start), speakerText(segment)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSRTTimestamp is the VTT timestamp with the comma SRT uses before
+// the milliseconds.
+func formatSRTTimestamp(seconds float64) string {
+	return strings.Replace(formatVTTTimestamp(seconds), ".", ",", 1)
+}
@@ -0,0 +1,40 @@
+package audio2org
+
+import "testing"
+
+func TestFormatSubtitles(t *testing.T) {
+	segments := []TranscriptionSegment{
+		{Start: 0, End: 2.5, Text: " Hello there."},
+		{Start: 2.5, End: 3, Text: " "},
+		{Start: 3, End: 3725.25, Text: " A very long pause."},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"srt", "1\n00:00:00,000 --> 00:00:02,500\nHello there.\n\n2\n00:00:03,000 --> 01:02:05,250\nA very long pause.\n"},
+		{"vtt", "WEBVTT\n\n1\n00:00:00.000 --> 00:00:02.500\nHello there.\n\n2\n00:00:03.000 --> 01:02:05.250\nA very long pause.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := formatSubtitles(tt.format, segments); got != tt.want {
+				t.Errorf("formatSubtitles(%q) =\n%q\nwant\n%q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTimestampedText(t *testing.T) {
+	segments := []TranscriptionSegment{
+		{Start: 0, End: 2.5, Text: " Hello there."},
+		{Start: 2.5, End: 3, Text: " "},
+		{Start: 83.2, End: 90, Text: " Back to the agenda."},
+	}
+
+	want := "[00:00:00] Hello there.\n[00:01:23] Back to the agenda."
+	if got := formatTimestampedText(segments); got != want {
+		t.Errorf("formatTimestampedText() = %q, want %q", got, want)
+	}
+}
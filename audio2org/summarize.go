@@ -0,0 +1,71 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// minSummaryChunkTokens keeps -summary-chunk-tokens from splitting a
+// recording into so many parts that each summary says next to nothing.
+const minSummaryChunkTokens = 1000
+
+func checkSummaryChunkTokens(config Config) error {
+	switch {
+	case config.SummaryChunkTokens == 0:
+		return nil
+	case !hasPostStep(config, "create_emacs_org_notes") && !hasPostStep(config, "create_markdown_notes"):
+		return errors.New("-summary-chunk-tokens requires -post create_emacs_org_notes or create_markdown_notes")
+	case config.SummaryChunkTokens < minSummaryChunkTokens:
+		return fmt.Errorf("-summary-chunk-tokens must be 0 or at least %d, got %d", minSummaryChunkTokens, config.SummaryChunkTokens)
+	}
+	return nil
+}
+
+// summaryParts splits a transcript longer than -summary-chunk-tokens into
+// parts of about that many tokens, between sentences. Shorter transcripts
+// are returned as their only part.
+func summaryParts(config Config, transcriptionText string) []string {
+	if config.SummaryChunkTokens <= 0 || estimateTokens(transcriptionText) <= config.SummaryChunkTokens {
+		return []string{transcriptionText}
+	}
+	return splitBetweenSentences(transcriptionText, config.SummaryChunkTokens*4)
+}
+
+// condenseTranscript summarizes each part of a long transcript on its own
+// and returns the part summaries, in order, for the notes prompt to turn
+// into one file. A transcript that fits in one part is returned as is.
+func condenseTranscript(config Config, transcriptionText string) (string, error) {
+	parts := summaryParts(config, transcriptionText)
+	if len(parts) == 1 {
+		return transcriptionText, nil
+	}
+
+	log.Printf("The transcript is about %d tokens; summarizing it in %d parts first (-summary-chunk-tokens %d)\n",
+		estimateTokens(transcriptionText), len(parts), config.SummaryChunkTokens)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "The following are detailed summaries of the %d consecutive parts of one recording, in order. Treat them as one continuous piece of content.", len(parts))
+	for i, part := range parts {
+		log.Printf("Summarizing part %d of %d...\n", i+1, len(parts))
+		message := map[string]string{
+			"role":    "user",
+			"content": createPartSummaryPrompt(part, i+1, len(parts)),
+		}
+		summary, err := completeNotes(config, []map[string]string{message}, "")
+		if err != nil {
+			return "", fmt.Errorf("summarizing part %d of %d: %w", i+1, len(parts), err)
+		}
+		fmt.Fprintf(&b, "\n\nPart %d of %d:\n\n%s", i+1, len(parts), strings.TrimSpace(summary))
+	}
+	return b.String(), nil
+}
+
+func createPartSummaryPrompt(transcriptionText string, part, parts int) string {
+	return fmt.Sprintf(`The following is part %d of %d of a transcript. Summarize it in detail as plain text, keeping every topic, decision, example, name, number, and action item it mentions, in the order they come up. It will be combined with the summaries of the other parts later, so do not add an introduction, a conclusion, or any formatting beyond paragraphs and simple lists.
+
+Here is the transcript part:
+
+%s`, part, parts, transcriptionText)
+}
@@ -0,0 +1,64 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckSummaryChunkTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"off", Config{PostProcessCmd: "create_glossary"}, ""},
+		{"org notes", Config{PostProcessCmd: "create_emacs_org_notes", SummaryChunkTokens: 8000}, ""},
+		{"markdown notes", Config{PostProcessCmd: "create_markdown_notes", SummaryChunkTokens: 1000}, ""},
+		{"other command", Config{PostProcessCmd: "create_glossary", SummaryChunkTokens: 8000}, "requires -post"},
+		{"too small", Config{PostProcessCmd: "create_emacs_org_notes", SummaryChunkTokens: 200}, "at least 1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSummaryChunkTokens(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkSummaryChunkTokens() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkSummaryChunkTokens() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCondenseTranscript(t *testing.T) {
+	dir := t.TempDir()
+	summarizer := filepath.Join(dir, "summarizer.sh")
+	// Counts the calls and answers each with its number.
+	script := "#!/bin/sh\ncat > /dev/null\necho x >> " + filepath.Join(dir, "calls") + "\nprintf 'Summary %s.' $(wc -l < " + filepath.Join(dir, "calls") + ")\n"
+	if err := os.WriteFile(summarizer, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{SummarizerCmd: summarizer, SummaryChunkTokens: 1000}
+
+	short := "A short meeting."
+	if got, err := condenseTranscript(config, short); err != nil || got != short {
+		t.Errorf("condenseTranscript(short) = %q, %v, want it unchanged", got, err)
+	}
+
+	long := strings.Repeat("This sentence is about forty characters. ", 250)
+	got, err := condenseTranscript(config, long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{"3 consecutive parts", "Part 1 of 3:\n\nSummary 1.", "Part 3 of 3:\n\nSummary 3."} {
+		if !strings.Contains(got, part) {
+			t.Errorf("condenseTranscript() = %q, want it to contain %q", got, part)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package audio2org
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// runSummarizerCmd sends prompt to an external command on stdin and returns
+// its stdout, for summarizing with a local model instead of the chat API.
+// The command is split on whitespace and run directly, without a shell. If
+// it fails, the error includes what it wrote to stderr.
+func runSummarizerCmd(config Config, prompt string) (string, error) {
+	args := strings.Fields(config.SummarizerCmd)
+	log.Printf("Running external summarizer: %s\n", args[0])
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runContext(config), args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(prompt)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running summarizer command: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	if output == "" {
+		return "", errors.New("summarizer command produced no output")
+	}
+	return output + "\n", nil
+}
@@ -0,0 +1,53 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSummarizerCmd(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "summarize.sh")
+	content := `case "$1" in
+echo) printf 'Summary of: '; cat ;;
+fail) echo 'model llama3 not found' >&2; exit 3 ;;
+empty) cat >/dev/null; printf '  \n' ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		want    string
+		wantErr []string
+	}{
+		{"prompt on stdin, result on stdout", "echo", "Summary of: Summarize this talk.\n", nil},
+		{"non-zero exit", "fail", "", []string{"exit status 3", "model llama3 not found"}},
+		{"empty output", "empty", "", []string{"produced no output"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{SummarizerCmd: "sh " + script + " " + tt.mode}
+			got, err := runSummarizerCmd(config, "Summarize this talk.")
+			if tt.wantErr != nil {
+				for _, want := range tt.wantErr {
+					if err == nil || !strings.Contains(err.Error(), want) {
+						t.Errorf("runSummarizerCmd() error = %v, want it to contain %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("runSummarizerCmd() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
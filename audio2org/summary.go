@@ -0,0 +1,96 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+type JSONSummary struct {
+	Title       string   `json:"title"`
+	Summary     string   `json:"summary"`
+	Bullets     []string `json:"bullets"`
+	ActionItems []string `json:"action_items"`
+}
+
+func createJSONSummary(config Config, transcriptionText, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_json_summary command...")
+
+	message := map[string]string{
+		"role":    "user",
+		"content": createJSONSummaryPrompt(transcriptionText),
+	}
+
+	reqBody := map[string]interface{}{
+		"model":           config.SummaryModel,
+		"messages":        []map[string]string{message},
+		"max_tokens":      3000,
+		"temperature":     0.7,
+		"response_format": map[string]string{"type": "json_object"},
+	}
+	if config.StructuredOutput {
+		reqBody["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "summary",
+				"strict": true,
+				"schema": jsonSchemaFor(JSONSummary{}),
+			},
+		}
+	}
+
+	content, err := sendChatRequest(config, reqBody, chatResponsePath(config, baseFilePath, ""))
+	if err != nil {
+		return "", err
+	}
+	summaryJSON, err := validateJSONSummary(content)
+	if err != nil {
+		return "", fmt.Errorf("invalid JSON summary from OpenAI API: %w", err)
+	}
+
+	outputFilePath := generateDerivedFilePath(baseFilePath, "_summary.json")
+	if err := writeToFile(config, outputFilePath, summaryJSON); err != nil {
+		return "", err
+	}
+	return summaryJSON, nil
+}
+
+// validateJSONSummary checks that content, with any Markdown code fence
+// around it removed, is a JSON summary with a title and a summary, and
+// returns it indented, with empty arrays for missing lists.
+func validateJSONSummary(content string) (string, error) {
+	var summary JSONSummary
+	if err := json.Unmarshal([]byte(stripCodeFence(content)), &summary); err != nil {
+		return "", err
+	}
+	if summary.Title == "" || summary.Summary == "" {
+		return "", fmt.Errorf("missing required title or summary field")
+	}
+	if summary.Bullets == nil {
+		summary.Bullets = []string{}
+	}
+	if summary.ActionItems == nil {
+		summary.ActionItems = []string{}
+	}
+
+	out, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+func createJSONSummaryPrompt(transcriptionText string) string {
+	return fmt.Sprintf(`Summarize the following content as a single JSON object with exactly these fields:
+
+- "title": a short descriptive title (string)
+- "summary": a one-paragraph overview of the key points (string)
+- "bullets": the key points, one per entry (array of strings)
+- "action_items": concrete follow-ups or tasks mentioned, or an empty array if there are none (array of strings)
+
+Respond with the JSON object only.
+
+Here is the content to summarize:
+
+%s`, transcriptionText)
+}
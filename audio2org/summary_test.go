@@ -0,0 +1,68 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateJSONSummary(t *testing.T) {
+	indented := `{
+  "title": "Sync",
+  "summary": "Planning for the launch.",
+  "bullets": [
+    "Ship on Friday"
+  ],
+  "action_items": []
+}
+`
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: `{"title": "Sync", "summary": "Planning for the launch.", "bullets": ["Ship on Friday"]}`,
+			want:    indented,
+		},
+		{
+			name:    "inside a json fence",
+			content: "```json\n{\"title\": \"Sync\", \"summary\": \"Planning for the launch.\", \"bullets\": [\"Ship on Friday\"]}\n```\n",
+			want:    indented,
+		},
+		{
+			name:    "missing summary",
+			content: `{"title": "Sync", "bullets": []}`,
+			wantErr: "missing required title or summary field",
+		},
+		{
+			name:    "missing title",
+			content: `{"summary": "Planning for the launch."}`,
+			wantErr: "missing required title or summary field",
+		},
+		{
+			name:    "invalid JSON",
+			content: `{"title": "Sync",`,
+			wantErr: "unexpected end of JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateJSONSummary(tt.content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("validateJSONSummary() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("validateJSONSummary() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
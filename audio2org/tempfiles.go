@@ -0,0 +1,64 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+const tempFilePrefix = "audio2org-"
+
+var (
+	tempFilesMu sync.Mutex
+	tempFiles   = map[string]struct{}{}
+	// keepTempFiles is -keep-temp: temp files are left in place and their
+	// paths logged, to inspect what was uploaded.
+	keepTempFiles bool
+)
+
+// createTempFile creates an empty, closed temp file named
+// audio2org-<kind>-*<ext> and tracks it so cleanupTempFiles can remove it
+// if the run ends early.
+func createTempFile(kind, ext string) (string, error) {
+	f, err := os.CreateTemp("", tempFilePrefix+kind+"-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	f.Close()
+
+	tempFilesMu.Lock()
+	tempFiles[f.Name()] = struct{}{}
+	tempFilesMu.Unlock()
+	return f.Name(), nil
+}
+
+func removeTempFile(path string) {
+	if keepTempFiles {
+		log.Printf("Keeping temp file %s (-keep-temp)\n", path)
+		tempFilesMu.Lock()
+		delete(tempFiles, path)
+		tempFilesMu.Unlock()
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing temp file: %v\n", err)
+	}
+
+	tempFilesMu.Lock()
+	delete(tempFiles, path)
+	tempFilesMu.Unlock()
+}
+
+func cleanupTempFiles() {
+	tempFilesMu.Lock()
+	paths := make([]string, 0, len(tempFiles))
+	for path := range tempFiles {
+		paths = append(paths, path)
+	}
+	tempFilesMu.Unlock()
+
+	for _, path := range paths {
+		removeTempFile(path)
+	}
+}
@@ -0,0 +1,31 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanupTempFilesOnPanic(t *testing.T) {
+	var path string
+	func() {
+		defer func() {
+			recover()
+			cleanupTempFiles()
+		}()
+		var err error
+		path, err = createTempFile("test", ".mp3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		panic("boom")
+	}()
+
+	if !strings.HasPrefix(filepath.Base(path), tempFilePrefix+"test-") || filepath.Ext(path) != ".mp3" {
+		t.Fatalf("unexpected temp file name %q", path)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("temp file %s still exists after cleanup", path)
+	}
+}
@@ -0,0 +1,80 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// needsTranscode reports whether -transcode applies to the -file input,
+// which is when Whisper does not accept its extension.
+func needsTranscode(config Config) bool {
+	return config.Transcode && !slices.Contains(supportedExtensions, strings.ToLower(filepath.Ext(config.AudioFilePath)))
+}
+
+func requireTranscodeFFmpeg(path string) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("-transcode needs ffmpeg to convert %s, but it was not found on PATH; install ffmpeg, or convert the file to one of %s yourself",
+			path, strings.Join(supportedExtensions, " "))
+	}
+	return nil
+}
+
+// uploadSource returns the file to read the -file audio from: the input
+// itself, or with -transcode a temp MP3 copy of an input Whisper would not
+// accept. cleanup removes the copy.
+func uploadSource(config Config) (path string, cleanup func(), err error) {
+	if !needsTranscode(config) {
+		return config.AudioFilePath, func() {}, nil
+	}
+	if err := requireTranscodeFFmpeg(config.AudioFilePath); err != nil {
+		return "", nil, err
+	}
+
+	tmpPath, err := createTempFile("transcoded", ".mp3")
+	if err != nil {
+		return "", nil, err
+	}
+	log.Printf("Transcoding %s to MP3 for upload (-transcode)...\n", config.AudioFilePath)
+	if err := runFFmpeg("-i", config.AudioFilePath, "-vn", "-ac", "1", "-ar", "16000", "-b:a", "64k", tmpPath); err != nil {
+		removeTempFile(tmpPath)
+		return "", nil, err
+	}
+	return tmpPath, func() { removeTempFile(tmpPath) }, nil
+}
+
+// uploadFileName is the file name sent with the audio: the -file input's
+// name, with the extension of what is actually uploaded so Whisper reads
+// a transcoded copy as MP3.
+func uploadFileName(config Config, uploadPath string) string {
+	ext := filepath.Ext(uploadPath)
+	return strings.TrimSuffix(config.AudioFilePath, filepath.Ext(config.AudioFilePath)) + ext
+}
+
+// multipartFileName is the file name in the upload form, from which Whisper
+// infers the format: the -upload-filename, or the upload's base name.
+func multipartFileName(config Config, filePath string) string {
+	if config.UploadFilename != "" {
+		return config.UploadFilename
+	}
+	return filepath.Base(filePath)
+}
+
+func checkUploadFilename(config Config) error {
+	name := config.UploadFilename
+	switch {
+	case name == "":
+		return nil
+	case config.Backend == "local":
+		return errors.New("-upload-filename names the file sent to the API and does not apply to -backend local")
+	case strings.ContainsAny(name, `/\`) || name == "." || name == "..":
+		return fmt.Errorf("-upload-filename %q must be a file name, not a path", name)
+	case !slices.Contains(supportedExtensions, strings.ToLower(filepath.Ext(name))):
+		return fmt.Errorf("-upload-filename %q needs an extension Whisper accepts, which it reads the format from: %s", name, strings.Join(supportedExtensions, " "))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNeedsTranscode(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   bool
+	}{
+		{"opus", Config{AudioFilePath: "memo.opus", Transcode: true}, true},
+		{"no extension", Config{AudioFilePath: "memo", Transcode: true}, true},
+		{"supported", Config{AudioFilePath: "memo.OGG", Transcode: true}, false},
+		{"off", Config{AudioFilePath: "memo.opus"}, false},
+	}
+	for _, tt := range tests {
+		if got := needsTranscode(tt.config); got != tt.want {
+			t.Errorf("%s: needsTranscode() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUploadSource(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "memo.opus")
+	if err := os.WriteFile(input, []byte("opus audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{AudioFilePath: input, Transcode: true, MaxChunkMB: 24}
+
+	t.Setenv("PATH", t.TempDir())
+	if err := checkAudioFile(config); err == nil || !strings.Contains(err.Error(), "install ffmpeg, or convert the file") {
+		t.Errorf("checkAudioFile() without ffmpeg = %v, want a hint to install it", err)
+	}
+
+	// A stand-in ffmpeg that writes "mp3 audio" to its last argument.
+	bin := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\nprintf 'mp3 audio' > \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	if err := checkAudioFile(config); err != nil {
+		t.Errorf("checkAudioFile() with ffmpeg = %v, want nil", err)
+	}
+
+	path, cleanup, err := uploadSource(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "mp3 audio" || filepath.Ext(path) != ".mp3" {
+		t.Errorf("uploadSource() = %s containing %q, want a transcoded .mp3", path, data)
+	}
+	if name := uploadFileName(config, path); name != filepath.Join(dir, "memo.mp3") {
+		t.Errorf("uploadFileName() = %q, want the input name with .mp3", name)
+	}
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("cleanup() left %s behind", path)
+	}
+}
+
+func TestCheckUploadFilename(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"not set", Config{}, ""},
+		{"ok", Config{UploadFilename: "audio.M4A"}, ""},
+		{"path", Config{UploadFilename: "clients/audio.mp3"}, "not a path"},
+		{"no extension", Config{UploadFilename: "audio"}, "needs an extension"},
+		{"unsupported extension", Config{UploadFilename: "audio.aiff"}, "needs an extension"},
+		{"local backend", Config{UploadFilename: "audio.mp3", Backend: "local"}, "-backend local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUploadFilename(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkUploadFilename() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkUploadFilename() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package audio2org
+
+import "io"
+
+// Transcriber turns audio into text. filePath names the audio, and its
+// extension tells the backend the format; audio reads the audio itself,
+// which may be one chunk of the file, and is read from the start each time.
+// extraForm carries the Whisper request options that callers add, such as
+// the prompt, the temperature, or the segment timestamps.
+type Transcriber interface {
+	Transcribe(filePath string, audio io.ReadSeeker, extraForm map[string]string) (TranscriptionResponse, error)
+}
+
+var backends = []string{"openai", "local"}
+
+func newTranscriber(config Config) Transcriber {
+	if config.Backend == "local" {
+		return LocalTranscriber{config: config}
+	}
+	return OpenAITranscriber{config: config}
+}
+
+// OpenAITranscriber uploads the audio to the transcriptions API.
+type OpenAITranscriber struct {
+	config Config
+}
+
+func (t OpenAITranscriber) Transcribe(filePath string, audio io.ReadSeeker, extraForm map[string]string) (TranscriptionResponse, error) {
+	return sendTranscription(t.config, filePath, audio, extraForm)
+}
+
+// transcriptionModel identifies the model behind a transcription, for the
+// chunk cache.
+func transcriptionModel(config Config) string {
+	if config.Backend == "local" {
+		return "whisper.cpp:" + config.WhisperModel
+	}
+	return config.TranscribeModel
+}
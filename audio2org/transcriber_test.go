@@ -0,0 +1,67 @@
+package audio2org
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTranscriber(t *testing.T) {
+	if _, ok := newTranscriber(Config{Backend: "local"}).(LocalTranscriber); !ok {
+		t.Error("newTranscriber(-backend local) is not a LocalTranscriber")
+	}
+	if _, ok := newTranscriber(Config{Backend: "openai"}).(OpenAITranscriber); !ok {
+		t.Error("newTranscriber(-backend openai) is not an OpenAITranscriber")
+	}
+}
+
+func TestOpenAITranscriberSendsTheAudio(t *testing.T) {
+	var model, prompt, fileName, audio string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		model, prompt = r.FormValue("model"), r.FormValue("prompt")
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		fileName, audio = header.Filename, string(data)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Hello there.", "language": "english", "duration": 2.5}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", TranscribeModel: "gpt-4o-transcribe", Timeout: time.Minute}
+	transcription, err := newTranscriber(config).Transcribe("talks/keynote.mp3", strings.NewReader("chunk audio"), map[string]string{"prompt": "Okonkwo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if model != "gpt-4o-transcribe" || prompt != "Okonkwo" {
+		t.Errorf("form model = %q, prompt = %q; want the -transcribe-model and the extra prompt", model, prompt)
+	}
+	if fileName != "keynote.mp3" || audio != "chunk audio" {
+		t.Errorf("uploaded %q as %q, want the audio as keynote.mp3", audio, fileName)
+	}
+	if transcription.Text != "Hello there." || transcription.Language != "english" || transcription.Duration != 2.5 {
+		t.Errorf("Transcribe() = %+v", transcription)
+	}
+}
+
+func TestTranscriptionModel(t *testing.T) {
+	tests := []struct {
+		config Config
+		want   string
+	}{
+		{Config{Backend: "openai", TranscribeModel: "whisper-1"}, "whisper-1"},
+		{Config{Backend: "local", TranscribeModel: "whisper-1", WhisperModel: "ggml-base.en.bin"}, "whisper.cpp:ggml-base.en.bin"},
+	}
+	for _, tt := range tests {
+		if got := transcriptionModel(tt.config); got != tt.want {
+			t.Errorf("transcriptionModel(%+v) = %q, want %q", tt.config, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// createOrgTranscript writes the full transcript as an org plain list, one
+// item per segment, each starting with a link that opens the audio at the
+// segment's offset.
+func createOrgTranscript(config Config, transcription TranscriptionResponse, baseFilePath string) (string, error) {
+	log.Println("Starting post-processing with create_org_transcript command...")
+
+	if len(transcription.Segments) == 0 {
+		return "", errors.New("create_org_transcript: the transcription has no segments")
+	}
+
+	outputFilePath := generateDerivedFilePath(baseFilePath, "_transcript.org")
+	orgTranscript := formatOrgTranscript(transcription.Segments, orgAudioLink(config, outputFilePath))
+	if err := writeToFile(config, outputFilePath, orgTranscript); err != nil {
+		return "", err
+	}
+
+	if config.EmacsLint {
+		lintOrgFile(config, outputFilePath)
+	}
+	return orgTranscript, nil
+}
+
+// orgAudioLink returns the audio path as written in the links: relative to
+// the org file so the pair can be moved together, or absolute when the org
+// file is uploaded with -output-uri.
+func orgAudioLink(config Config, orgFilePath string) string {
+	if config.OutputURI != "" {
+		if audioPath, err := filepath.Abs(config.AudioFilePath); err == nil {
+			return audioPath
+		}
+		return config.AudioFilePath
+	}
+	return relativeLink(config.AudioFilePath, orgFilePath)
+}
+
+// relativeLink returns target as a path relative to the directory of the
+// org file, falling back to the absolute path.
+func relativeLink(target, orgFilePath string) string {
+	targetPath, err := filepath.Abs(target)
+	if err != nil {
+		return target
+	}
+
+	orgDir, err := filepath.Abs(filepath.Dir(orgFilePath))
+	if err != nil {
+		return targetPath
+	}
+	if rel, err := filepath.Rel(orgDir, targetPath); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return targetPath
+}
+
+func formatOrgTranscript(segments []TranscriptionSegment, audioLink string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#+title: Transcript of %s\n\n", filepath.Base(audioLink))
+	for _, segment := range segments {
+		text := strings.Join(strings.Fields(segment.Text), " ")
+		if text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "- [[file:%s::%d][%s]] %s\n", audioLink, int(segment.Start), formatListTimestamp(segment.Start), text)
+	}
+	return b.String()
+}
+
+// formatListTimestamp formats seconds as MM:SS, letting the minutes run past
+// 59 for recordings over an hour.
+func formatListTimestamp(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
@@ -0,0 +1,42 @@
+package audio2org
+
+import "testing"
+
+func TestFormatOrgTranscript(t *testing.T) {
+	segments := []TranscriptionSegment{
+		{Start: 0, End: 4.2, Text: " Welcome back to the show."},
+		{Start: 4.2, End: 5, Text: "  "},
+		{Start: 83.9, End: 90, Text: " Today we talk\nabout compilers."},
+		{Start: 3725, End: 3730, Text: " Thanks for listening."},
+	}
+
+	want := `#+title: Transcript of talk.mp3
+
+- [[file:../talk.mp3::0][00:00]] Welcome back to the show.
+- [[file:../talk.mp3::83][01:23]] Today we talk about compilers.
+- [[file:../talk.mp3::3725][62:05]] Thanks for listening.
+`
+	if got := formatOrgTranscript(segments, "../talk.mp3"); got != want {
+		t.Errorf("formatOrgTranscript() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOrgAudioLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		audio   string
+		orgPath string
+		want    string
+	}{
+		{"audio next to the output directory", "talk.mp3", "output/talk_transcript.org", "../talk.mp3"},
+		{"audio in the output directory", "output/talk.mp3", "output/talk_transcript.org", "talk.mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orgAudioLink(Config{AudioFilePath: tt.audio}, tt.orgPath); got != tt.want {
+				t.Errorf("orgAudioLink() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
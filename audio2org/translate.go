@@ -0,0 +1,36 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+)
+
+// checkTranslate rejects -translate with the flags that assume the
+// transcript is in the spoken language.
+func checkTranslate(config Config) error {
+	if !config.Translate {
+		return nil
+	}
+	switch {
+	case config.Backend == "local":
+		return nil
+	case config.TranscribeModel != "whisper-1":
+		return fmt.Errorf("-translate uses Whisper's translations endpoint, which only supports whisper-1, not -transcribe-model %s", config.TranscribeModel)
+	case config.Language != "":
+		return errors.New("-language cannot be combined with -translate, since the translations endpoint detects the spoken language itself")
+	case config.Multilang:
+		return errors.New("-multilang keeps each language as spoken and cannot be combined with -translate")
+	case config.Compare != "":
+		return errors.New("-compare compares transcription models and cannot be combined with -translate")
+	}
+	return nil
+}
+
+// transcriptionEndpoint is the Whisper API path audio is uploaded to: the
+// translations endpoint with -translate, which always returns English.
+func transcriptionEndpoint(config Config) string {
+	if config.Translate {
+		return "/audio/translations"
+	}
+	return "/audio/transcriptions"
+}
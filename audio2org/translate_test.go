@@ -0,0 +1,61 @@
+package audio2org
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckTranslate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"off", Config{TranscribeModel: "gpt-4o-transcribe", Language: "es"}, ""},
+		{"whisper-1", Config{Translate: true, TranscribeModel: "whisper-1"}, ""},
+		{"local with language", Config{Translate: true, Backend: "local", Language: "es"}, ""},
+		{"other model", Config{Translate: true, TranscribeModel: "gpt-4o-transcribe"}, "only supports whisper-1"},
+		{"language", Config{Translate: true, TranscribeModel: "whisper-1", Language: "es"}, "-language"},
+		{"multilang", Config{Translate: true, TranscribeModel: "whisper-1", Multilang: true}, "-multilang"},
+		{"compare", Config{Translate: true, TranscribeModel: "whisper-1", Compare: "whisper-1,gpt-4o-transcribe"}, "-compare"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTranslate(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkTranslate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkTranslate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSendTranscriptionTranslate(t *testing.T) {
+	var path, model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, model = r.URL.Path, r.FormValue("model")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Good morning, everyone."}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL + "/v1", TranscribeModel: "whisper-1", Translate: true, RetryLog: "quiet"}
+	got, err := sendTranscription(config, "reunion.mp3", strings.NewReader("audio"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/audio/translations" || model != "whisper-1" {
+		t.Errorf("request to %s with model %q, want /v1/audio/translations with whisper-1", path, model)
+	}
+	if got.Text != "Good morning, everyone." {
+		t.Errorf("sendTranscription() text = %q", got.Text)
+	}
+}
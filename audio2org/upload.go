@@ -0,0 +1,115 @@
+package audio2org
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// multipartUpload is the body of a transcription request. resty reads any
+// request body into memory, a multipart one twice, so instead the form
+// fields are encoded up front and the audio is read from its reader while
+// the request is sent.
+type multipartUpload struct {
+	fields      url.Values
+	audio       io.ReadSeeker
+	size        int64
+	head, tail  []byte
+	contentType string
+	r           io.Reader
+}
+
+func newMultipartUpload(fields url.Values, fileName string, audio io.ReadSeeker) (*multipartUpload, error) {
+	size, err := audio.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("reading audio file: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range fields[key] {
+			if err := w.WriteField(key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if _, err := w.CreateFormFile("file", fileName); err != nil {
+		return nil, err
+	}
+	head := bytes.Clone(buf.Bytes())
+	buf.Reset()
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	u := &multipartUpload{fields: fields, audio: audio, size: size, head: head, tail: buf.Bytes(), contentType: w.FormDataContentType()}
+	return u, u.rewind()
+}
+
+func (u *multipartUpload) Read(p []byte) (int, error) {
+	return u.r.Read(p)
+}
+
+// rewind starts the body over, for the next attempt of the request.
+func (u *multipartUpload) rewind() error {
+	if _, err := u.audio.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading audio file: %w", err)
+	}
+	u.r = io.MultiReader(bytes.NewReader(u.head), u.audio, bytes.NewReader(u.tail))
+	return nil
+}
+
+func (u *multipartUpload) contentLength() int64 {
+	return int64(len(u.head)) + u.size + int64(len(u.tail))
+}
+
+type uploadContextKey struct{}
+
+// setUpload sends upload as the body of the request. resty is given no
+// body at all; attachUpload, which runs before every attempt, including
+// retries, attaches the upload from its start.
+func setUpload(request *resty.Request, upload *multipartUpload) {
+	request.SetHeader("Content-Type", upload.contentType).
+		SetContext(context.WithValue(request.Context(), uploadContextKey{}, upload))
+}
+
+// attachUpload is the client's pre-request hook: it sets the body of a
+// request given an upload by setUpload, with a Content-Length so it is not
+// sent with chunked encoding, and leaves other requests alone. The upload
+// travels in the request's context, so one client can send several at
+// once.
+func attachUpload(_ *resty.Client, r *http.Request) error {
+	upload, ok := r.Context().Value(uploadContextKey{}).(*multipartUpload)
+	if !ok {
+		return nil
+	}
+	if err := upload.rewind(); err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(upload)
+	r.ContentLength = upload.contentLength()
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(upload), upload.rewind()
+	}
+	return nil
+}
+
+// requestUpload returns the upload setUpload attached to a request, for
+// logging its form fields.
+func requestUpload(request *resty.Request) (*multipartUpload, bool) {
+	upload, ok := request.Context().Value(uploadContextKey{}).(*multipartUpload)
+	return upload, ok
+}
@@ -0,0 +1,154 @@
+package audio2org
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// patternAudio is an io.ReadSeeker of size bytes generated as they are
+// read, so a test can upload a large file without holding it in memory.
+type patternAudio struct {
+	size, offset int64
+}
+
+func (a *patternAudio) Read(p []byte) (int, error) {
+	if a.offset >= a.size {
+		return 0, io.EOF
+	}
+	n := int(min(int64(len(p)), a.size-a.offset))
+	for i := range p[:n] {
+		p[i] = byte(a.offset + int64(i))
+	}
+	a.offset += int64(n)
+	return n, nil
+}
+
+func (a *patternAudio) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		a.offset = offset
+	case io.SeekCurrent:
+		a.offset += offset
+	case io.SeekEnd:
+		a.offset = a.size + offset
+	}
+	return a.offset, nil
+}
+
+func TestSendTranscriptionStreamsTheFile(t *testing.T) {
+	const size = 32 << 20
+	var received int64
+	var contentLength int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		reader, err := r.MultipartReader()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			if part.FormName() == "file" {
+				received, _ = io.Copy(io.Discard, part)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Hello."}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", TranscribeModel: "whisper-1", Timeout: time.Minute}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	if _, err := sendTranscription(config, "talk.mp3", &patternAudio{size: size}, nil); err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&after)
+
+	if received != size {
+		t.Errorf("server received %d bytes of audio, want %d", received, size)
+	}
+	if contentLength <= size {
+		t.Errorf("Content-Length = %d, want the size of the whole body", contentLength)
+	}
+	// Buffering the body would allocate at least the file's size, once
+	// for resty's buffer and once more for its copy.
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/4 {
+		t.Errorf("uploading %d MB allocated %d MB; the file was buffered instead of streamed", size>>20, allocated>>20)
+	}
+}
+
+func TestSendTranscriptionRetryResendsTheFile(t *testing.T) {
+	var attempts int
+	var lastFile string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		lastFile = string(data)
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Hello."}`))
+	}))
+	defer server.Close()
+
+	config := Config{
+		OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", TranscribeModel: "whisper-1",
+		Timeout: time.Minute, MaxRetries: 1, RetryBaseDelay: time.Millisecond, RetryLog: "quiet",
+	}
+	if _, err := sendTranscription(config, "talk.mp3", strings.NewReader("the whole audio"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 || lastFile != "the whole audio" {
+		t.Errorf("attempts = %d, last file = %q, want the retry to send the whole file again", attempts, lastFile)
+	}
+}
+
+func TestSendTranscriptionUploadFilename(t *testing.T) {
+	tests := []struct {
+		name           string
+		uploadFilename string
+		want           string
+	}{
+		{"base name by default", "", "talk.m4a"},
+		{"override", "audio.mp3", "audio.mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fileName string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, header, err := r.FormFile("file"); err == nil {
+					fileName = header.Filename
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"text": "Hello."}`))
+			}))
+			defer server.Close()
+
+			config := Config{OpenAIAPIKey: "key", BaseURL: server.URL, AuthHeader: "bearer", TranscribeModel: "whisper-1", Timeout: time.Minute, UploadFilename: tt.uploadFilename}
+			if _, err := sendTranscription(config, "/home/me/clients/acme/talk.m4a", strings.NewReader("audio"), nil); err != nil {
+				t.Fatal(err)
+			}
+			if fileName != tt.want {
+				t.Errorf("uploaded file name = %q, want %q", fileName, tt.want)
+			}
+		})
+	}
+}
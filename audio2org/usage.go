@@ -0,0 +1,98 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage is the token count a chat completion reports, which is what the
+// request is billed by.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// runUsage adds up the chat usage of one input for the line logged when it
+// is done. The copies of the config made for each -post step share it.
+type runUsage struct {
+	mu       sync.Mutex
+	requests int
+	tokens   Usage
+	cost     float64
+	// unpriced lists the models without a chatPrices entry, whose tokens
+	// are left out of the cost.
+	unpriced []string
+}
+
+// addChat logs the usage of one chat response and adds it to the run's
+// total. A nil runUsage only logs.
+func (u *runUsage) addChat(model string, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	log.Printf("Chat usage: %d prompt + %d completion = %d tokens\n", usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.requests++
+	u.tokens.PromptTokens += usage.PromptTokens
+	u.tokens.CompletionTokens += usage.CompletionTokens
+	u.tokens.TotalTokens += usage.TotalTokens
+	if price, ok := chatPrices[model]; ok {
+		u.cost += (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6
+	} else if !slices.Contains(u.unpriced, model) {
+		u.unpriced = append(u.unpriced, model)
+	}
+}
+
+// usageSummary is the line logged when an input is done: how long the
+// audio was, the tokens its chat requests used, and what both cost at the
+// -dry-run prices. The duration is 0 when it is unknown.
+func usageSummary(config Config, u *runUsage, duration time.Duration) string {
+	var parts []string
+	cost := 0.0
+	switch {
+	case config.AudioFilePath == "":
+	case duration > 0:
+		parts = append(parts, fmt.Sprintf("%s of audio", duration.Round(time.Second)))
+		cost += transcriptionCost(config, duration)
+	default:
+		parts = append(parts, "audio of unknown duration")
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.requests == 0 {
+		parts = append(parts, "no chat requests")
+	} else {
+		parts = append(parts, fmt.Sprintf("%d chat %s using %d tokens (%d prompt, %d completion)",
+			u.requests, plural(u.requests, "request", "requests"), u.tokens.TotalTokens, u.tokens.PromptTokens, u.tokens.CompletionTokens))
+	}
+	cost += u.cost
+
+	line := "Done: " + strings.Join(parts, ", ")
+	if cost > 0 {
+		line += fmt.Sprintf(", about $%.4f", cost)
+		if len(u.unpriced) > 0 {
+			line += " not counting " + strings.Join(u.unpriced, ", ") + ", whose price is unknown"
+		}
+	}
+	return line
+}
+
+// transcriptionCost is what transcribing this much audio costs at the
+// -dry-run prices; nothing with -backend local.
+func transcriptionCost(config Config, duration time.Duration) float64 {
+	if config.AudioFilePath == "" || config.Backend == "local" {
+		return 0
+	}
+	return duration.Minutes() * transcribePricesPerMinute[config.TranscribeModel]
+}
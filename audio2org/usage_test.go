@@ -0,0 +1,61 @@
+package audio2org
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestUsageSummary(t *testing.T) {
+	var buf bytes.Buffer
+	captureLog(t, &buf)
+
+	tests := []struct {
+		name     string
+		config   Config
+		chats    map[string]Usage
+		duration time.Duration
+		want     string
+	}{
+		{
+			name:     "transcript only",
+			config:   Config{AudioFilePath: "talk.mp3", TranscribeModel: "whisper-1"},
+			duration: 10 * time.Minute,
+			want:     "Done: 10m0s of audio, no chat requests, about $0.0600",
+		},
+		{
+			name:     "notes",
+			config:   Config{AudioFilePath: "talk.mp3", TranscribeModel: "whisper-1"},
+			chats:    map[string]Usage{"gpt-4o": {PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}},
+			duration: 90 * time.Second,
+			want:     "Done: 1m30s of audio, 1 chat request using 1500 tokens (1000 prompt, 500 completion), about $0.0165",
+		},
+		{
+			name:   "existing transcript with an unpriced model",
+			config: Config{TranscriptionFilePath: "talk.txt"},
+			chats: map[string]Usage{
+				"gpt-4o":  {PromptTokens: 1000, CompletionTokens: 0, TotalTokens: 1000},
+				"llama-3": {PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+			},
+			want: "Done: 2 chat requests using 1015 tokens (1010 prompt, 5 completion), about $0.0025 not counting llama-3, whose price is unknown",
+		},
+		{
+			name:   "unknown duration with -backend local",
+			config: Config{AudioFilePath: "talk.mp3", Backend: "local"},
+			want:   "Done: audio of unknown duration, no chat requests",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &runUsage{}
+			for _, model := range []string{"gpt-4o", "llama-3"} {
+				if usage, ok := tt.chats[model]; ok {
+					u.addChat(model, &usage)
+				}
+			}
+			if got := usageSummary(tt.config, u, tt.duration); got != tt.want {
+				t.Errorf("usageSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	vadNoiseThreshold = "-35dB"
+	vadMinSilence     = 1.0
+	vadPadding        = 0.25
+)
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: (-?[\d.]+)`)
+)
+
+type audioRegion struct {
+	Start float64
+	End   float64
+}
+
+// transcribeSpeechRegions transcribes each region with speech and prefixes
+// its text with the region's start time. If a region fails after others
+// finished, the error is a *partialTranscriptionError with the text so far.
+func transcribeSpeechRegions(config Config, audioFilePath string) (string, error) {
+	if err := requireFFmpeg("-vad"); err != nil {
+		return "", err
+	}
+	if err := requireFFprobe("-vad"); err != nil {
+		return "", err
+	}
+
+	duration, err := probeDuration(audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	total := duration.Seconds()
+	regions, err := detectSpeechRegions(audioFilePath, total)
+	if err != nil {
+		return "", err
+	}
+	if len(regions) == 0 {
+		log.Println("No speech detected, nothing to transcribe")
+		return "", nil
+	}
+
+	var speech float64
+	for _, r := range regions {
+		speech += r.End - r.Start
+	}
+	log.Printf("Detected %d speech regions covering %.0fs of %.0fs\n", len(regions), speech, total)
+
+	var parts []string
+	previousText := ""
+	for i, region := range regions {
+		log.Printf("Transcribing region %d/%d (%s-%s)...\n", i+1, len(regions), formatTimestamp(region.Start), formatTimestamp(region.End))
+
+		transcription, err := transcribeRegion(config, audioFilePath, "vad", region, chunkForm(config, previousText, nil))
+		if err != nil {
+			err = fmt.Errorf("transcribing region %d: %w", i+1, err)
+			if len(parts) == 0 {
+				return "", err
+			}
+			partial := TranscriptionResponse{Text: strings.Join(parts, "\n"), Duration: region.Start}
+			return "", &partialTranscriptionError{transcription: partial, done: i, total: len(regions), units: "regions", err: err}
+		}
+		text := strings.TrimSpace(transcription.Text)
+		if text != "" {
+			previousText = text
+			parts = append(parts, fmt.Sprintf("[%s] %s", formatTimestamp(region.Start), text))
+		}
+	}
+
+	return strings.Join(parts, "\n"), nil
+}
+
+func detectSpeechRegions(audioFilePath string, total float64) ([]audioRegion, error) {
+	output, err := runSilenceDetect(audioFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return speechRegions(output, total), nil
+}
+
+func runSilenceDetect(audioFilePath string) (string, error) {
+	filter := fmt.Sprintf("silencedetect=noise=%s:d=%.1f", vadNoiseThreshold, vadMinSilence)
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-nostats", "-i", audioFilePath, "-af", filter, "-f", "null", "-")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("running ffmpeg silencedetect: %w\n%s", err, output)
+	}
+	return string(output), nil
+}
+
+// speechRegions inverts the silence intervals reported by silencedetect
+// into padded speech regions.
+func speechRegions(silencedetectOutput string, total float64) []audioRegion {
+	var regions []audioRegion
+	cursor := 0.0
+
+	starts := silenceStartPattern.FindAllStringSubmatch(silencedetectOutput, -1)
+	ends := silenceEndPattern.FindAllStringSubmatch(silencedetectOutput, -1)
+	for i, m := range starts {
+		silenceStart, _ := strconv.ParseFloat(m[1], 64)
+		if silenceStart > cursor {
+			regions = append(regions, padRegion(cursor, silenceStart, total))
+		}
+		if i >= len(ends) {
+			// Silence runs to the end of the file.
+			return regions
+		}
+		cursor, _ = strconv.ParseFloat(ends[i][1], 64)
+	}
+
+	if cursor < total {
+		regions = append(regions, padRegion(cursor, total, total))
+	}
+	return regions
+}
+
+func padRegion(start, end, total float64) audioRegion {
+	return audioRegion{
+		Start: max(0, start-vadPadding),
+		End:   min(total, end+vadPadding),
+	}
+}
+
+func extractRegion(audioFilePath string, region audioRegion) (string, error) {
+	tmpPath, err := createTempFile("region", filepath.Ext(audioFilePath))
+	if err != nil {
+		return "", err
+	}
+
+	err = runFFmpeg("-ss", strconv.FormatFloat(region.Start, 'f', 3, 64),
+		"-t", strconv.FormatFloat(region.End-region.Start, 'f', 3, 64),
+		"-i", audioFilePath, tmpPath)
+	if err != nil {
+		removeTempFile(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// readRegion extracts region to a temp file and returns its bytes.
+func readRegion(audioFilePath string, region audioRegion) ([]byte, error) {
+	regionPath, err := extractRegion(audioFilePath, region)
+	if err != nil {
+		return nil, err
+	}
+	defer removeTempFile(regionPath)
+
+	audioBytes, err := os.ReadFile(regionPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading audio region: %w", err)
+	}
+	return audioBytes, nil
+}
+
+func formatTimestamp(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
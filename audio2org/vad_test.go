@@ -0,0 +1,150 @@
+package audio2org
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSpeechRegions(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []audioRegion
+	}{
+		{"no silence", "size=N/A time=00:00:30.00 bitrate=N/A\n", []audioRegion{{0, 30}}},
+		{
+			"silence in the middle",
+			"[silencedetect @ 0x1] silence_start: 10.5\n[silencedetect @ 0x1] silence_end: 14 | silence_duration: 3.5\n",
+			[]audioRegion{{0, 10.75}, {13.75, 30}},
+		},
+		{
+			"leading silence",
+			"[silencedetect @ 0x1] silence_start: 0\n[silencedetect @ 0x1] silence_end: 4.2 | silence_duration: 4.2\n",
+			[]audioRegion{{3.95, 30}},
+		},
+		{
+			"negative start from ffmpeg",
+			"[silencedetect @ 0x1] silence_start: -0.01\n[silencedetect @ 0x1] silence_end: 2 | silence_duration: 2.01\n",
+			[]audioRegion{{1.75, 30}},
+		},
+		{
+			"silence to the end without silence_end",
+			"[silencedetect @ 0x1] silence_start: 5\n[silencedetect @ 0x1] silence_end: 8 | silence_duration: 3\n[silencedetect @ 0x1] silence_start: 25\n",
+			[]audioRegion{{0, 5.25}, {7.75, 25.25}},
+		},
+		{
+			"silence ending at the end",
+			"[silencedetect @ 0x1] silence_start: 20\n[silencedetect @ 0x1] silence_end: 30 | silence_duration: 10\n",
+			[]audioRegion{{0, 20.25}},
+		},
+		{
+			"all silence",
+			"[silencedetect @ 0x1] silence_start: 0\n[silencedetect @ 0x1] silence_end: 30 | silence_duration: 30\n",
+			nil,
+		},
+		{
+			"padding clamped to the file",
+			"[silencedetect @ 0x1] silence_start: 29.9\n",
+			[]audioRegion{{0, 30}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := speechRegions(tt.output, 30); !slices.Equal(got, tt.want) {
+				t.Errorf("speechRegions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "00:00:00"},
+		{59.6, "00:01:00"},
+		{754.2, "00:12:34"},
+		{3725, "01:02:05"},
+	}
+
+	for _, tt := range tests {
+		if got := formatTimestamp(tt.seconds); got != tt.want {
+			t.Errorf("formatTimestamp(%v) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestProcessTranscriptionWritesPartialRegions(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*Config)
+		failOn    int
+		wantError string
+		want      string
+	}{
+		{
+			name:      "vad",
+			modify:    func(c *Config) { c.VAD = true },
+			failOn:    3,
+			wantError: "transcribing region 3",
+			want:      incompleteMarker + " Regions 1-2 of 3, up to 00:00:45; stopped by: transcribing region 3: Whisper API returned 400 Bad Request: {\"error\": {\"message\": \"bad region\"}}\n\n[00:00:00] Part 1.\n[00:00:25] Part 2.\n",
+		},
+		{
+			name:      "multilang",
+			modify:    func(c *Config) { c.Multilang = true },
+			failOn:    2,
+			wantError: "transcribing chunk 2",
+			want:      incompleteMarker + " Chunks 1-1 of 2, up to 00:00:30; stopped by: transcribing chunk 2: Whisper API returned 400 Bad Request: {\"error\": {\"message\": \"bad region\"}}\n\n[en] Part 1.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeChunkTools(t)
+			t.Setenv("FAKE_SILENCES", "silence_start: 20\nsilence_end: 25\nsilence_start: 40\nsilence_end: 45\n")
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == tt.failOn {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error": {"message": "bad region"}}`))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"text": "Part %d.", "language": "en"}`, requests)
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			config := validConfig()
+			config.TranscriptionFilePath = ""
+			config.AudioFilePath = filepath.Join(dir, "talk.mp3")
+			config.OutputDir = filepath.Join(dir, "output")
+			config.OpenAIAPIKey = "key"
+			config.BaseURL = server.URL
+			config.NoCache = true
+			config.RetryLog = "quiet"
+			tt.modify(&config)
+			writeSparseAudio(t, config.AudioFilePath, 1024)
+
+			if _, _, err := processTranscription(config); err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("processTranscription() = %v, want the %q error", err, tt.wantError)
+			}
+			data, err := os.ReadFile(filepath.Join(config.OutputDir, "talk_incomplete.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("partial transcript =\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
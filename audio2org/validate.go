@@ -0,0 +1,330 @@
+package audio2org
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// configErrors lists every problem validateConfig found, so they can all be
+// fixed before the next run instead of one at a time.
+type configErrors []error
+
+func (errs configErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problems with the configuration:", len(errs))
+	for _, err := range errs {
+		b.WriteString("\n  - ")
+		b.WriteString(strings.ReplaceAll(err.Error(), "\n", "\n    "))
+	}
+	return b.String()
+}
+
+func (errs configErrors) Unwrap() []error {
+	return errs
+}
+
+// validateConfig checks the flags and the environment variables the run
+// needs before any input is read, and returns every problem at once.
+func validateConfig(config Config) error {
+	var errs configErrors
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	// -check reads the key itself, and the other modes here call no API.
+	inputs := audioInputs(config)
+	hasInput := len(inputs) > 0 || config.TranscriptionFilePath != ""
+	if needsAPIKey(config) && hasInput && !config.Check && !config.DryRun && !config.Info && !config.FormatCheck {
+		if key, err := apiKey(config); err != nil {
+			check(err)
+		} else if key == "" {
+			check(errNoAPIKey)
+		}
+	}
+	check(checkInputs(config))
+	for _, input := range inputs {
+		input := batchFileConfig(config, input)
+		if err := checkStdinInput(input); err != nil {
+			check(err)
+			break
+		}
+		if err := checkURLInput(input); err != nil {
+			check(err)
+			break
+		}
+	}
+	if config.Info && len(inputs) == 0 {
+		fail("-info requires -file")
+	}
+	if config.FormatCheck && len(inputs) == 0 {
+		fail("-format-check requires -file")
+	}
+	for _, ext := range parseExtensions(config.Extensions) {
+		if !slices.Contains(supportedExtensions, ext) && !config.Transcode {
+			fail("-ext %s is not a format Whisper accepts: %s", ext, strings.Join(supportedExtensions, " "))
+		}
+	}
+	if config.Since != "" {
+		if len(inputs) == 0 {
+			fail("-since filters the -file inputs and requires -file")
+		}
+		_, err := parseSince(config.Since, time.Now())
+		check(err)
+	}
+	if config.Limit < 0 {
+		fail("-limit must not be negative, got %d", config.Limit)
+	} else if config.Limit > 0 && len(inputs) == 0 {
+		fail("-limit caps the -file inputs and requires -file")
+	}
+	if config.Concurrency < 1 {
+		fail("-concurrency must be at least 1, got %d", config.Concurrency)
+	}
+	if config.Manifest != "" && len(inputs) == 0 {
+		fail("-manifest tracks the progress of -file inputs and requires -file")
+	}
+	if config.Manifest != "" && config.Bench {
+		fail("-manifest cannot be combined with -bench, which times every input")
+	}
+	check(checkOrgIndex(config, inputs))
+	check(checkMergeOutputs(config, inputs))
+	check(checkStdout(config))
+	if config.NoEnv && config.EnvFile != "" {
+		fail("-no-env and -env-file cannot be combined")
+	}
+	if config.RPM < 0 {
+		fail("-rpm must not be negative, got %g", config.RPM)
+	}
+	if config.ShutdownGrace <= 0 {
+		fail("-shutdown-grace must be positive, got %s", config.ShutdownGrace)
+	}
+
+	if strings.TrimSpace(config.OutputDir) == "" {
+		fail("-output-dir is empty")
+	}
+	if config.HeadingOffset < 0 {
+		fail("-heading-offset must not be negative")
+	}
+	if _, ok := orgDateLayouts[config.OrgDateStyle]; !ok {
+		fail("unknown -org-date-style %q: expected active, inactive, or iso", config.OrgDateStyle)
+	}
+	if config.RecordingDate != "" {
+		if _, err := time.Parse(recordingDateLayout, config.RecordingDate); err != nil {
+			fail("invalid -recording-date %q: expected YYYY-MM-DD", config.RecordingDate)
+		}
+	}
+	if _, ok := transcriptStylePrompts[config.TranscriptStyle]; config.TranscriptStyle != "" && !ok {
+		fail("unknown -transcript-style %q: expected formal or verbatim", config.TranscriptStyle)
+	}
+	if config.MaxChunkMB < 1 || config.MaxChunkMB > maxUploadMB {
+		fail("-max-chunk-mb must be between 1 and %d, the Whisper upload limit", maxUploadMB)
+	}
+
+	check(checkBaseURL(config.BaseURL))
+	switch config.AuthHeader {
+	case "bearer", "api-key":
+	default:
+		fail("unknown -auth-header %q: expected bearer or api-key", config.AuthHeader)
+	}
+	if _, ok := providers[config.Provider]; !ok {
+		fail("unknown -provider %q: expected openai or compatible", config.Provider)
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"-timeout", config.Timeout},
+		{"-connect-timeout", config.ConnectTimeout},
+		{"-tls-handshake-timeout", config.TLSHandshakeTimeout},
+	} {
+		if timeout.value <= 0 {
+			fail("%s must be positive, got %s", timeout.name, timeout.value)
+		}
+	}
+	if config.ResponseHeaderTimeout < 0 {
+		fail("-response-header-timeout must not be negative, got %s", config.ResponseHeaderTimeout)
+	}
+	if config.MaxRetries < 0 {
+		fail("-max-retries must not be negative, got %d", config.MaxRetries)
+	}
+	if config.RetryBaseDelay <= 0 {
+		fail("-retry-base-delay must be positive, got %s", config.RetryBaseDelay)
+	}
+	if config.RetryBudget < 0 {
+		fail("-retry-budget must not be negative, got %s", config.RetryBudget)
+	}
+	switch config.RetryLog {
+	case "quiet", "normal", "verbose":
+	default:
+		fail("unknown -retry-log %q: expected quiet, normal, or verbose", config.RetryLog)
+	}
+	if config.GibberishThreshold <= 0 || config.GibberishThreshold > 1 {
+		fail("-gibberish-threshold must be above 0 and at most 1, got %g", config.GibberishThreshold)
+	}
+
+	if !slices.Contains(backends, config.Backend) {
+		fail("unknown -backend %q: expected openai or local", config.Backend)
+	} else {
+		check(checkLocalBackend(config))
+	}
+
+	if !slices.Contains(transcribeModels, config.TranscribeModel) {
+		fail("unknown -transcribe-model %q: expected one of %s", config.TranscribeModel, strings.Join(transcribeModels, ", "))
+	}
+	if config.TranscribeModel != "whisper-1" && needsSegments(config) {
+		fail("-transcribe-model %s does not return the segment timing needed by %s; use whisper-1",
+			config.TranscribeModel, strings.Join(segmentFeatures(config), ", "))
+	}
+	if config.TranscribeModel != "whisper-1" && config.Multilang {
+		fail("-transcribe-model %s does not report the detected language that -multilang needs; use whisper-1", config.TranscribeModel)
+	}
+	check(checkTranslate(config))
+	check(checkDropLowConfidence(config))
+	check(checkWhisperResponseFormat(config))
+	check(checkUploadFilename(config))
+
+	if strings.TrimSpace(config.SummaryModel) == "" {
+		fail("-summary-model is empty")
+	}
+	check(checkFallbackSummaryModel(config))
+	if config.MaxTokens <= 0 {
+		fail("-max-tokens must be positive, got %d", config.MaxTokens)
+	}
+	if config.Temperature < 0 || config.Temperature > 2 {
+		fail("-temperature must be between 0.0 and 2.0, got %g", config.Temperature)
+	}
+
+	if config.Language != "" && config.Multilang {
+		fail("-language cannot be combined with -multilang, which detects the language of each chunk")
+	}
+	if config.VAD && config.Multilang {
+		fail("-vad and -multilang cannot be combined")
+	}
+
+	switch config.Format {
+	case "text", "srt", "vtt", "json":
+	default:
+		fail("unknown -format %q: expected text, srt, vtt, or json", config.Format)
+	}
+	if config.Timestamps && config.Format != "text" {
+		fail("-timestamps applies to the text transcript and cannot be combined with -format %s", config.Format)
+	}
+	if config.PrependMetadata && config.Format != "text" {
+		fail("-prepend-metadata applies to the text transcript and cannot be combined with -format %s", config.Format)
+	}
+	if needsSegments(config) && (len(inputs) == 0 || config.VAD || config.Multilang) {
+		fail("%s need segment timing, which requires -file and cannot be combined with -vad or -multilang",
+			strings.Join(segmentFeatures(config), ", "))
+	}
+
+	if config.MinTranscriptChars < 0 {
+		fail("-min-transcript-chars must not be negative, got %d", config.MinTranscriptChars)
+	}
+
+	if config.Cards < 1 || config.Cards > maxFlashcards {
+		fail("-cards must be between 1 and %d, got %d", maxFlashcards, config.Cards)
+	}
+	if _, ok := cardDifficultyPrompts[config.CardDifficulty]; !ok {
+		fail("unknown -card-difficulty %q: expected basic, intermediate, or advanced", config.CardDifficulty)
+	}
+
+	check(checkSummaryChunkTokens(config))
+	check(checkPostSteps("-post", config.PostProcessCmd))
+	if config.SummaryLanguages != "" && !hasPostStep(config, "create_emacs_org_notes") {
+		fail("-summary-languages requires -post create_emacs_org_notes")
+	}
+	for _, language := range summaryLanguages(config) {
+		if !languageCodePattern.MatchString(language) {
+			fail("invalid -summary-languages code %q: expected codes like en, es, or pt-BR", language)
+		}
+	}
+	if config.SummarizerCmd != "" && len(strings.Fields(config.SummarizerCmd)) == 0 {
+		fail("-summarizer-cmd is empty")
+	}
+
+	if config.WordTimestamps && config.RedactPII {
+		fail("-word-timestamps cannot be combined with -redact-pii, which does not redact the individual words")
+	}
+	if config.RedactPIIChat {
+		if !config.RedactPII {
+			fail("-redact-pii-chat requires -redact-pii")
+		}
+		if needsSegments(config) {
+			fail("-redact-pii-chat only redacts the plain text, so it cannot be combined with %s, which use the segment text", strings.Join(segmentFeatures(config), ", "))
+		}
+	}
+	check(checkRedact(config))
+	check(checkOrgPathTemplate(config))
+	check(checkAppend(config))
+	check(checkBundle(config))
+	check(checkStream(config))
+	if config.PromptTemplate != "" && !hasPostStep(config, "create_emacs_org_notes") {
+		fail("-prompt-template requires -post create_emacs_org_notes")
+	}
+	if (config.SystemPrompt != "" || config.SystemPromptFile != "") && !hasPostStep(config, "create_emacs_org_notes") {
+		fail("-system-prompt requires -post create_emacs_org_notes")
+	}
+	check(checkOrgTags(config))
+	if (config.OrgTags != "" || config.ExtractTodos) && !hasPostStep(config, "create_emacs_org_notes") {
+		fail("-org-tags and -extract-todos require -post create_emacs_org_notes")
+	}
+	if config.AppendTranscript && !hasPostStep(config, "create_emacs_org_notes") {
+		fail("-append-transcript requires -post create_emacs_org_notes")
+	}
+	if (config.Context != "" || config.ContextFile != "") && !hasPostStep(config, "create_emacs_org_notes") && !hasPostStep(config, "create_markdown_notes") {
+		fail("-context requires -post create_emacs_org_notes or create_markdown_notes")
+	}
+	check(checkDetailLevel(config))
+	if isFlagSet("detail-level") && !hasPostStep(config, "create_emacs_org_notes") && !hasPostStep(config, "create_markdown_notes") {
+		fail("-detail-level requires -post create_emacs_org_notes or create_markdown_notes")
+	}
+
+	if config.NoOutput && config.OutputURI != "" {
+		fail("-no-output and -output-uri cannot be combined")
+	}
+	switch config.Versioning {
+	case "", "overwrite", "timestamp", "increment":
+	default:
+		fail("unknown -versioning %q: expected overwrite, timestamp, or increment", config.Versioning)
+	}
+	check(checkOverwrite(config))
+	if config.Versioning == "increment" && config.OutputURI != "" {
+		fail("-versioning increment cannot check for existing objects with -output-uri")
+	}
+	check(checkNoTranscriptFile(config))
+	check(checkExec(config))
+	if config.WebhookURL != "" && !strings.HasPrefix(config.WebhookURL, "http://") && !strings.HasPrefix(config.WebhookURL, "https://") {
+		fail("unsupported -webhook-url %q: expected an http:// or https:// URL", config.WebhookURL)
+	}
+	if config.OutputURI != "" && !isCloudURI(config.OutputURI) {
+		fail("unsupported -output-uri %q: only s3:// URIs are supported", config.OutputURI)
+	}
+
+	if config.Sample > 0 && len(inputs) == 0 {
+		fail("-sample requires -file")
+	}
+	if config.Compare != "" {
+		if len(inputs) == 0 {
+			fail("-compare requires -file")
+		}
+		if config.VAD || config.Multilang {
+			fail("-compare transcribes the whole file and cannot be combined with -vad or -multilang")
+		}
+		_, err := compareModels(config.Compare)
+		check(err)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,119 @@
+package audio2org
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() Config {
+	return Config{
+		TranscriptionFilePath: "notes/talk.txt",
+		OutputDir:             "output",
+		OrgDateStyle:          "active",
+		MaxChunkMB:            24,
+		BaseURL:               defaultBaseURL,
+		AuthHeader:            "bearer",
+		Provider:              "openai",
+		Concurrency:           1,
+		ShutdownGrace:         25 * time.Second,
+		Timeout:               10 * time.Minute,
+		ConnectTimeout:        30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		RetryBaseDelay:        time.Second,
+		RetryLog:              "normal",
+		GibberishThreshold:    0.5,
+		Backend:               "openai",
+		TranscribeModel:       "whisper-1",
+		DetailLevel:           "normal",
+		SummaryModel:          "gpt-4o",
+		MaxTokens:             3000,
+		Temperature:           0.7,
+		Format:                "text",
+		Cards:                 20,
+		CardDifficulty:        "intermediate",
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	if err := validateConfig(validConfig()); err != nil {
+		t.Fatalf("validateConfig() of the defaults = %v", err)
+	}
+
+	config := validConfig()
+	config.Format = "docx"
+	if err := validateConfig(config); err == nil || err.Error() != `unknown -format "docx": expected text, srt, vtt, or json` {
+		t.Errorf("validateConfig() with one problem = %v, want only that problem", err)
+	}
+}
+
+func TestValidateConfigListsEveryProblem(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	config := validConfig()
+	config.MaxTokens = 0
+	config.Temperature = 3
+	config.PostProcessCmd = "create_glossary"
+	config.SummaryLanguages = "en"
+
+	err := validateConfig(config)
+	if err == nil {
+		t.Fatal("validateConfig() = nil, want errors")
+	}
+	for _, want := range []string{
+		"4 problems with the configuration:",
+		"\n  - OPENAI_API_KEY not set in environment",
+		"\n  - -max-tokens must be positive, got 0",
+		"\n  - -temperature must be between 0.0 and 2.0, got 3",
+		"\n  - -summary-languages requires -post create_emacs_org_notes",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validateConfig() = %q, want it to contain %q", err, want)
+		}
+	}
+
+	var errs configErrors
+	if !errors.As(err, &errs) || len(errs) != 4 {
+		t.Errorf("validateConfig() is not a configErrors of 4 problems: %#v", err)
+	}
+}
+
+func TestValidateConfigRunFlags(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"negative -rpm", func(c *Config) { c.RPM = -1 }, "-rpm must not be negative, got -1"},
+		{"-no-env with -env-file", func(c *Config) { c.NoEnv, c.EnvFile = true, "prod.env" }, "-no-env and -env-file cannot be combined"},
+		{"zero -shutdown-grace", func(c *Config) { c.ShutdownGrace = 0 }, "-shutdown-grace must be positive, got 0s"},
+		{"zero -concurrency", func(c *Config) { c.Concurrency = 0 }, "-concurrency must be at least 1, got 0"},
+		{"-limit without -file", func(c *Config) { c.Limit = 2 }, "-limit caps the -file inputs and requires -file"},
+		{"-since without -file", func(c *Config) { c.Since = "7d" }, "-since filters the -file inputs and requires -file"},
+		{"-info without -file", func(c *Config) { c.Info = true }, "-info requires -file"},
+		{
+			"stdin among the -file arguments",
+			func(c *Config) { c.TranscriptionFilePath, c.AudioFiles = "", fileFlags{"talk.mp3", "-"} },
+			"-file - needs -stdin-format",
+		},
+		{
+			"-manifest with -bench",
+			func(c *Config) {
+				c.TranscriptionFilePath, c.AudioFiles, c.Manifest, c.Bench = "", fileFlags{"talks"}, "done.json", true
+			},
+			"-manifest cannot be combined with -bench",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig()
+			tt.modify(&config)
+			if err := validateConfig(config); err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("validateConfig() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package audio2org
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set at build time with
+//
+//	go build -ldflags "-X github.com/bashhack/go_transcribe/audio2org.version=v1.2.0 -X github.com/bashhack/go_transcribe/audio2org.commit=$(git rev-parse --short HEAD) -X github.com/bashhack/go_transcribe/audio2org.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// A plain go build in a git checkout still records the commit and its time,
+// which versionString falls back to.
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// versionString is what -version prints, e.g.
+// "go-audio2org v1.2.0 (commit 1a2b3c4, built 2024-05-06T14:30:00Z, go1.21.3)".
+func versionString() string {
+	revision, date, modified := commit, buildDate, false
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if revision == "" && len(setting.Value) >= 7 {
+					revision = setting.Value[:7]
+				}
+			case "vcs.time":
+				if date == "" {
+					date = setting.Value
+				}
+			case "vcs.modified":
+				modified = commit == "" && setting.Value == "true"
+			}
+		}
+	}
+	if revision == "" {
+		revision = "unknown"
+	} else if modified {
+		revision += "-dirty"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("go-audio2org %s (commit %s, built %s, %s)", version, revision, date, runtime.Version())
+}
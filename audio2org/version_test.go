@@ -0,0 +1,16 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersionString(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "v1.2.0", "1a2b3c4", "2024-05-06T14:30:00Z"
+
+	want := "go-audio2org v1.2.0 (commit 1a2b3c4, built 2024-05-06T14:30:00Z, go"
+	if got := versionString(); !strings.HasPrefix(got, want) {
+		t.Errorf("versionString() = %q, want it to start with %q", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package audio2org
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Whisper only reads about 224 tokens of prompt. The vocabulary prompt is
+// kept to this many characters, roughly 170 tokens of English, so that the
+// tail of the previous chunk still fits when a recording is split.
+const maxVocabPromptChars = 600
+
+// loadVocabPrompt returns the -vocab-prompt, or the contents of
+// -vocab-prompt-file, cut at a word boundary when it is too long for
+// Whisper to read in full.
+func loadVocabPrompt(config Config) (string, error) {
+	prompt := config.VocabPrompt
+	if config.VocabPromptFile != "" {
+		if prompt != "" {
+			return "", errors.New("specify only one of -vocab-prompt or -vocab-prompt-file")
+		}
+		data, err := os.ReadFile(config.VocabPromptFile)
+		if err != nil {
+			return "", fmt.Errorf("reading -vocab-prompt-file: %w", err)
+		}
+		prompt = string(data)
+	}
+
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	if len(prompt) > maxVocabPromptChars {
+		prompt = truncateText(prompt, maxVocabPromptChars)
+		log.Printf("Warning: the vocabulary prompt is longer than the %d characters Whisper reads; using only %q\n", maxVocabPromptChars, prompt)
+	}
+	return prompt, nil
+}
+
+// whisperPrompt is the prompt sent with every transcription request: the
+// vocabulary prompt followed by the -transcript-style preset.
+func whisperPrompt(config Config) string {
+	var parts []string
+	if config.VocabPrompt != "" {
+		parts = append(parts, config.VocabPrompt)
+	}
+	if style := transcriptStylePrompts[config.TranscriptStyle]; style != "" {
+		parts = append(parts, style)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,46 @@
+package audio2org
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadVocabPrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	if err := os.WriteFile(path, []byte("Okonkwo\ntachycardia\n  SVT\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadVocabPrompt(Config{VocabPromptFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Okonkwo tachycardia SVT" {
+		t.Errorf("loadVocabPrompt() = %q, want the file's words on one line", got)
+	}
+
+	long := strings.Repeat("electrocardiogram ", 50)
+	got, err = loadVocabPrompt(Config{VocabPrompt: long})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) > maxVocabPromptChars || !strings.HasSuffix(got, "electrocardiogram") {
+		t.Errorf("loadVocabPrompt() = %q, want it cut at a word within %d characters", got, maxVocabPromptChars)
+	}
+
+	if _, err := loadVocabPrompt(Config{VocabPrompt: "SVT", VocabPromptFile: path}); err == nil {
+		t.Error("loadVocabPrompt() accepted both -vocab-prompt and -vocab-prompt-file")
+	}
+}
+
+func TestWhisperPrompt(t *testing.T) {
+	config := Config{VocabPrompt: "Okonkwo, SVT", TranscriptStyle: "formal"}
+	if got, want := whisperPrompt(config), "Okonkwo, SVT "+transcriptStylePrompts["formal"]; got != want {
+		t.Errorf("whisperPrompt() = %q, want %q", got, want)
+	}
+	if got := whisperPrompt(Config{}); got != "" {
+		t.Errorf("whisperPrompt() = %q without a prompt, want empty", got)
+	}
+}
@@ -0,0 +1,76 @@
+package audio2org
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// headerFlags collects repeated -webhook-header "Name: value" flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	if name, _, ok := strings.Cut(value, ":"); !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+type webhookPayload struct {
+	CreatedAt      string  `json:"created_at"`
+	Source         string  `json:"source"`
+	TranscriptPath string  `json:"transcript_path,omitempty"`
+	PostCommand    string  `json:"post_command,omitempty"`
+	Notes          string  `json:"notes,omitempty"`
+	Transcript     string  `json:"transcript"`
+	Language       string  `json:"language,omitempty"`
+	DurationSecs   float64 `json:"duration_secs,omitempty"`
+}
+
+func postWebhook(config Config, transcription TranscriptionResponse, transcriptPath, notes string) error {
+	source := inputSource(config)
+	if !transcriptOnDisk(config) {
+		transcriptPath = ""
+	}
+
+	payload := webhookPayload{
+		CreatedAt:      time.Now().UTC().Format(time.RFC3339),
+		Source:         source,
+		TranscriptPath: transcriptPath,
+		PostCommand:    config.PostProcessCmd,
+		Notes:          notes,
+		Transcript:     transcription.Text,
+		Language:       transcription.Language,
+		DurationSecs:   transcription.Duration,
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return err
+	}
+	request := client.R().
+		SetContext(runContext(config)).
+		SetHeader("Content-Type", "application/json").
+		SetBody(payload)
+	for _, header := range config.WebhookHeaders {
+		name, value, _ := strings.Cut(header, ":")
+		request.SetHeader(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	log.Printf("Posting results to webhook %s...\n", config.WebhookURL)
+	resp, err := request.Post(config.WebhookURL)
+	if err != nil {
+		return fmt.Errorf("posting to webhook: %w", err)
+	}
+	if resp.IsError() {
+		return fmt.Errorf("webhook responded with %s\n%s", resp.Status(), resp.String())
+	}
+	log.Printf("Webhook responded with %s\n", resp.Status())
+	return nil
+}
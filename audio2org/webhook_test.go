@@ -0,0 +1,51 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostWebhook(t *testing.T) {
+	var got webhookPayload
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	config := Config{
+		TranscriptionFilePath: "notes/talk.txt",
+		PostProcessCmd:        "create_emacs_org_notes",
+		WebhookURL:            server.URL,
+		WebhookHeaders:        headerFlags{"Authorization: Bearer secret"},
+	}
+	if err := postWebhook(config, TranscriptionResponse{Text: "hello"}, "notes/talk.txt", "* Notes"); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+	}
+	if got.Source != "notes/talk.txt" || got.Notes != "* Notes" || got.Transcript != "hello" || got.PostCommand != "create_emacs_org_notes" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+}
+
+func TestHeaderFlagsSet(t *testing.T) {
+	var h headerFlags
+	if err := h.Set("X-Token: abc"); err != nil {
+		t.Errorf("Set() error = %v", err)
+	}
+	if err := h.Set("no colon"); err == nil {
+		t.Error("Set() accepted a header without a colon")
+	}
+	if len(h) != 1 {
+		t.Errorf("len(h) = %d, want 1", len(h))
+	}
+}
@@ -0,0 +1,229 @@
+package audio2org
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// whisper.cpp reads these formats itself; anything else is converted to
+// 16 kHz mono WAV with ffmpeg first.
+var whisperCppFormats = []string{".wav", ".mp3", ".flac", ".ogg"}
+
+var whisperCppLinePattern = regexp.MustCompile(`^\[(\d+):(\d{2}):(\d{2})[.,](\d{3}) --> (\d+):(\d{2}):(\d{2})[.,](\d{3})\]\s*(.*)$`)
+
+// LocalTranscriber runs a whisper.cpp binary on the audio, so nothing is
+// sent to OpenAI.
+type LocalTranscriber struct {
+	config Config
+}
+
+func (t LocalTranscriber) Transcribe(filePath string, audio io.ReadSeeker, extraForm map[string]string) (TranscriptionResponse, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	inputPath, err := createTempFile("local", ext)
+	if err != nil {
+		return TranscriptionResponse{}, err
+	}
+	defer removeTempFile(inputPath)
+	if err := writeAudioFile(inputPath, audio); err != nil {
+		return TranscriptionResponse{}, fmt.Errorf("writing audio for whisper.cpp: %w", err)
+	}
+
+	if ext != ".wav" {
+		if err := requireFFmpeg("-backend local with " + ext + " audio"); err == nil {
+			wavPath, err := createTempFile("local", ".wav")
+			if err != nil {
+				return TranscriptionResponse{}, err
+			}
+			defer removeTempFile(wavPath)
+			if err := runFFmpeg("-i", inputPath, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wavPath); err != nil {
+				return TranscriptionResponse{}, err
+			}
+			inputPath = wavPath
+		} else if !slices.Contains(whisperCppFormats, ext) {
+			return TranscriptionResponse{}, err
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runContext(t.config), t.config.WhisperCpp, whisperCppArgs(t.config, inputPath, extraForm)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	stopProgress, stopStage := startProgress("whisper.cpp"), timeStage(t.config, "whisper.cpp run")
+	err = cmd.Run()
+	stopStage()
+	stopProgress()
+	if err != nil {
+		return TranscriptionResponse{}, fmt.Errorf("running %s: %w\n%s", t.config.WhisperCpp, err, strings.TrimSpace(stderr.String()))
+	}
+
+	transcription, turns := parseWhisperCppOutput(stdout.String())
+	if t.config.Diarize {
+		labelSpeakerTurns(transcription.Segments, turns)
+	}
+	if transcription.Text == "" {
+		return transcription, fmt.Errorf("%s printed no transcription", t.config.WhisperCpp)
+	}
+	return transcription, nil
+}
+
+func writeAudioFile(path string, audio io.ReadSeeker) error {
+	if _, err := audio.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, audio); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// whisperCppArgs maps the run's options and the Whisper request options in
+// extraForm onto whisper.cpp flags. Timestamps are always printed, so the
+// segment options need no flag.
+func whisperCppArgs(config Config, inputPath string, extraForm map[string]string) []string {
+	language := config.Language
+	if language == "" {
+		language = "auto"
+	}
+	args := []string{"-m", config.WhisperModel, "-f", inputPath, "-l", language, "-np"}
+
+	prompt := whisperPrompt(config)
+	if p, ok := extraForm["prompt"]; ok {
+		prompt = p
+	}
+	if prompt != "" {
+		args = append(args, "--prompt", prompt)
+	}
+	if temperature, ok := extraForm["temperature"]; ok {
+		args = append(args, "-tp", temperature)
+	}
+	if config.Diarize {
+		args = append(args, "-tdrz")
+	}
+	if config.Translate {
+		args = append(args, "-tr")
+	}
+	return args
+}
+
+// parseWhisperCppOutput reads the lines whisper.cpp prints to stdout, of
+// the form "[00:00:01.000 --> 00:00:04.500]  text", into the text and its
+// segments. Lines without timestamps are added to the text only. turns
+// reports, for each segment, whether -tdrz marked a change of speaker
+// after it.
+func parseWhisperCppOutput(output string) (transcription TranscriptionResponse, turns []bool) {
+	var texts []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		match := whisperCppLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			texts = append(texts, line)
+			continue
+		}
+
+		text, turn := strings.CutSuffix(strings.TrimSpace(match[9]), whisperCppSpeakerTurn)
+		text = strings.TrimSpace(text)
+		if text == "" {
+			if turn && len(turns) > 0 {
+				turns[len(turns)-1] = true
+			}
+			continue
+		}
+		segment := TranscriptionSegment{
+			ID:    len(transcription.Segments),
+			Start: whisperCppSeconds(match[1:5]),
+			End:   whisperCppSeconds(match[5:9]),
+			Text:  text,
+		}
+		transcription.Segments = append(transcription.Segments, segment)
+		turns = append(turns, turn)
+		transcription.Duration = segment.End
+		texts = append(texts, text)
+	}
+	transcription.Text = strings.Join(texts, " ")
+	return transcription, turns
+}
+
+// whisperCppSeconds converts the hours, minutes, seconds, and milliseconds
+// matched from a timestamp into seconds.
+func whisperCppSeconds(parts []string) float64 {
+	var n [4]int
+	for i, part := range parts {
+		n[i], _ = strconv.Atoi(part)
+	}
+	return float64(n[0]*3600+n[1]*60+n[2]) + float64(n[3])/1000
+}
+
+// checkLocalBackend checks the -backend local options and rejects the
+// features that need the OpenAI transcription API.
+func checkLocalBackend(config Config) error {
+	if config.Backend != "local" {
+		if config.WhisperModel != "" {
+			return errors.New("-whisper-model requires -backend local")
+		}
+		if config.Diarize {
+			return fmt.Errorf("-diarize is unsupported for -backend %s, since Whisper does not label speakers; use -backend local with a tinydiarize model", config.Backend)
+		}
+		return nil
+	}
+
+	switch {
+	case config.WhisperModel == "":
+		return errors.New("-backend local needs -whisper-model, the path to a whisper.cpp ggml model file")
+	case isFlagSet("transcribe-model"):
+		return errors.New("-transcribe-model picks an OpenAI model; with -backend local, -whisper-model picks the model")
+	case config.Compare != "":
+		return errors.New("-compare compares OpenAI transcription models and cannot be combined with -backend local")
+	case config.Multilang:
+		return errors.New("-multilang needs the per-chunk language Whisper reports, which -backend local does not parse")
+	case len(config.WhisperParams) > 0:
+		return errors.New("-whisper-param adds fields to the Whisper API request and cannot be combined with -backend local")
+	case config.WordTimestamps:
+		return errors.New("-word-timestamps needs the word timing Whisper reports, which -backend local does not parse")
+	}
+	if _, err := os.Stat(config.WhisperModel); err != nil {
+		return fmt.Errorf("reading -whisper-model: %w", err)
+	}
+	if _, err := exec.LookPath(config.WhisperCpp); err != nil {
+		return fmt.Errorf("-backend local runs %s, but it was not found; set -whisper-cpp to the whisper.cpp binary", config.WhisperCpp)
+	}
+	return nil
+}
+
+// needsAPIKey reports whether the run calls the OpenAI API: always with the
+// openai backend, and with -backend local only for the features that use
+// the chat or speech API.
+func needsAPIKey(config Config) bool {
+	if config.Backend != "local" {
+		return true
+	}
+	for _, step := range postSteps(config) {
+		switch step {
+		case "create_org_transcript":
+		case "create_emacs_org_notes", "create_markdown_notes":
+			if config.SummarizerCmd == "" {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return config.TitleFromContent || config.InlineSummary || config.RedactPIIChat || config.SpeakSummary
+}
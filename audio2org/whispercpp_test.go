@@ -0,0 +1,104 @@
+package audio2org
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWhisperCppOutput(t *testing.T) {
+	output := `
+[00:00:00.000 --> 00:00:04.200]   Welcome back to the show.
+[00:00:04.200 --> 00:00:04.900]
+[00:00:04.900 --> 00:01:02.050]   Today we talk about compilers.
+`
+	got, _ := parseWhisperCppOutput(output)
+
+	want := TranscriptionResponse{
+		Text:     "Welcome back to the show. Today we talk about compilers.",
+		Duration: 62.05,
+		Segments: []TranscriptionSegment{
+			{ID: 0, Start: 0, End: 4.2, Text: "Welcome back to the show."},
+			{ID: 1, Start: 4.9, End: 62.05, Text: "Today we talk about compilers."},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWhisperCppOutput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseWhisperCppOutputWithoutTimestamps(t *testing.T) {
+	got, _ := parseWhisperCppOutput(" First sentence.\n Second sentence.\n")
+	if got.Text != "First sentence. Second sentence." || len(got.Segments) != 0 {
+		t.Errorf("parseWhisperCppOutput() = %+v, want the text without segments", got)
+	}
+}
+
+func TestWhisperCppArgs(t *testing.T) {
+	config := Config{WhisperModel: "ggml-base.en.bin", Language: "en", TranscriptStyle: "formal"}
+
+	got := whisperCppArgs(config, "/tmp/in.wav", map[string]string{"prompt": "previous chunk", "temperature": "0.4", "response_format": "verbose_json"})
+	want := []string{"-m", "ggml-base.en.bin", "-f", "/tmp/in.wav", "-l", "en", "-np", "--prompt", "previous chunk", "-tp", "0.4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("whisperCppArgs() = %q, want %q", got, want)
+	}
+
+	got = whisperCppArgs(Config{WhisperModel: "m.bin"}, "in.wav", nil)
+	want = []string{"-m", "m.bin", "-f", "in.wav", "-l", "auto", "-np"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("whisperCppArgs() = %q, want %q", got, want)
+	}
+
+	got = whisperCppArgs(Config{WhisperModel: "m.bin", Language: "es", Translate: true}, "in.wav", nil)
+	want = []string{"-m", "m.bin", "-f", "in.wav", "-l", "es", "-np", "-tr"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("whisperCppArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestNeedsAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   bool
+	}{
+		{"openai backend", Config{Backend: "openai"}, true},
+		{"local transcript only", Config{Backend: "local"}, false},
+		{"local org transcript", Config{Backend: "local", PostProcessCmd: "create_org_transcript"}, false},
+		{"local org notes with summarizer", Config{Backend: "local", PostProcessCmd: "create_emacs_org_notes", SummarizerCmd: "llm"}, false},
+		{"local org notes", Config{Backend: "local", PostProcessCmd: "create_emacs_org_notes"}, true},
+		{"local glossary", Config{Backend: "local", PostProcessCmd: "create_glossary"}, true},
+		{"local title", Config{Backend: "local", TitleFromContent: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsAPIKey(tt.config); got != tt.want {
+				t.Errorf("needsAPIKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWhisperCppOutputSpeakerTurns(t *testing.T) {
+	output := `
+[00:00:00.000 --> 00:00:03.000]   How did the trial go? [SPEAKER_TURN]
+[00:00:03.000 --> 00:00:06.000]   Better than expected.
+[00:00:06.000 --> 00:00:08.000]   We enrolled forty patients.
+[00:00:08.000 --> 00:00:08.500]   [SPEAKER_TURN]
+[00:00:08.500 --> 00:00:10.000]   That's great news.
+`
+	transcription, turns := parseWhisperCppOutput(output)
+	labelSpeakerTurns(transcription.Segments, turns)
+
+	var speakers []string
+	for _, segment := range transcription.Segments {
+		speakers = append(speakers, segment.Speaker)
+	}
+	want := []string{"Speaker 1", "Speaker 2", "Speaker 2", "Speaker 1"}
+	if !reflect.DeepEqual(speakers, want) {
+		t.Errorf("speakers = %q, want %q", speakers, want)
+	}
+	if strings.Contains(transcription.Text, "SPEAKER_TURN") {
+		t.Errorf("text %q still has the turn markers", transcription.Text)
+	}
+}
@@ -0,0 +1,41 @@
+package audio2org
+
+import (
+	"fmt"
+	"strings"
+)
+
+// paramFlags collects repeated -whisper-param key=value flags, the extra
+// form fields sent with each transcription request.
+type paramFlags []string
+
+func (p *paramFlags) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *paramFlags) Set(value string) error {
+	key, _, ok := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	switch {
+	case !ok || key == "":
+		return fmt.Errorf("expected key=value, got %q", value)
+	case key == "file":
+		return fmt.Errorf("%q is the uploaded audio and cannot be set", key)
+	}
+	if _, ok := p.form()[key]; ok {
+		return fmt.Errorf("%q is given more than once", key)
+	}
+	*p = append(*p, value)
+	return nil
+}
+
+// form returns the fields by key. The dedicated flags set their fields
+// after these, so they win over a -whisper-param with the same key.
+func (p paramFlags) form() map[string]string {
+	form := make(map[string]string, len(p))
+	for _, param := range p {
+		key, value, _ := strings.Cut(param, "=")
+		form[strings.TrimSpace(key)] = value
+	}
+	return form
+}
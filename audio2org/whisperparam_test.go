@@ -0,0 +1,64 @@
+package audio2org
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParamFlagsSet(t *testing.T) {
+	var params paramFlags
+	for _, value := range []string{"temperature=0.2", "prompt=", "hotwords=a=b"} {
+		if err := params.Set(value); err != nil {
+			t.Errorf("Set(%q) = %v, want nil", value, err)
+		}
+	}
+	tests := []struct {
+		value   string
+		wantErr string
+	}{
+		{"temperature", "expected key=value"},
+		{"=0.2", "expected key=value"},
+		{"file=other.mp3", "is the uploaded audio"},
+		{"temperature=0.5", `"temperature" is given more than once`},
+		{" temperature =0.5", `"temperature" is given more than once`},
+	}
+	for _, tt := range tests {
+		if err := params.Set(tt.value); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Set(%q) error = %v, want it to contain %q", tt.value, err, tt.wantErr)
+		}
+	}
+	if form := params.form(); len(form) != 3 || form["hotwords"] != "a=b" || form["prompt"] != "" {
+		t.Errorf("form() = %v", form)
+	}
+}
+
+func TestSendTranscriptionWhisperParams(t *testing.T) {
+	var fields map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			fields = r.MultipartForm.Value
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Hello there."}`))
+	}))
+	defer server.Close()
+
+	config := Config{
+		OpenAIAPIKey:    "test-key",
+		BaseURL:         server.URL + "/v1",
+		TranscribeModel: "whisper-1",
+		Language:        "en",
+		RetryLog:        "quiet",
+		WhisperParams:   paramFlags{"temperature=0.2", "language=de", "model=whisper-2"},
+	}
+	if _, err := sendTranscription(config, "talk.mp3", strings.NewReader("audio"), nil); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"temperature": "0.2", "language": "en", "model": "whisper-1"} {
+		if got := fields[key]; len(got) != 1 || got[0] != want {
+			t.Errorf("form field %s = %q, want %q", key, got, want)
+		}
+	}
+}
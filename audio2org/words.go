@@ -0,0 +1,55 @@
+package audio2org
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// wordTimestampForm asks for word timing as well as the segments, which
+// the other segment features use. Whisper takes each granularity as its own
+// timestamp_granularities[] field; transcriptionFormValues splits them.
+var wordTimestampForm = map[string]string{
+	"response_format":           "verbose_json",
+	"timestamp_granularities[]": "segment,word",
+}
+
+// TranscriptionWord is one word of a verbose_json transcription, with its
+// start and end in seconds.
+type TranscriptionWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// transcriptionFormValues turns the transcription form into the fields
+// sent, with one timestamp_granularities[] field per granularity.
+func transcriptionFormValues(formData map[string]string) url.Values {
+	values := url.Values{}
+	for key, value := range formData {
+		if key == "timestamp_granularities[]" {
+			values[key] = strings.Split(value, ",")
+			continue
+		}
+		values.Set(key, value)
+	}
+	return values
+}
+
+func generateWordsFilePath(transcriptPath string) string {
+	return generateDerivedFilePath(transcriptPath, "_words.json")
+}
+
+// writeWordTimestamps writes the -word-timestamps file next to the
+// transcript: a JSON array of every word with its start and end.
+func writeWordTimestamps(config Config, transcriptPath string, words []TranscriptionWord) error {
+	if words == nil {
+		words = []TranscriptionWord{}
+	}
+	data, err := json.MarshalIndent(words, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding word timestamps: %w", err)
+	}
+	return writeToFile(config, generateWordsFilePath(transcriptPath), string(data)+"\n")
+}
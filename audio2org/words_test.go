@@ -0,0 +1,67 @@
+package audio2org
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSendTranscriptionWordTimestamps(t *testing.T) {
+	var granularities []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			granularities = r.MultipartForm.Value["timestamp_granularities[]"]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Hello there.", "segments": [{"id": 0, "start": 0, "end": 1.1, "text": "Hello there."}],
+			"words": [{"word": "Hello", "start": 0, "end": 0.4}, {"word": "there", "start": 0.5, "end": 1.1}]}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIAPIKey: "test-key", BaseURL: server.URL + "/v1", TranscribeModel: "whisper-1", RetryLog: "quiet"}
+	got, err := sendTranscription(config, "talk.mp3", strings.NewReader("audio"), wordTimestampForm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"segment", "word"}; !reflect.DeepEqual(granularities, want) {
+		t.Errorf("timestamp_granularities[] = %q, want %q", granularities, want)
+	}
+	want := []TranscriptionWord{{"Hello", 0, 0.4}, {"there", 0.5, 1.1}}
+	if !reflect.DeepEqual(got.Words, want) || len(got.Segments) != 1 {
+		t.Errorf("sendTranscription() words = %+v, segments = %+v", got.Words, got.Segments)
+	}
+}
+
+func TestWriteWordTimestamps(t *testing.T) {
+	dir := t.TempDir()
+	transcriptPath := filepath.Join(dir, "talk.txt")
+	words := []TranscriptionWord{{"Hello", 0, 0.4}, {"there", 0.5, 1.1}}
+	if err := writeWordTimestamps(Config{}, transcriptPath, words); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "talk_words.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "word": "Hello",
+    "start": 0,
+    "end": 0.4
+  },
+  {
+    "word": "there",
+    "start": 0.5,
+    "end": 1.1
+  }
+]
+`
+	if string(data) != want {
+		t.Errorf("words file = %q, want %q", data, want)
+	}
+}
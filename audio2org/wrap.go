@@ -0,0 +1,165 @@
+package audio2org
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var listItemPattern = regexp.MustCompile(`^(\s*)([-+*]|\d+[.)])\s+`)
+
+// wrapText hard-wraps prose paragraphs to width columns. Structural lines
+// (headings, keywords, tables, drawers, source/example blocks and fenced
+// code) are passed through untouched so the org or markdown stays valid.
+func wrapText(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+
+	var out []string
+	var words []string
+	var firstPrefix, restPrefix string
+	inBlock := false
+	fence := ""
+
+	flush := func() {
+		if len(words) > 0 {
+			out = append(out, fillWords(words, firstPrefix, restPrefix, width)...)
+		}
+		words = nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		lower := strings.ToLower(trimmed)
+
+		if inBlock {
+			out = append(out, line)
+			if (fence == "" && strings.HasPrefix(lower, "#+end_")) || (fence != "" && strings.HasPrefix(trimmed, fence)) {
+				inBlock = false
+				fence = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(lower, "#+begin_"):
+			flush()
+			inBlock = true
+			out = append(out, line)
+			continue
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			inBlock = true
+			fence = trimmed[:3]
+			out = append(out, line)
+			continue
+		case isStructuralLine(line, trimmed):
+			flush()
+			out = append(out, line)
+			continue
+		}
+
+		if m := listItemPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			firstPrefix = m[0]
+			restPrefix = strings.Repeat(" ", utf8.RuneCountInString(m[0]))
+			words = strings.Fields(line[len(m[0]):])
+			continue
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if len(words) > 0 && (indent == restPrefix || (firstPrefix != restPrefix && len(indent) >= len(restPrefix))) {
+			words = append(words, strings.Fields(trimmed)...)
+			continue
+		}
+
+		flush()
+		firstPrefix = indent
+		restPrefix = indent
+		words = strings.Fields(trimmed)
+	}
+	flush()
+
+	return strings.Join(out, "\n")
+}
+
+func isStructuralLine(line, trimmed string) bool {
+	if trimmed == "" {
+		return true
+	}
+	if strings.HasPrefix(line, "*") && strings.HasPrefix(strings.TrimLeft(line, "*"), " ") {
+		return true
+	}
+	switch trimmed[0] {
+	case '#', '|', ':':
+		return true
+	}
+	for _, prefix := range []string{"CLOCK:", "SCHEDULED:", "DEADLINE:", "CLOSED:", "---", "===", "> "} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func fillWords(words []string, firstPrefix, restPrefix string, width int) []string {
+	var lines []string
+	var b strings.Builder
+	b.WriteString(firstPrefix)
+	lineLen := utf8.RuneCountInString(firstPrefix)
+	restLen := utf8.RuneCountInString(restPrefix)
+	empty := true
+
+	for _, w := range words {
+		wLen := utf8.RuneCountInString(w)
+
+		// A token that cannot fit on any line, such as text in a script
+		// written without spaces, is broken across lines instead of being
+		// left as one overlong line. Links are kept whole so they still work.
+		if avail := width - restLen; avail > 0 && wLen > avail && !isLinkToken(w) {
+			runes := []rune(w)
+			if !empty {
+				lines = append(lines, b.String())
+				b.Reset()
+				b.WriteString(restPrefix)
+				lineLen = restLen
+			}
+			for lineLen+len(runes) > width {
+				n := max(width-lineLen, 1)
+				b.WriteString(string(runes[:n]))
+				lines = append(lines, b.String())
+				runes = runes[n:]
+				b.Reset()
+				b.WriteString(restPrefix)
+				lineLen = restLen
+			}
+			b.WriteString(string(runes))
+			lineLen += len(runes)
+			empty = false
+			continue
+		}
+
+		if !empty && lineLen+1+wLen > width {
+			lines = append(lines, b.String())
+			b.Reset()
+			b.WriteString(restPrefix)
+			lineLen = restLen
+			empty = true
+		}
+		if !empty {
+			b.WriteByte(' ')
+			lineLen++
+		}
+		b.WriteString(w)
+		lineLen += wLen
+		empty = false
+	}
+	lines = append(lines, b.String())
+	return lines
+}
+
+func isLinkToken(w string) bool {
+	return strings.Contains(w, "[[") || strings.Contains(w, "://")
+}
@@ -0,0 +1,51 @@
+package audio2org
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"prose", "one two three four five", 10, "one two\nthree four\nfive"},
+		{"list item", "- one two three four", 10, "- one two\n  three\n  four"},
+		{"heading untouched", "* a heading that is long", 10, "* a heading that is long"},
+		{"no whitespace", "abcdefghijklmnopqrstuvwxy", 10, "abcdefghij\nklmnopqrst\nuvwxy"},
+		{"long token after words", "hi abcdefghijklmno ok", 10, "hi\nabcdefghij\nklmno ok"},
+		{"long token in a list", "- abcdefghijklmnop", 10, "- abcdefgh\n  ijklmnop"},
+		{"runes not bytes", "日本語のテキストです", 4, "日本語の\nテキスト\nです"},
+		{"links kept whole", "see [[https://example.com/a/very/long/path]] now", 10, "see\n[[https://example.com/a/very/long/path]]\nnow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.text, tt.width); got != tt.want {
+				t.Errorf("wrapText() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapTextLongInput(t *testing.T) {
+	text := strings.Repeat("x", 1<<20)
+
+	start := time.Now()
+	got := wrapText(text, 80)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("wrapText() took %s on a 1 MB token", elapsed)
+	}
+
+	lines := strings.Split(got, "\n")
+	if want := (1<<20 + 79) / 80; len(lines) != want {
+		t.Errorf("wrapText() produced %d lines, want %d", len(lines), want)
+	}
+	if strings.Join(lines, "") != text {
+		t.Error("wrapText() changed the text of the token")
+	}
+}